@@ -0,0 +1,228 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+var (
+	ErrProductNotFound  = errors.New("product not found in the catalog")
+	ErrDuplicateProduct = errors.New("product appears in the catalog more than once")
+	ErrMissingName      = errors.New("product must have a name")
+	ErrMissingSKU       = errors.New("product must have a sku")
+	ErrUnknownTaxClass  = errors.New("unknown tax class")
+	ErrUnknownUnit      = errors.New("unknown unit of measure")
+)
+
+// TaxClass decides which rate of VAT applies to a product
+type TaxClass string
+
+const (
+	TaxStandard TaxClass = "standard"
+	TaxReduced  TaxClass = "reduced"
+	TaxZero     TaxClass = "zero"
+)
+
+// Unit of measure a product is sold in
+type Unit string
+
+const (
+	UnitEach     Unit = "each"
+	UnitKilogram Unit = "kg"
+	UnitLitre    Unit = "litre"
+)
+
+// Product is everything we know about a sku apart from its price
+type Product struct {
+	SKU      sku.SKU  `json:"sku"`
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Tags     []string `json:"tags,omitempty"`
+	TaxClass TaxClass `json:"tax_class"`
+	Unit     Unit     `json:"unit"`
+	// discontinued products are no longer sold and can't be scanned, the zero value means the product is sold
+	Discontinued bool `json:"discontinued,omitempty"`
+}
+
+// UnmarshalJSON also reads catalogs written before products were discontinued, they marked them "active": false
+func (p *Product) UnmarshalJSON(data []byte) error {
+	type product Product
+	var aux struct {
+		product
+		Active *bool `json:"active"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*p = Product(aux.product)
+	if aux.Active != nil && !*aux.Active {
+		p.Discontinued = true
+	}
+	return nil
+}
+
+// HasTag reports whether the product has been given the tag
+func (p Product) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Catalog holds the product information for each sku, operation is go-routine safe
+type Catalog struct {
+	mu       sync.RWMutex
+	products map[sku.SKU]Product
+}
+
+// New creates a catalog from the given products, a missing tax class or unit defaults to standard and each
+func New(products ...Product) (*Catalog, error) {
+	c := &Catalog{products: make(map[sku.SKU]Product, len(products))}
+
+	for _, p := range products {
+		p, err := withDefaults(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := c.products[p.SKU]; exists {
+			return nil, fmt.Errorf("%w: sku %s", ErrDuplicateProduct, p.SKU)
+		}
+
+		c.products[p.SKU] = p
+	}
+
+	return c, nil
+}
+
+// Load reads a catalog from a json array of products
+func Load(r io.Reader) (*Catalog, error) {
+	var products []Product
+	if err := json.NewDecoder(r).Decode(&products); err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+	return New(products...)
+}
+
+// LoadFile reads a catalog from a json file
+func LoadFile(path string) (*Catalog, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Load(file)
+}
+
+// Product gets a product by its sku, returns ErrProductNotFound if it isn't in the catalog
+func (c *Catalog) Product(sku sku.SKU) (Product, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	p, found := c.products[sku]
+	if !found {
+		return Product{}, ErrProductNotFound
+	}
+	return p, nil
+}
+
+// Put adds or replaces a product, it is validated and given the same defaults as New
+func (c *Catalog) Put(p Product) error {
+	p, err := withDefaults(p)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.products == nil {
+		c.products = make(map[sku.SKU]Product)
+	}
+	c.products[p.SKU] = p
+
+	return nil
+}
+
+// validates the product, a missing tax class or unit defaults to standard and each
+func withDefaults(p Product) (Product, error) {
+	if p.SKU == (sku.SKU{}) {
+		return p, fmt.Errorf("%w: %q", ErrMissingSKU, p.Name)
+	}
+
+	if p.Name == "" {
+		return p, fmt.Errorf("%w: sku %s", ErrMissingName, p.SKU)
+	}
+
+	switch p.TaxClass {
+	case "":
+		p.TaxClass = TaxStandard
+	case TaxStandard, TaxReduced, TaxZero:
+	default:
+		return p, fmt.Errorf("%w: sku %s has %q", ErrUnknownTaxClass, p.SKU, p.TaxClass)
+	}
+
+	switch p.Unit {
+	case "":
+		p.Unit = UnitEach
+	case UnitEach, UnitKilogram, UnitLitre:
+	default:
+		return p, fmt.Errorf("%w: sku %s has %q", ErrUnknownUnit, p.SKU, p.Unit)
+	}
+
+	return p, nil
+}
+
+// ByCategory returns every product in a category sorted by sku
+func (c *Catalog) ByCategory(category string) []Product {
+	return c.filter(func(p Product) bool { return p.Category == category })
+}
+
+// ByTag returns every product with the tag sorted by sku
+func (c *Catalog) ByTag(tag string) []Product {
+	return c.filter(func(p Product) bool { return p.HasTag(tag) })
+}
+
+// Categories lists every category in the catalog alphabetically
+func (c *Catalog) Categories() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	categories := make([]string, 0)
+	for _, p := range c.products {
+		if p.Category != "" && !seen[p.Category] {
+			seen[p.Category] = true
+			categories = append(categories, p.Category)
+		}
+	}
+	sort.Strings(categories)
+
+	return categories
+}
+
+func (c *Catalog) filter(match func(Product) bool) []Product {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	products := make([]Product, 0)
+	for _, p := range c.products {
+		if match(p) {
+			products = append(products, p)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].SKU.Value() < products[j].SKU.Value() })
+
+	return products
+}
@@ -0,0 +1,151 @@
+package catalog
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func skuGenerator(t *testing.T, r rune) sku.SKU {
+	s, err := sku.New(r)
+	if err != nil {
+		t.Fatalf("failed to make sku, input: %c, err: %v", r, err)
+	}
+	return s
+}
+
+func TestLoadFile(t *testing.T) {
+	c, err := LoadFile("./testdata/products.json")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	doughnut, err := c.Product(skuGenerator(t, 'D'))
+	if err != nil {
+		t.Fatalf("Catalog.Product() error = %v", err)
+	}
+
+	want := Product{SKU: skuGenerator(t, 'D'), Name: "Doughnut", Category: "bakery", TaxClass: TaxZero, Unit: UnitEach, Discontinued: true}
+	if !reflect.DeepEqual(doughnut, want) {
+		t.Errorf("Catalog.Product() = %+v, want %+v", doughnut, want)
+	}
+
+	if _, err := c.Product(skuGenerator(t, 'Z')); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Catalog.Product() error = %v, want %v", err, ErrProductNotFound)
+	}
+
+	if got, want := c.Categories(), []string{"bakery", "drinks", "fruit"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Catalog.Categories() = %v, want %v", got, want)
+	}
+}
+
+func TestCatalog_ByCategory(t *testing.T) {
+	c, err := LoadFile("./testdata/products.json")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		products []Product
+		want     string
+	}{
+		{name: "by category", products: c.ByCategory("fruit"), want: "AB"},
+		{name: "by tag", products: c.ByTag("tropical"), want: "AB"},
+		{name: "unknown category", products: c.ByCategory("meat"), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			for _, p := range tt.products {
+				got += p.SKU.String()
+			}
+			if got != tt.want {
+				t.Errorf("got products %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_defaults(t *testing.T) {
+	c, err := Load(strings.NewReader(`[{"sku":"A","name":"Apple"}]`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	apple, err := c.Product(skuGenerator(t, 'A'))
+	if err != nil {
+		t.Fatalf("Catalog.Product() error = %v", err)
+	}
+
+	want := Product{SKU: skuGenerator(t, 'A'), Name: "Apple", TaxClass: TaxStandard, Unit: UnitEach}
+	if !reflect.DeepEqual(apple, want) {
+		t.Errorf("Catalog.Product() = %+v, want %+v", apple, want)
+	}
+
+	// products put into the catalog get the same defaults
+	if err := c.Put(Product{SKU: skuGenerator(t, 'B'), Name: "Bread"}); err != nil {
+		t.Fatalf("Catalog.Put() error = %v", err)
+	}
+
+	bread, err := c.Product(skuGenerator(t, 'B'))
+	if err != nil {
+		t.Fatalf("Catalog.Product() error = %v", err)
+	}
+
+	want = Product{SKU: skuGenerator(t, 'B'), Name: "Bread", TaxClass: TaxStandard, Unit: UnitEach}
+	if !reflect.DeepEqual(bread, want) {
+		t.Errorf("Catalog.Product() = %+v, want %+v", bread, want)
+	}
+
+	// and are validated the same way
+	if err := c.Put(Product{SKU: skuGenerator(t, 'C'), Name: "Cola", Unit: "crate"}); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("Catalog.Put() error = %v, want %v", err, ErrUnknownUnit)
+	}
+	if err := c.Put(Product{Name: "Cola"}); !errors.Is(err, ErrMissingSKU) {
+		t.Errorf("Catalog.Put() error = %v, want %v", err, ErrMissingSKU)
+	}
+}
+
+func TestLoad_active(t *testing.T) {
+	// catalogs written before discontinued existed marked products as active or not
+	c, err := Load(strings.NewReader(`[{"sku":"A","name":"Apple","active":true},{"sku":"B","name":"Bread","active":false}]`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for r, want := range map[rune]bool{'A': false, 'B': true} {
+		p, err := c.Product(skuGenerator(t, r))
+		if err != nil {
+			t.Fatalf("Catalog.Product() error = %v", err)
+		}
+		if p.Discontinued != want {
+			t.Errorf("sku %c discontinued = %v, want %v", r, p.Discontinued, want)
+		}
+	}
+}
+
+func TestLoad_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr error
+	}{
+		{name: "duplicate skus", json: `[{"sku":"A","name":"x"},{"sku":"a","name":"y"}]`, wantErr: ErrDuplicateProduct},
+		{name: "missing name", json: `[{"sku":"A"}]`, wantErr: ErrMissingName},
+		{name: "invalid sku", json: `[{"sku":"1","name":"x"}]`, wantErr: sku.ErrNoSpecialCharacters},
+		{name: "missing sku", json: `[{"name":"x"}]`, wantErr: ErrMissingSKU},
+		{name: "unknown tax class", json: `[{"sku":"A","name":"x","tax_class":"luxury"}]`, wantErr: ErrUnknownTaxClass},
+		{name: "unknown unit", json: `[{"sku":"A","name":"x","unit":"crate"}]`, wantErr: ErrUnknownUnit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(strings.NewReader(tt.json)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Load() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
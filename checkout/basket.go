@@ -23,6 +23,8 @@ func NewBasket() *basket {
 type basket struct {
 	mu    sync.RWMutex
 	items map[itemID]quantity.Quantity
+	// skus in the order they were first added
+	scanned []itemID
 }
 
 // adds an item to the basket, operation is go-routine safe
@@ -35,6 +37,10 @@ func (b *basket) AddItem(sku sku.SKU, quantity quantity.Quantity) error {
 		b.items = make(map[itemID]qty)
 	}
 
+	if _, exists := b.items[sku]; !exists {
+		b.scanned = append(b.scanned, sku)
+	}
+
 	b.items[sku] = quantity
 
 	return nil
@@ -60,13 +66,28 @@ func (b *basket) GetItem(sku sku.SKU) (qty quantity.Quantity, err error) {
 }
 
 // Range loops through all the items and applies your function on each iteration
-// operation is go-routine safe
+// operation is go-routine safe, the items are copied first so the iterator can read the basket e.g. pricing scripts
 // if you required anything different you would probably refactor this to create transactions for the outside users or could use googles sync.Map
 func (b *basket) Range(iterator func(id itemID, quantity quantity.Quantity)) {
 	b.mu.RLock()
-	defer b.mu.RUnlock()
-
+	items := make(map[itemID]quantity.Quantity, len(b.items))
 	for id, qty := range b.items {
+		items[id] = qty
+	}
+	b.mu.RUnlock()
+
+	for id, qty := range items {
 		iterator(id, qty)
 	}
 }
+
+// RangeOrdered is like Range but the items are visited in a stable order, operation is go-routine safe
+func (b *basket) RangeOrdered(order Order, iterator func(id itemID, quantity quantity.Quantity)) {
+	b.mu.RLock()
+	items := orderedItems(b.items, b.scanned, order)
+	b.mu.RUnlock()
+
+	for _, item := range items {
+		iterator(item.SKU, item.Quantity)
+	}
+}
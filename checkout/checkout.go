@@ -2,16 +2,21 @@ package checkout
 
 import (
 	"errors"
-	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"sync"
+	"time"
 
+	"github.com/Joshswooft/thinkmoney-test/catalog"
 	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/metrics"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
 	"github.com/Joshswooft/thinkmoney-test/quantity"
 	"github.com/Joshswooft/thinkmoney-test/sku"
 )
 
 var (
+	ErrInactiveProduct        = errors.New("the product is no longer sold")
 	errUnknownItemScanned     = errors.New("an unknown item was scanned")
 	errNoPricingRulesProvided = errors.New("no pricing rules was provided")
 	errNoScannerProvided      = errors.New("no scanner was provided")
@@ -32,6 +37,13 @@ type Basket interface {
 	GetItem(sku sku.SKU) (qty quantity.Quantity, err error)
 	// runs the iterator func over every item in the basket
 	Range(iterator func(id itemID, quantity quantity.Quantity))
+	// runs the iterator func over every item in the basket in a stable order
+	RangeOrdered(order Order, iterator func(id itemID, quantity quantity.Quantity))
+}
+
+// Catalog provides the product information for a sku, products missing from the catalog can still be sold if they have a price
+type Catalog interface {
+	Product(sku sku.SKU) (catalog.Product, error)
 }
 
 type PricingRules interface {
@@ -43,9 +55,31 @@ type checkout struct {
 	basket       Basket
 	scanner      Scanner
 	pricingRules PricingRules
+	events       eventBus
+	logger       *slog.Logger
+	recorder     metrics.Recorder
+	itemOrder    Order
+	itemRules    ItemRules
+	catalog      Catalog
+	promotions   []pricing.Promotion
+	thresholds   []*pricing.SpendThreshold
+	loyalty      Loyalty
+	inventory    Inventory
+	outcomes     ExperimentOutcomes
+	// the stock reserved for this checkout in the inventory
+	reservation string
+
+	// guards the journal, suspended, closed, approval and member state
+	mu              sync.Mutex
+	journal         *Journal
+	suspended       bool
+	closed          bool
+	approvalPending bool
+	approvedBy      string
+	member          string
 }
 
-func NewCheckout(pricingRules PricingRules, basket Basket, scanner Scanner) (*checkout, error) {
+func NewCheckout(pricingRules PricingRules, basket Basket, scanner Scanner, opts ...Option) (*checkout, error) {
 	if pricingRules == nil {
 		return nil, errNoPricingRulesProvided
 	}
@@ -58,29 +92,86 @@ func NewCheckout(pricingRules PricingRules, basket Basket, scanner Scanner) (*ch
 		return nil, errNoBasketProvided
 	}
 
-	return &checkout{
+	c := &checkout{
 		pricingRules: pricingRules,
 		basket:       basket,
 		scanner:      scanner,
-	}, nil
+		itemOrder:    BySKU,
+		journal:      NewJournal(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if err := validateItemRules(c.itemRules); err != nil {
+		return nil, err
+	}
+
+	if c.inventory != nil {
+		reservation, err := newReservationID()
+		if err != nil {
+			return nil, err
+		}
+		c.reservation = reservation
+	}
+
+	return c, nil
+}
+
+func (c *checkout) log() *slog.Logger {
+	return loggerOrDiscard(c.logger)
 }
 
-func (c *checkout) doScan(sku sku.SKU, quantity quantity.Quantity) error {
+func (c *checkout) doScan(sku sku.SKU, amount quantity.Quantity, source string) error {
+	if err := c.checkOpen(); err != nil {
+		return err
+	}
+
+	if c.ApprovalRequired() {
+		return ErrApprovalRequired
+	}
+
 	// maybe its better to just add the item to the basket?
 	if exists := c.pricingRules.PriceExists(sku); !exists {
+		c.metrics().IncCounter(MetricUnknownSKUs)
+		c.publishUnknownItem(sku)
 		return errUnknownItemScanned
 	}
 
+	if product, found := c.product(sku); found && product.Discontinued {
+		return ErrInactiveProduct
+	}
+
 	itemQuantity, err := c.basket.GetItem(sku)
 
 	if err != nil && errors.Is(err, ErrItemNotFound) {
-		return c.basket.AddItem(sku, quantity)
+		itemQuantity = *quantity.New(0)
 	}
 
 	updatedQuantity := itemQuantity
-	updatedQuantity.Add(quantity.Value())
+	updatedQuantity.Add(amount.Value())
 
-	return c.basket.AddItem(sku, updatedQuantity)
+	if err := c.checkLimit(sku, updatedQuantity.Value()); err != nil {
+		return err
+	}
+
+	if err := c.reserve(sku, amount.Value()); err != nil {
+		return err
+	}
+
+	if err := c.basket.AddItem(sku, updatedQuantity); err != nil {
+		return errors.Join(err, c.reserve(sku, -amount.Value()))
+	}
+
+	c.requireApproval(sku)
+
+	c.Journal().Append(sku, amount.Value(), source)
+	c.metrics().AddCounter(MetricItemsScanned, float64(amount.Value()))
+
+	c.publishChange(sku, amount, itemQuantity, updatedQuantity)
+
+	return nil
 }
 
 // scans in a single item into the basket
@@ -88,12 +179,26 @@ func (c *checkout) Scan(sku sku.SKU, quantity quantity.Quantity) error {
 	if quantity.Value() == 0 {
 		return nil
 	}
-	return c.doScan(sku, quantity)
+	return c.doScan(sku, quantity, SourceManual)
 }
 
 // reads in everything from the scanner and adds to the basket
 // doesnt stop reading until it hits an io.EOF error
 func (c *checkout) ScanItems() error {
+	if err := c.checkOpen(); err != nil {
+		return err
+	}
+
+	// nothing is read from the scanner until a supervisor approves, otherwise every item would be read and dropped
+	if c.ApprovalRequired() {
+		return ErrApprovalRequired
+	}
+
+	source := SourceScanner
+	if named, ok := c.scanner.(interface{ Name() string }); ok && named.Name() != "" {
+		source = named.Name()
+	}
+
 	for {
 		skuInstance, err := c.scanner.Scan()
 		if err == io.EOF {
@@ -101,31 +206,56 @@ func (c *checkout) ScanItems() error {
 		}
 
 		if errors.Is(err, sku.ErrNoSpecialCharacters) {
+			c.recordScanError(err, errorKindInvalidSKU)
 			continue
 		}
 
 		if err != nil {
 			// unknown error
-			log.Println(fmt.Errorf("failed to read items from scanner, err=%w", err))
+			c.recordScanError(err, errorKindRead)
+			c.log().Error("failed to read items from scanner", errorAttrs(err, errorKindRead)...)
 			// not sure if its better to continue or return an error here?
 			// continue
 			return err
 		}
 
-		if scanErr := c.doScan(skuInstance, *quantity.New(1)); scanErr != nil {
-			log.Println(fmt.Errorf("failed to scan items into basket, err=%v, sku=%s", scanErr, skuInstance))
+		if scanErr := c.doScan(skuInstance, *quantity.New(1), source); scanErr != nil {
+			c.recordScanError(scanErr, errorKindBasket)
+			attrs := append([]any{slog.String(logKeySKU, skuInstance.String()), slog.Int(logKeyQuantity, 1)}, errorAttrs(scanErr, errorKindBasket)...)
+			c.log().Warn("failed to scan item into basket", attrs...)
 			// if the error is an unknown item then continue else setup retry logic...
 			continue
 		}
+
+		// stop before reading the next item so nothing is lost while waiting for a supervisor, call ScanItems again once approved
+		if c.ApprovalRequired() {
+			return ErrApprovalRequired
+		}
 	}
 	return nil
 }
 
 func (c *checkout) GetTotalPrice() currency.Pence {
+	start := time.Now()
+	total := c.total()
+	c.metrics().Observe(MetricTotalPriceDuration, time.Since(start).Seconds())
+
+	basketSize := 0
+	c.basket.Range(func(_ itemID, qty quantity.Quantity) {
+		basketSize += qty.Value()
+	})
+	c.metrics().Observe(MetricBasketSize, float64(basketSize))
+
+	return total
+}
+
+// sums the price of every item in the basket before any basket wide promotions
+func (c *checkout) subtotal() currency.Pence {
 	totalPrice := currency.Pence(0)
 
+	rules := c.rules()
 	adder := func(sku itemID, qty quantity.Quantity) {
-		price := c.pricingRules.GetPrice(sku, qty)
+		price := rules.GetPrice(sku, qty)
 		totalPrice += price
 	}
 
@@ -134,3 +264,13 @@ func (c *checkout) GetTotalPrice() currency.Pence {
 	return totalPrice
 
 }
+
+// looks up a product in the catalog, found is false when there is no catalog or the product isn't in it
+func (c *checkout) product(sku sku.SKU) (product catalog.Product, found bool) {
+	if c.catalog == nil {
+		return product, false
+	}
+
+	product, err := c.catalog.Product(sku)
+	return product, err == nil
+}
@@ -34,6 +34,7 @@ type MockBasketStorage struct {
 	Items           map[sku.SKU]quantity.Quantity
 	AddItemErr      error
 	GetTotalPriceFn func() int
+	scanned         []sku.SKU
 }
 
 // AddItem simulates adding an item to the mock basket storage.
@@ -41,6 +42,9 @@ func (m *MockBasketStorage) AddItem(sku sku.SKU, quantity quantity.Quantity) err
 	if m.AddItemErr != nil {
 		return m.AddItemErr
 	}
+	if _, exists := m.Items[sku]; !exists {
+		m.scanned = append(m.scanned, sku)
+	}
 	m.Items[sku] = quantity
 	return nil
 }
@@ -59,6 +63,12 @@ func (m *MockBasketStorage) Range(iterator func(id itemID, quantity quantity.Qua
 	}
 }
 
+func (m *MockBasketStorage) RangeOrdered(order Order, iterator func(id itemID, quantity quantity.Quantity)) {
+	for _, item := range orderedItems(m.Items, m.scanned, order) {
+		iterator(item.SKU, item.Quantity)
+	}
+}
+
 // MockPricingRules is a mock implementation of PricingRules for testing purposes.
 type MockPricingRules struct {
 	Prices map[sku.SKU]currency.Pence
@@ -0,0 +1,352 @@
+package checkout
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+var (
+	ErrEventNotFound    = errors.New("basket event not found")
+	ErrEventAlreadyVoid = errors.New("basket event has already been voided")
+	ErrCannotVoidVoid   = errors.New("a void can't be voided")
+	errInvalidEventQty  = errors.New("basket event quantity must be positive")
+	errNothingToRemove  = errors.New("item is not in the basket")
+)
+
+const defaultSnapshotEvery = 50
+
+// BasketEventKind is the type of change made to an event sourced basket
+type BasketEventKind int
+
+const (
+	BasketAdd BasketEventKind = iota + 1
+	BasketRemove
+	// cancels an earlier add or remove
+	BasketVoid
+)
+
+func (k BasketEventKind) String() string {
+	switch k {
+	case BasketAdd:
+		return "add"
+	case BasketRemove:
+		return "remove"
+	case BasketVoid:
+		return "void"
+	default:
+		return "unknown"
+	}
+}
+
+// BasketEvent is a single change to an event sourced basket
+// for a remove, Quantity is what was actually taken out which may be less than requested
+type BasketEvent struct {
+	Seq      uint64
+	Kind     BasketEventKind
+	SKU      sku.SKU
+	Quantity int
+	// the event a void cancels
+	Voids uint64
+}
+
+// folded state of the basket at a point in the event stream
+type basketState struct {
+	seq     uint64
+	items   map[itemID]quantity.Quantity
+	scanned []itemID
+}
+
+func newBasketState() basketState {
+	return basketState{items: make(map[itemID]quantity.Quantity)}
+}
+
+func (s basketState) clone() basketState {
+	items := make(map[itemID]quantity.Quantity, len(s.items))
+	for id, qty := range s.items {
+		items[id] = qty
+	}
+	return basketState{seq: s.seq, items: items, scanned: append([]itemID(nil), s.scanned...)}
+}
+
+func (s *basketState) change(id itemID, delta int) {
+	qty, exists := s.items[id]
+
+	// removing what isn't there does nothing, e.g. a remove whose add was voided
+	if delta == 0 || (!exists && delta < 0) {
+		return
+	}
+
+	if !exists {
+		s.scanned = append(s.scanned, id)
+	}
+
+	// an emptied line is taken off the basket, if it comes back it goes to the end of the scan order
+	if qty.Add(delta) == 0 {
+		delete(s.items, id)
+		for i, scanned := range s.scanned {
+			if scanned == id {
+				s.scanned = append(s.scanned[:i], s.scanned[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+
+	s.items[id] = qty
+}
+
+// apply folds a single event into the state, voids are taken into account by leaving out the events they cancel
+func (s *basketState) apply(e BasketEvent) {
+	s.seq = e.Seq
+
+	switch e.Kind {
+	case BasketAdd:
+		s.change(e.SKU, e.Quantity)
+	case BasketRemove:
+		s.change(e.SKU, -e.Quantity)
+	}
+}
+
+// eventBasket is a Basket whose contents are derived purely from an ordered stream of add, remove and void events.
+// The current state is cached and snapshots are taken every so often so rebuilding an old state doesn't replay the whole stream.
+// operation is go-routine safe
+type eventBasket struct {
+	mu     sync.RWMutex
+	events []BasketEvent
+	// the sequence number of the void which cancelled each voided event
+	voidedBy      map[uint64]uint64
+	current       basketState
+	snapshots     []basketState
+	snapshotEvery int
+}
+
+// creates an empty event sourced basket which snapshots its state every snapshotEvery events, zero uses a sensible default
+func NewEventBasket(snapshotEvery int) *eventBasket {
+	if snapshotEvery <= 0 {
+		snapshotEvery = defaultSnapshotEvery
+	}
+
+	return &eventBasket{
+		voidedBy:      make(map[uint64]uint64),
+		current:       newBasketState(),
+		snapshotEvery: snapshotEvery,
+	}
+}
+
+// EventBasketFromJournal rebuilds a basket from a scan journal, positive deltas are adds and negative deltas removes
+func EventBasketFromJournal(entries []JournalEntry, snapshotEvery int) (*eventBasket, error) {
+	b := NewEventBasket(snapshotEvery)
+	for _, e := range entries {
+		var err error
+		switch {
+		case e.Delta > 0:
+			_, err = b.Add(e.SKU, e.Delta)
+		case e.Delta < 0:
+			_, err = b.Remove(e.SKU, -e.Delta)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("journal entry %d: %w", e.Seq, err)
+		}
+	}
+	return b, nil
+}
+
+// event sequence numbers are their position in the stream starting from 1
+func (b *eventBasket) event(seq uint64) (BasketEvent, bool) {
+	if seq == 0 || seq > uint64(len(b.events)) {
+		return BasketEvent{}, false
+	}
+	return b.events[seq-1], true
+}
+
+// appends an event and folds it into the current state, must be called with the lock held
+func (b *eventBasket) append(e BasketEvent) BasketEvent {
+	e.Seq = uint64(len(b.events)) + 1
+	b.events = append(b.events, e)
+
+	if e.Kind == BasketVoid {
+		b.voidedBy[e.Voids] = e.Seq
+
+		// snapshots which include the voided event are out of date, the current state is rebuilt without it
+		i := sort.Search(len(b.snapshots), func(i int) bool { return b.snapshots[i].seq >= e.Voids })
+		b.snapshots = b.snapshots[:i]
+		b.current = b.replay(e.Seq)
+	} else {
+		b.current.apply(e)
+	}
+
+	if len(b.events)%b.snapshotEvery == 0 {
+		b.snapshots = append(b.snapshots, b.current.clone())
+	}
+
+	return e
+}
+
+// Add puts items into the basket and returns the sequence number of the event
+func (b *eventBasket) Add(sku sku.SKU, qty int) (uint64, error) {
+	if qty <= 0 {
+		return 0, errInvalidEventQty
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.append(BasketEvent{Kind: BasketAdd, SKU: sku, Quantity: qty}).Seq, nil
+}
+
+// Remove takes items out of the basket, removing more than is in the basket empties the line
+func (b *eventBasket) Remove(sku sku.SKU, qty int) (uint64, error) {
+	if qty <= 0 {
+		return 0, errInvalidEventQty
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current, exists := b.current.items[sku]
+	if !exists || current.Value() == 0 {
+		return 0, fmt.Errorf("%w: %s", errNothingToRemove, sku)
+	}
+
+	if qty > current.Value() {
+		qty = current.Value()
+	}
+
+	return b.append(BasketEvent{Kind: BasketRemove, SKU: sku, Quantity: qty}).Seq, nil
+}
+
+// Void cancels an earlier add or remove, e.g. when a cashier scans something by mistake
+func (b *eventBasket) Void(seq uint64) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	target, exists := b.event(seq)
+	if !exists {
+		return 0, ErrEventNotFound
+	}
+
+	if target.Kind == BasketVoid {
+		return 0, ErrCannotVoidVoid
+	}
+
+	if _, voided := b.voidedBy[seq]; voided {
+		return 0, ErrEventAlreadyVoid
+	}
+
+	return b.append(BasketEvent{Kind: BasketVoid, SKU: target.SKU, Quantity: target.Quantity, Voids: seq}).Seq, nil
+}
+
+// Events returns a copy of the event stream
+func (b *eventBasket) Events() []BasketEvent {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]BasketEvent(nil), b.events...)
+}
+
+// rebuilds the state after the given event from the closest snapshot, must be called with the lock held
+func (b *eventBasket) stateAt(seq uint64) basketState {
+	if seq >= uint64(len(b.events)) {
+		return b.current.clone()
+	}
+	return b.replay(seq)
+}
+
+// replays the adds and removes up to the given event which hadn't been voided by then, starting from the closest snapshot.
+// A snapshot never includes an event voided later as those snapshots are dropped, so it's a safe place to start.
+func (b *eventBasket) replay(seq uint64) basketState {
+	// snapshots are in sequence order so find the last one at or before seq
+	i := sort.Search(len(b.snapshots), func(i int) bool { return b.snapshots[i].seq > seq })
+
+	state := newBasketState()
+	if i > 0 {
+		state = b.snapshots[i-1].clone()
+	}
+
+	for _, e := range b.events[state.seq:seq] {
+		if voidSeq, voided := b.voidedBy[e.Seq]; voided && voidSeq <= seq {
+			state.seq = e.Seq
+			continue
+		}
+		state.apply(e)
+	}
+	state.seq = seq
+
+	return state
+}
+
+// At returns a read-only copy of the basket as it was after the given event e.g. "what was in the basket after scan 17?"
+func (b *eventBasket) At(seq uint64) Basket {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	state := b.stateAt(seq)
+	return &basket{items: state.items, scanned: state.scanned}
+}
+
+// TotalAt prices the basket as it was after the given event with any pricing rules, useful to compare rules
+// rules which look at the rest of the basket see the basket as it was then
+func (b *eventBasket) TotalAt(seq uint64, rules PricingRules) currency.Pence {
+	at := b.At(seq)
+
+	ctx := pricing.Context{Basket: make(map[sku.SKU]quantity.Quantity)}
+	at.Range(func(id itemID, qty quantity.Quantity) {
+		ctx.Basket[id] = qty
+	})
+
+	total := currency.Pence(0)
+	for id, qty := range ctx.Basket {
+		total += pricing.PriceIn(rules, ctx, id, qty)
+	}
+	return total
+}
+
+// AddItem sets the quantity of an item as the Basket interface expects, it is recorded as an add or remove of the difference
+// setting an item to the quantity it already has records nothing
+func (b *eventBasket) AddItem(sku sku.SKU, qty quantity.Quantity) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current := b.current.items[sku]
+	delta := qty.Value() - current.Value()
+
+	switch {
+	case delta > 0:
+		b.append(BasketEvent{Kind: BasketAdd, SKU: sku, Quantity: delta})
+	case delta < 0:
+		b.append(BasketEvent{Kind: BasketRemove, SKU: sku, Quantity: -delta})
+	}
+
+	return nil
+}
+
+func (b *eventBasket) GetItem(sku sku.SKU) (qty quantity.Quantity, err error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	qty, found := b.current.items[sku]
+	if !found {
+		return *quantity.New(0), ErrItemNotFound
+	}
+	return qty, nil
+}
+
+func (b *eventBasket) Range(iterator func(id itemID, quantity quantity.Quantity)) {
+	b.RangeOrdered(ByScanOrder, iterator)
+}
+
+func (b *eventBasket) RangeOrdered(order Order, iterator func(id itemID, quantity quantity.Quantity)) {
+	b.mu.RLock()
+	items := orderedItems(b.current.items, b.current.scanned, order)
+	b.mu.RUnlock()
+
+	for _, item := range items {
+		iterator(item.SKU, item.Quantity)
+	}
+}
@@ -0,0 +1,237 @@
+package checkout
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func Test_eventBasket(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+
+	b := NewEventBasket(2)
+
+	steps := []struct {
+		name    string
+		do      func() (uint64, error)
+		wantErr error
+		want    map[sku.SKU]quantity.Quantity
+	}{
+		{
+			name: "add A",
+			do:   func() (uint64, error) { return b.Add(skuA, 2) },
+			want: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(2)},
+		},
+		{
+			name: "add B",
+			do:   func() (uint64, error) { return b.Add(skuB, 1) },
+			want: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(2), skuB: *quantity.New(1)},
+		},
+		{
+			name: "add more A",
+			do:   func() (uint64, error) { return b.Add(skuA, 1) },
+			want: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(3), skuB: *quantity.New(1)},
+		},
+		{
+			name: "removing more B than there is empties the line",
+			do:   func() (uint64, error) { return b.Remove(skuB, 5) },
+			want: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(3)},
+		},
+		{
+			name: "void the first add",
+			do:   func() (uint64, error) { return b.Void(1) },
+			want: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(1)},
+		},
+		{
+			name: "void the remove puts B back",
+			do:   func() (uint64, error) { return b.Void(4) },
+			want: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(1), skuB: *quantity.New(1)},
+		},
+		{
+			name:    "can't void twice",
+			do:      func() (uint64, error) { return b.Void(1) },
+			wantErr: ErrEventAlreadyVoid,
+		},
+		{
+			name:    "can't void a void",
+			do:      func() (uint64, error) { return b.Void(5) },
+			wantErr: ErrCannotVoidVoid,
+		},
+		{
+			name:    "can't void an unknown event",
+			do:      func() (uint64, error) { return b.Void(99) },
+			wantErr: ErrEventNotFound,
+		},
+		{
+			name:    "can't remove an item that isn't in the basket",
+			do:      func() (uint64, error) { return b.Remove(skuGenerator(t, 'Z'), 1) },
+			wantErr: errNothingToRemove,
+		},
+	}
+
+	history := make(map[uint64]map[sku.SKU]quantity.Quantity)
+
+	for _, step := range steps {
+		seq, err := step.do()
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+
+		history[seq] = step.want
+
+		got := map[sku.SKU]quantity.Quantity{}
+		b.Range(func(id itemID, qty quantity.Quantity) { got[id] = qty })
+		if !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: basket = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	// every point in time can be rebuilt, with and without snapshots
+	for seq, want := range history {
+		got := map[sku.SKU]quantity.Quantity{}
+		b.At(seq).Range(func(id itemID, qty quantity.Quantity) { got[id] = qty })
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("At(%d) = %v, want %v", seq, got, want)
+		}
+	}
+
+	if got := len(b.Events()); got != 6 {
+		t.Errorf("expected 6 events, got %d", got)
+	}
+}
+
+func Test_eventBasket_TotalAt(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	b, err := EventBasketFromJournal([]JournalEntry{
+		{Seq: 1, SKU: skuA, Delta: 1},
+		{Seq: 2, SKU: skuA, Delta: 1},
+		{Seq: 3, SKU: skuA, Delta: 1},
+		{Seq: 4, SKU: skuA, Delta: -1},
+	}, 0)
+	if err != nil {
+		t.Fatalf("EventBasketFromJournal() error = %v", err)
+	}
+
+	unitPrices := &pricing.SimplePricing{UnitPrices: map[sku.SKU]currency.Pence{skuA: 50}}
+	multiBuy := &pricing.SpecialPricing{Config: map[sku.SKU]pricing.PricingData{
+		skuA: {UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3)},
+	}}
+
+	tests := []struct {
+		seq          uint64
+		wantUnit     currency.Pence
+		wantMultiBuy currency.Pence
+	}{
+		{seq: 0, wantUnit: 0, wantMultiBuy: 0},
+		{seq: 2, wantUnit: 100, wantMultiBuy: 100},
+		{seq: 3, wantUnit: 150, wantMultiBuy: 130},
+		{seq: 4, wantUnit: 100, wantMultiBuy: 100},
+	}
+	for _, tt := range tests {
+		if got := b.TotalAt(tt.seq, unitPrices); got != tt.wantUnit {
+			t.Errorf("TotalAt(%d) with unit prices = %d, want %d", tt.seq, got, tt.wantUnit)
+		}
+		if got := b.TotalAt(tt.seq, multiBuy); got != tt.wantMultiBuy {
+			t.Errorf("TotalAt(%d) with multi-buy = %d, want %d", tt.seq, got, tt.wantMultiBuy)
+		}
+	}
+}
+
+func Test_eventBasket_checkout(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	b := NewEventBasket(0)
+	c, err := NewCheckout(&MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 10}}, b, &MockScanner{})
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := c.Scan(skuA, *quantity.New(1)); err != nil {
+			t.Fatalf("checkout.Scan() error = %v", err)
+		}
+	}
+
+	if got := c.GetTotalPrice(); got != 30 {
+		t.Errorf("checkout.GetTotalPrice() = %d, want %d", got, 30)
+	}
+
+	// the checkout sets absolute quantities which are recorded as the difference
+	for _, e := range b.Events() {
+		if e.Kind != BasketAdd || e.Quantity != 1 {
+			t.Errorf("unexpected event %+v", e)
+		}
+	}
+}
+
+func Test_eventBasket_Void_undoesEvents(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	tests := []struct {
+		name  string
+		steps func(b *eventBasket) error
+	}{
+		{
+			name: "voiding an add and then the remove after it",
+			steps: func(b *eventBasket) error {
+				add, _ := b.Add(skuA, 3)
+				remove, _ := b.Remove(skuA, 2)
+				if _, err := b.Void(add); err != nil {
+					return err
+				}
+				_, err := b.Void(remove)
+				return err
+			},
+		},
+		{
+			name: "voiding an add whose items were all removed",
+			steps: func(b *eventBasket) error {
+				add, _ := b.Add(skuA, 3)
+				b.Remove(skuA, 3)
+				_, err := b.Void(add)
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// snapshotting every event checks the snapshots are thrown away when an event in them is voided
+			for _, snapshotEvery := range []int{1, 50} {
+				b := NewEventBasket(snapshotEvery)
+				if err := tt.steps(b); err != nil {
+					t.Fatal(err)
+				}
+
+				got := map[sku.SKU]quantity.Quantity{}
+				b.Range(func(id itemID, qty quantity.Quantity) { got[id] = qty })
+				if len(got) != 0 {
+					t.Errorf("basket = %v, want it empty", got)
+				}
+
+				last := uint64(len(b.Events()))
+				if items := b.At(last); len(Items(items, BySKU)) != 0 {
+					t.Errorf("At(%d) = %v, want it empty", last, Items(items, BySKU))
+				}
+			}
+		})
+	}
+}
+
+func TestEventBasketFromJournal_error(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	_, err := EventBasketFromJournal([]JournalEntry{{Seq: 1, SKU: skuA, Delta: -1}}, 0)
+	if !errors.Is(err, errNothingToRemove) {
+		t.Errorf("EventBasketFromJournal() error = %v, want %v", err, errNothingToRemove)
+	}
+}
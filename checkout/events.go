@@ -0,0 +1,199 @@
+package checkout
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// EventKind describes what happened to the checkout
+type EventKind int
+
+const (
+	// an item was successfully scanned into the basket, the event's quantity is how many were scanned
+	EventItemScanned EventKind = iota + 1
+	// an item was scanned which has no pricing rules
+	EventUnknownItem
+	// the quantity of a basket line has changed
+	EventQuantityChanged
+	// the basket line now qualifies for more special offers than before
+	EventOfferTriggered
+	// the basket line now qualifies for less special offers than before
+	EventOfferBroken
+	// the running subtotal has been recalculated
+	EventTotalRecalculated
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventItemScanned:
+		return "item_scanned"
+	case EventUnknownItem:
+		return "unknown_item"
+	case EventQuantityChanged:
+		return "quantity_changed"
+	case EventOfferTriggered:
+		return "offer_triggered"
+	case EventOfferBroken:
+		return "offer_broken"
+	case EventTotalRecalculated:
+		return "total_recalculated"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is published to subscribers every time the checkout changes
+// Quantity is the basket line quantity after the change and Previous is the quantity before it,
+// except for item scanned events where Quantity is only the amount scanned and Previous is zero
+type Event struct {
+	Kind     EventKind
+	SKU      sku.SKU
+	Quantity quantity.Quantity
+	Previous quantity.Quantity
+	Subtotal currency.Pence
+}
+
+// OfferCounter is an optional interface for pricing rules which know how many special offers a quantity qualifies for.
+// Without it (or pricing.ContextOfferCounter) the checkout can't publish offer triggered or broken events.
+type OfferCounter interface {
+	OffersApplied(sku sku.SKU, quantity quantity.Quantity) int
+}
+
+type subscription struct {
+	id      int
+	handler func(Event)
+	ch      chan Event
+}
+
+// fans events out to the subscribers, the zero value is ready to use
+// handlers are called synchronously whereas channels never block the publisher, if a channel is full the event is dropped
+type eventBus struct {
+	mu      sync.RWMutex
+	nextID  int
+	subs    []subscription
+	dropped atomic.Int64
+}
+
+func (b *eventBus) subscribe(sub subscription) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	sub.id = b.nextID
+	b.subs = append(b.subs, sub)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { b.unsubscribe(sub.id) })
+	}
+}
+
+func (b *eventBus) unsubscribe(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, sub := range b.subs {
+		if sub.id != id {
+			continue
+		}
+		if sub.ch != nil {
+			close(sub.ch)
+		}
+		b.subs = append(b.subs[:i], b.subs[i+1:]...)
+		return
+	}
+}
+
+func (b *eventBus) hasSubscribers() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs) > 0
+}
+
+func (b *eventBus) publish(event Event) {
+	b.mu.RLock()
+	var handlers []func(Event)
+	for _, sub := range b.subs {
+		if sub.handler != nil {
+			handlers = append(handlers, sub.handler)
+			continue
+		}
+
+		select {
+		case sub.ch <- event:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+	b.mu.RUnlock()
+
+	// handlers run without holding the lock so they are free to (un)subscribe
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// Subscribe registers a handler which is called synchronously for every event
+// the handler should be quick as it runs on the scanning go-routine, use SubscribeChan for slow consumers
+// call the returned func to unsubscribe
+func (c *checkout) Subscribe(handler func(Event)) (unsubscribe func()) {
+	return c.events.subscribe(subscription{handler: handler})
+}
+
+// SubscribeChan returns a buffered channel which receives every event
+// a slow subscriber never blocks scanning, once the buffer is full new events are dropped (see DroppedEvents)
+// call the returned func to unsubscribe which also closes the channel
+func (c *checkout) SubscribeChan(buffer int) (events <-chan Event, unsubscribe func()) {
+	if buffer < 1 {
+		buffer = 1
+	}
+	ch := make(chan Event, buffer)
+	return ch, c.events.subscribe(subscription{ch: ch})
+}
+
+// DroppedEvents is the number of events which could not be delivered to a full subscriber channel
+func (c *checkout) DroppedEvents() int64 {
+	return c.events.dropped.Load()
+}
+
+// publishes all the events caused by scanning an amount which changed a basket line from previous to current
+func (c *checkout) publishChange(sku sku.SKU, amount, previous, current quantity.Quantity) {
+	if !c.events.hasSubscribers() {
+		return
+	}
+
+	subtotal := c.total()
+
+	c.events.publish(Event{Kind: EventItemScanned, SKU: sku, Quantity: amount, Subtotal: subtotal})
+
+	event := Event{Kind: EventQuantityChanged, SKU: sku, Quantity: current, Previous: previous, Subtotal: subtotal}
+	c.events.publish(event)
+
+	rules := c.rules()
+	if before, ok := rules.offersApplied(sku, previous); ok {
+		after, _ := rules.offersApplied(sku, current)
+
+		switch {
+		case after > before:
+			event.Kind = EventOfferTriggered
+			c.events.publish(event)
+		case after < before:
+			event.Kind = EventOfferBroken
+			c.events.publish(event)
+		}
+	}
+
+	event.Kind = EventTotalRecalculated
+	c.events.publish(event)
+}
+
+func (c *checkout) publishUnknownItem(sku sku.SKU) {
+	if !c.events.hasSubscribers() {
+		return
+	}
+	c.events.publish(Event{Kind: EventUnknownItem, SKU: sku, Subtotal: c.total()})
+}
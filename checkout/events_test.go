@@ -0,0 +1,137 @@
+package checkout
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func Test_checkout_Subscribe(t *testing.T) {
+
+	skuGen := func(r rune) sku.SKU {
+		return skuGenerator(t, r)
+	}
+
+	pricingRules := &pricing.SpecialPricing{
+		Config: map[sku.SKU]pricing.PricingData{
+			skuGen('A'): {UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3)},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		basket    Basket
+		scan      sku.SKU
+		wantKinds []EventKind
+		// the basket line after the scan
+		wantQuantity int
+		wantTotal    currency.Pence
+		wantErr      bool
+	}{
+		{
+			name:         "scanning a new item publishes the scan, quantity and total events",
+			basket:       NewBasket(),
+			scan:         skuGen('A'),
+			wantKinds:    []EventKind{EventItemScanned, EventQuantityChanged, EventTotalRecalculated},
+			wantQuantity: 1,
+			wantTotal:    50,
+		},
+		{
+			name:         "completing a multi-buy publishes an offer triggered event",
+			basket:       &basket{items: map[sku.SKU]quantity.Quantity{skuGen('A'): *quantity.New(2)}},
+			scan:         skuGen('A'),
+			wantKinds:    []EventKind{EventItemScanned, EventQuantityChanged, EventOfferTriggered, EventTotalRecalculated},
+			wantQuantity: 3,
+			wantTotal:    130,
+		},
+		{
+			name:      "scanning an unknown item publishes an unknown item event",
+			basket:    NewBasket(),
+			scan:      skuGen('Z'),
+			wantKinds: []EventKind{EventUnknownItem},
+			wantTotal: 0,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCheckout(pricingRules, tt.basket, &MockScanner{})
+			if err != nil {
+				t.Fatalf("failed to init checkout: %v", err)
+			}
+
+			var got []Event
+			unsubscribe := c.Subscribe(func(e Event) { got = append(got, e) })
+			defer unsubscribe()
+
+			if err := c.Scan(tt.scan, *quantity.New(1)); (err != nil) != tt.wantErr {
+				t.Errorf("checkout.Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var gotKinds []EventKind
+			for _, e := range got {
+				gotKinds = append(gotKinds, e.Kind)
+			}
+
+			if !reflect.DeepEqual(gotKinds, tt.wantKinds) {
+				t.Errorf("published events = %v, want %v", gotKinds, tt.wantKinds)
+			}
+
+			// the scan carries how many were scanned, the quantity change carries the whole basket line
+			for _, e := range got {
+				switch e.Kind {
+				case EventItemScanned:
+					if e.Quantity.Value() != 1 || e.Previous.Value() != 0 {
+						t.Errorf("item scanned event = %+v, want a quantity of 1", e)
+					}
+				case EventQuantityChanged:
+					if e.Quantity.Value() != tt.wantQuantity || e.Previous.Value() != tt.wantQuantity-1 {
+						t.Errorf("quantity changed event = %+v, want %d to %d", e, tt.wantQuantity-1, tt.wantQuantity)
+					}
+				}
+			}
+
+			if len(got) > 0 && got[len(got)-1].Subtotal != tt.wantTotal {
+				t.Errorf("event subtotal = %d, want %d", got[len(got)-1].Subtotal, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func Test_checkout_SubscribeChan(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	c, err := NewCheckout(&pricing.SimplePricing{UnitPrices: map[sku.SKU]currency.Pence{skuA: 10}}, NewBasket(), &MockScanner{})
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	// nobody reads from the channel so it fills up and the rest are dropped
+	events, unsubscribe := c.SubscribeChan(1)
+
+	for i := 0; i < 3; i++ {
+		if err := c.Scan(skuA, *quantity.New(1)); err != nil {
+			t.Fatalf("checkout.Scan() error = %v", err)
+		}
+	}
+
+	// 3 scans publish 3 events each
+	if got := c.DroppedEvents(); got != 8 {
+		t.Errorf("checkout.DroppedEvents() = %d, want %d", got, 8)
+	}
+
+	first := <-events
+	if first.Kind != EventItemScanned || first.Subtotal != 10 {
+		t.Errorf("unexpected first event: %+v", first)
+	}
+
+	unsubscribe()
+
+	if _, open := <-events; open {
+		t.Errorf("expected channel to be closed after unsubscribing")
+	}
+}
@@ -0,0 +1,22 @@
+package checkout
+
+import "github.com/Joshswooft/thinkmoney-test/currency"
+
+// Experimental is an optional interface for pricing rules taking part in a price experiment, the variant is printed on the receipt
+type Experimental interface {
+	Variant() string
+}
+
+// ExperimentOutcomes records how a checkout session in a price experiment ended, experiment.Journal.ForSession satisfies this
+type ExperimentOutcomes interface {
+	Completed(total currency.Pence) error
+	Abandoned() error
+}
+
+// WithExperimentOutcomes records whether the session was completed, and what it took, or abandoned when the checkout is
+// completed or abandoned. Sessions which are suspended aren't recorded until the resumed checkout ends.
+func WithExperimentOutcomes(outcomes ExperimentOutcomes) Option {
+	return func(c *checkout) {
+		c.outcomes = outcomes
+	}
+}
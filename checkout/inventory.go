@@ -0,0 +1,156 @@
+package checkout
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Joshswooft/thinkmoney-test/inventory"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+var ErrCheckoutClosed = errors.New("the checkout has been completed or abandoned")
+
+// Inventory reserves stock for the items in the basket, the inventory package satisfies this
+type Inventory interface {
+	// a negative delta gives stock back, shortfall is how many were reserved without stock when the policy allows it
+	Reserve(reservation string, sku sku.SKU, delta int) (shortfall int, err error)
+	Commit(reservation string) error
+	Release(reservation string) error
+}
+
+func newReservationID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// reserves stock for a scan, does nothing without an inventory
+func (c *checkout) reserve(sku sku.SKU, delta int) error {
+	if c.inventory == nil {
+		return nil
+	}
+
+	shortfall, err := c.inventory.Reserve(c.reservation, sku, delta)
+	if err != nil {
+		return err
+	}
+
+	if shortfall > 0 {
+		c.metrics().IncCounter(MetricStockShortages)
+		c.log().Warn("scanned more than is in stock", slog.String(logKeySKU, sku.String()), slog.Int(logKeyQuantity, shortfall))
+	}
+
+	return nil
+}
+
+// reserves stock for everything already in the basket e.g. after resuming
+func (c *checkout) reserveBasket() error {
+	var err error
+	c.basket.RangeOrdered(BySKU, func(id itemID, qty quantity.Quantity) {
+		if err == nil {
+			err = c.reserve(id, qty.Value())
+		}
+	})
+	return err
+}
+
+// gives back the stock reserved by the checkout, a checkout which never reserved anything isn't an error
+func (c *checkout) releaseStock() error {
+	if c.inventory == nil {
+		return nil
+	}
+	if err := c.inventory.Release(c.reservation); err != nil && !errors.Is(err, inventory.ErrReservationUnknown) {
+		return err
+	}
+	return nil
+}
+
+// takes the reserved stock out of the inventory, a checkout which never reserved anything isn't an error
+func (c *checkout) commitStock() error {
+	if c.inventory == nil {
+		return nil
+	}
+	if err := c.inventory.Commit(c.reservation); err != nil && !errors.Is(err, inventory.ErrReservationUnknown) {
+		return err
+	}
+	return nil
+}
+
+// settles the stock and closes the checkout holding the lock throughout so it can only be completed or abandoned once,
+// only completing a sale needs the age restricted items approved
+func (c *checkout) close(needsApproval bool, settleStock func() error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.checkOpenLocked(); err != nil {
+		return err
+	}
+
+	if needsApproval && c.approvalPending {
+		return ErrApprovalRequired
+	}
+
+	if err := settleStock(); err != nil {
+		return err
+	}
+
+	c.closed = true
+	return nil
+}
+
+// the checkout is open until it is suspended, completed or abandoned
+func (c *checkout) checkOpen() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checkOpenLocked()
+}
+
+// checkOpen for callers already holding c.mu
+func (c *checkout) checkOpenLocked() error {
+	switch {
+	case c.closed:
+		return ErrCheckoutClosed
+	case c.suspended:
+		return ErrCheckoutSuspended
+	default:
+		return nil
+	}
+}
+
+// Complete finishes the sale once it has been paid for and takes the reserved stock out of the inventory
+// nothing else can be scanned afterwards
+func (c *checkout) Complete() error {
+	if err := c.close(true, c.commitStock); err != nil {
+		return err
+	}
+
+	if c.outcomes != nil {
+		if err := c.outcomes.Completed(c.total()); err != nil {
+			return fmt.Errorf("sale completed but the experiment outcome wasn't recorded: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Abandon gives back the reserved stock when the customer leaves without paying
+// nothing else can be scanned afterwards
+func (c *checkout) Abandon() error {
+	if err := c.close(false, c.releaseStock); err != nil {
+		return err
+	}
+
+	if c.outcomes != nil {
+		if err := c.outcomes.Abandoned(); err != nil {
+			return fmt.Errorf("checkout abandoned but the experiment outcome wasn't recorded: %w", err)
+		}
+	}
+
+	return nil
+}
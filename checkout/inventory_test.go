@@ -0,0 +1,277 @@
+package checkout
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/inventory"
+	"github.com/Joshswooft/thinkmoney-test/metrics"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func Test_checkout_inventory(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	rules := &MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 50}}
+
+	newInventory := func(policy inventory.Policy) *inventory.Inventory {
+		inv := inventory.New(policy)
+		if err := inv.SetStock(skuA, 5); err != nil {
+			t.Fatalf("failed to set stock: %v", err)
+		}
+		return inv
+	}
+
+	t.Run("completed sale takes the stock", func(t *testing.T) {
+		inv := newInventory(inventory.PolicyError)
+
+		c, err := NewCheckout(rules, NewBasket(), &MockScanner{}, WithInventory(inv))
+		if err != nil {
+			t.Fatalf("failed to init checkout: %v", err)
+		}
+
+		if err := c.Scan(skuA, *quantity.New(3)); err != nil {
+			t.Fatalf("checkout.Scan() error = %v", err)
+		}
+
+		if got := inv.Available(skuA); got != 2 {
+			t.Errorf("available while scanning = %d, want %d", got, 2)
+		}
+
+		if err := c.Complete(); err != nil {
+			t.Fatalf("checkout.Complete() error = %v", err)
+		}
+
+		if got := inv.OnHand(skuA); got != 2 {
+			t.Errorf("on hand after the sale = %d, want %d", got, 2)
+		}
+
+		if err := c.Scan(skuA, *quantity.New(1)); !errors.Is(err, ErrCheckoutClosed) {
+			t.Errorf("scanning after completing error = %v, want %v", err, ErrCheckoutClosed)
+		}
+	})
+
+	t.Run("abandoned basket gives the stock back", func(t *testing.T) {
+		inv := newInventory(inventory.PolicyError)
+
+		c, err := NewCheckout(rules, NewBasket(), &MockScanner{}, WithInventory(inv))
+		if err != nil {
+			t.Fatalf("failed to init checkout: %v", err)
+		}
+
+		if err := c.Scan(skuA, *quantity.New(3)); err != nil {
+			t.Fatalf("checkout.Scan() error = %v", err)
+		}
+
+		if err := c.Abandon(); err != nil {
+			t.Fatalf("checkout.Abandon() error = %v", err)
+		}
+
+		if got := inv.Available(skuA); got != 5 {
+			t.Errorf("available after abandoning = %d, want %d", got, 5)
+		}
+	})
+
+	t.Run("error policy refuses scans without stock", func(t *testing.T) {
+		inv := newInventory(inventory.PolicyError)
+
+		c, err := NewCheckout(rules, NewBasket(), &MockScanner{}, WithInventory(inv))
+		if err != nil {
+			t.Fatalf("failed to init checkout: %v", err)
+		}
+
+		if err := c.Scan(skuA, *quantity.New(6)); !errors.Is(err, inventory.ErrInsufficientStock) {
+			t.Fatalf("checkout.Scan() error = %v, want %v", err, inventory.ErrInsufficientStock)
+		}
+
+		if _, err := c.basket.GetItem(skuA); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("expected the basket to be unchanged, got %v", err)
+		}
+	})
+
+	t.Run("warn policy sells it anyway", func(t *testing.T) {
+		inv := newInventory(inventory.PolicyWarn)
+		registry := metrics.NewRegistry()
+
+		c, err := NewCheckout(rules, NewBasket(), &MockScanner{}, WithInventory(inv), WithMetrics(registry))
+		if err != nil {
+			t.Fatalf("failed to init checkout: %v", err)
+		}
+
+		if err := c.Scan(skuA, *quantity.New(6)); err != nil {
+			t.Fatalf("checkout.Scan() error = %v", err)
+		}
+
+		if got := registry.Counter(MetricStockShortages); got != 1 {
+			t.Errorf("stock shortages = %v, want %v", got, 1)
+		}
+	})
+
+	t.Run("suspending releases the stock until it is resumed", func(t *testing.T) {
+		inv := newInventory(inventory.PolicyError)
+
+		c, err := NewCheckout(rules, NewBasket(), &MockScanner{}, WithInventory(inv))
+		if err != nil {
+			t.Fatalf("failed to init checkout: %v", err)
+		}
+
+		if err := c.Scan(skuA, *quantity.New(3)); err != nil {
+			t.Fatalf("checkout.Scan() error = %v", err)
+		}
+
+		store, err := NewFileSuspendStore(t.TempDir(), time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		code, err := c.Suspend(store)
+		if err != nil {
+			t.Fatalf("checkout.Suspend() error = %v", err)
+		}
+
+		if got := inv.Available(skuA); got != 5 {
+			t.Errorf("available while suspended = %d, want %d", got, 5)
+		}
+
+		if _, err := Resume(store, code, rules, NewBasket(), &MockScanner{}, WithInventory(inv)); err != nil {
+			t.Fatalf("Resume() error = %v", err)
+		}
+
+		if got := inv.Available(skuA); got != 2 {
+			t.Errorf("available after resuming = %d, want %d", got, 2)
+		}
+	})
+
+	t.Run("completing and abandoning at the same time closes the checkout once", func(t *testing.T) {
+		inv := newInventory(inventory.PolicyError)
+		outcomes := &recordedOutcomes{}
+
+		c, err := NewCheckout(rules, NewBasket(), &MockScanner{}, WithInventory(slowInventory{inv}), WithExperimentOutcomes(outcomes))
+		if err != nil {
+			t.Fatalf("failed to init checkout: %v", err)
+		}
+
+		if err := c.Scan(skuA, *quantity.New(3)); err != nil {
+			t.Fatalf("checkout.Scan() error = %v", err)
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			end := c.Complete
+			if i%2 == 1 {
+				end = c.Abandon
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- end()
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		closed := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				closed++
+			case !errors.Is(err, ErrCheckoutClosed):
+				t.Errorf("closing error = %v, want %v", err, ErrCheckoutClosed)
+			}
+		}
+
+		if closed != 1 || len(outcomes.outcomes) != 1 {
+			t.Errorf("closed %d times and recorded %v, want to close once", closed, outcomes.outcomes)
+		}
+
+		// the sale either took the stock or gave it all back, never both
+		onHand, available := inv.OnHand(skuA), inv.Available(skuA)
+		if (onHand != 2 || available != 2) && (onHand != 5 || available != 5) {
+			t.Errorf("on hand %d and available %d after closing once", onHand, available)
+		}
+	})
+}
+
+// takes a while to settle the stock so closing at the same time overlaps
+type slowInventory struct {
+	*inventory.Inventory
+}
+
+func (i slowInventory) Commit(reservation string) error {
+	time.Sleep(10 * time.Millisecond)
+	return i.Inventory.Commit(reservation)
+}
+
+func (i slowInventory) Release(reservation string) error {
+	time.Sleep(10 * time.Millisecond)
+	return i.Inventory.Release(reservation)
+}
+
+// records experiment outcomes in memory
+type recordedOutcomes struct {
+	mu       sync.Mutex
+	outcomes []string
+	totals   []currency.Pence
+}
+
+func (r *recordedOutcomes) Completed(total currency.Pence) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outcomes = append(r.outcomes, "completed")
+	r.totals = append(r.totals, total)
+	return nil
+}
+
+func (r *recordedOutcomes) Abandoned() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outcomes = append(r.outcomes, "abandoned")
+	r.totals = append(r.totals, 0)
+	return nil
+}
+
+func Test_checkout_experimentOutcomes(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	rules := &MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 50}}
+
+	tests := []struct {
+		name      string
+		end       func(c *checkout) error
+		wantTotal currency.Pence
+		want      string
+	}{
+		{name: "completed", end: (*checkout).Complete, want: "completed", wantTotal: 100},
+		{name: "abandoned", end: (*checkout).Abandon, want: "abandoned"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcomes := &recordedOutcomes{}
+
+			c, err := NewCheckout(rules, NewBasket(), &MockScanner{}, WithExperimentOutcomes(outcomes))
+			if err != nil {
+				t.Fatalf("failed to init checkout: %v", err)
+			}
+			if err := c.Scan(skuA, *quantity.New(2)); err != nil {
+				t.Fatalf("checkout.Scan() error = %v", err)
+			}
+
+			if err := tt.end(c); err != nil {
+				t.Fatalf("ending the checkout error = %v", err)
+			}
+			// ending it again is refused and isn't recorded twice
+			if err := tt.end(c); !errors.Is(err, ErrCheckoutClosed) {
+				t.Errorf("ending the checkout again error = %v, want %v", err, ErrCheckoutClosed)
+			}
+
+			if len(outcomes.outcomes) != 1 || outcomes.outcomes[0] != tt.want || outcomes.totals[0] != tt.wantTotal {
+				t.Errorf("recorded %v %v, want %s %d", outcomes.outcomes, outcomes.totals, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package checkout
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// sources recorded against journal entries which didn't come from a scanner
+const (
+	SourceManual = "manual"
+	SourceMerge  = "merge"
+	// used for scanners which don't have a name
+	SourceScanner = "scanner"
+)
+
+var errJournalOutOfOrder = errors.New("journal entries must be in sequence order")
+
+// JournalEntry is a single change to the basket in the order it happened
+type JournalEntry struct {
+	Seq    uint64    `json:"seq"`
+	Time   time.Time `json:"time"`
+	SKU    sku.SKU   `json:"sku"`
+	Delta  int       `json:"delta"`
+	Source string    `json:"source"`
+}
+
+// JournalFilter narrows down a journal query, zero values match everything
+type JournalFilter struct {
+	SKUs   []sku.SKU
+	Source string
+	// entries at or after Since and before Until
+	Since time.Time
+	Until time.Time
+	// entries with a sequence number greater than AfterSeq
+	AfterSeq uint64
+}
+
+func (f JournalFilter) matches(e JournalEntry) bool {
+	if e.Seq <= f.AfterSeq {
+		return false
+	}
+	if f.Source != "" && e.Source != f.Source {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	if len(f.SKUs) == 0 {
+		return true
+	}
+	for _, s := range f.SKUs {
+		if s == e.SKU {
+			return true
+		}
+	}
+	return false
+}
+
+// Journal is an append-only log of every scan, the basket only keeps totals so this is where the order and time of scans live
+// operation is go-routine safe
+type Journal struct {
+	mu      sync.RWMutex
+	entries []JournalEntry
+	now     func() time.Time
+}
+
+func NewJournal() *Journal {
+	return &Journal{now: time.Now}
+}
+
+// Append records a change to the basket and returns the entry with its sequence number and timestamp
+func (j *Journal) Append(sku sku.SKU, delta int, source string) JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now
+	if j.now != nil {
+		now = j.now
+	}
+
+	seq := uint64(1)
+	if n := len(j.entries); n > 0 {
+		seq = j.entries[n-1].Seq + 1
+	}
+
+	// stored in utc without the monotonic clock reading so entries compare equal after being exported and read back
+	entry := JournalEntry{Seq: seq, Time: now().UTC().Round(0), SKU: sku, Delta: delta, Source: source}
+
+	j.entries = append(j.entries, entry)
+
+	return entry
+}
+
+// appends existing entries e.g. from a suspended transaction, keeping their sequence numbers and times
+func (j *Journal) load(entries []JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, e := range entries {
+		if n := len(j.entries); n > 0 && e.Seq <= j.entries[n-1].Seq {
+			return errJournalOutOfOrder
+		}
+		j.entries = append(j.entries, e)
+	}
+	return nil
+}
+
+// Len is the number of entries in the journal
+func (j *Journal) Len() int {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return len(j.entries)
+}
+
+// Entries returns a copy of every entry in sequence order
+func (j *Journal) Entries() []JournalEntry {
+	return j.Query(JournalFilter{})
+}
+
+// Query returns a copy of the entries matching the filter in sequence order
+func (j *Journal) Query(filter JournalFilter) []JournalEntry {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	matched := make([]JournalEntry, 0)
+	for _, e := range j.entries {
+		if filter.matches(e) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// Replay applies every entry in order to the basket, use a fresh basket to rebuild the original
+func (j *Journal) Replay(b Basket) error {
+	return ReplayEntries(b, j.Entries())
+}
+
+// ReplayEntries applies the entries in order to the basket, quantities can't go below zero
+func ReplayEntries(b Basket, entries []JournalEntry) error {
+	for _, e := range entries {
+		qty, err := b.GetItem(e.SKU)
+		if errors.Is(err, ErrItemNotFound) {
+			qty = *quantity.New(0)
+		} else if err != nil {
+			return err
+		}
+
+		qty.Add(e.Delta)
+
+		if err := b.AddItem(e.SKU, qty); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export writes the journal as json lines, one entry per line
+func (j *Journal) Export(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, e := range j.Entries() {
+		if err := encoder.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportCSV writes the journal as csv with a header row, easier to open in a spreadsheet for loss prevention reviews
+func (j *Journal) ExportCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"seq", "time", "sku", "delta", "source"}); err != nil {
+		return err
+	}
+
+	for _, e := range j.Entries() {
+		record := []string{
+			strconv.FormatUint(e.Seq, 10),
+			e.Time.Format(time.RFC3339Nano),
+			e.SKU.String(),
+			strconv.Itoa(e.Delta),
+			e.Source,
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// ReadJournal reads a journal written by Export
+func ReadJournal(r io.Reader) (*Journal, error) {
+	var entries []JournalEntry
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var e JournalEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	j := NewJournal()
+	return j, j.load(entries)
+}
+
+// Journal returns the checkout's scan journal
+func (c *checkout) Journal() *Journal {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.journal == nil {
+		c.journal = NewJournal()
+	}
+	return c.journal
+}
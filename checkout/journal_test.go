@@ -0,0 +1,131 @@
+package checkout
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func Test_checkout_journal(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	tick := 0
+
+	journal := NewJournal()
+	journal.now = func() time.Time {
+		tick++
+		return start.Add(time.Duration(tick) * time.Minute)
+	}
+
+	scanner, err := NewSkuScanner(strings.NewReader("AB"), WithScannerName("lane-1"))
+	if err != nil {
+		t.Fatalf("failed to init scanner: %v", err)
+	}
+
+	c, err := NewCheckout(&MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 10, skuB: 20}}, NewBasket(), scanner, WithJournal(journal))
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	if err := c.ScanItems(); err != nil {
+		t.Fatalf("checkout.ScanItems() error = %v", err)
+	}
+
+	if err := c.Scan(skuA, *quantity.New(3)); err != nil {
+		t.Fatalf("checkout.Scan() error = %v", err)
+	}
+
+	want := []JournalEntry{
+		{Seq: 1, Time: start.Add(time.Minute), SKU: skuA, Delta: 1, Source: "lane-1"},
+		{Seq: 2, Time: start.Add(2 * time.Minute), SKU: skuB, Delta: 1, Source: "lane-1"},
+		{Seq: 3, Time: start.Add(3 * time.Minute), SKU: skuA, Delta: 3, Source: SourceManual},
+	}
+
+	if got := c.Journal().Entries(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("journal entries = %v, want %v", got, want)
+	}
+
+	queries := []struct {
+		name    string
+		filter  JournalFilter
+		wantSeq []uint64
+	}{
+		{name: "by sku", filter: JournalFilter{SKUs: []sku.SKU{skuA}}, wantSeq: []uint64{1, 3}},
+		{name: "by source", filter: JournalFilter{Source: SourceManual}, wantSeq: []uint64{3}},
+		{name: "by time range", filter: JournalFilter{Since: start.Add(2 * time.Minute), Until: start.Add(3 * time.Minute)}, wantSeq: []uint64{2}},
+		{name: "after a sequence number", filter: JournalFilter{AfterSeq: 1}, wantSeq: []uint64{2, 3}},
+		{name: "nothing matches", filter: JournalFilter{Source: "lane-2"}, wantSeq: []uint64{}},
+	}
+	for _, tt := range queries {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSeq := []uint64{}
+			for _, e := range c.Journal().Query(tt.filter) {
+				gotSeq = append(gotSeq, e.Seq)
+			}
+			if !reflect.DeepEqual(gotSeq, tt.wantSeq) {
+				t.Errorf("Journal.Query() = %v, want %v", gotSeq, tt.wantSeq)
+			}
+		})
+	}
+
+	t.Run("replays into a fresh basket", func(t *testing.T) {
+		replayed := NewBasket()
+		if err := c.Journal().Replay(replayed); err != nil {
+			t.Fatalf("Journal.Replay() error = %v", err)
+		}
+
+		wantItems := map[sku.SKU]quantity.Quantity{skuA: *quantity.New(4), skuB: *quantity.New(1)}
+		if !reflect.DeepEqual(replayed.items, wantItems) {
+			t.Errorf("replayed basket = %v, want %v", replayed.items, wantItems)
+		}
+	})
+
+	t.Run("exports and reads back", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := c.Journal().Export(&buf); err != nil {
+			t.Fatalf("Journal.Export() error = %v", err)
+		}
+
+		read, err := ReadJournal(&buf)
+		if err != nil {
+			t.Fatalf("ReadJournal() error = %v", err)
+		}
+
+		if got := read.Entries(); !reflect.DeepEqual(got, want) {
+			t.Errorf("read journal = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("exports csv", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := c.Journal().ExportCSV(&buf); err != nil {
+			t.Fatalf("Journal.ExportCSV() error = %v", err)
+		}
+
+		wantCSV := "seq,time,sku,delta,source\n" +
+			"1,2024-01-01T09:01:00Z,A,1,lane-1\n" +
+			"2,2024-01-01T09:02:00Z,B,1,lane-1\n" +
+			"3,2024-01-01T09:03:00Z,A,3,manual\n"
+
+		if buf.String() != wantCSV {
+			t.Errorf("Journal.ExportCSV() =\n%s\nwant\n%s", buf.String(), wantCSV)
+		}
+	})
+}
+
+func TestReadJournal_outOfOrder(t *testing.T) {
+	input := `{"seq":2,"time":"2024-01-01T09:00:00Z","sku":"A","delta":1,"source":"manual"}
+{"seq":1,"time":"2024-01-01T09:00:00Z","sku":"A","delta":1,"source":"manual"}
+`
+	if _, err := ReadJournal(strings.NewReader(input)); err != errJournalOutOfOrder {
+		t.Errorf("ReadJournal() error = %v, want %v", err, errJournalOutOfOrder)
+	}
+}
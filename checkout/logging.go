@@ -0,0 +1,78 @@
+package checkout
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Joshswooft/thinkmoney-test/inventory"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// attribute keys used on every log record so they can be parsed consistently
+const (
+	logKeySKU        = "sku"
+	logKeyQuantity   = "quantity"
+	logKeyPosition   = "position"
+	logKeyErrorKind  = "error_kind"
+	logKeyError      = "error"
+	logKeySupervisor = "supervisor"
+	logKeyCard       = "card"
+)
+
+// values for the error_kind attribute
+const (
+	errorKindInvalidSKU  = "invalid_sku"
+	errorKindUnknownItem = "unknown_item"
+	errorKindRead        = "read"
+	errorKindBasket      = "basket"
+	errorKindLimit       = "limit_exceeded"
+	errorKindApproval    = "approval_required"
+	errorKindInactive    = "inactive_product"
+	errorKindOutOfStock  = "out_of_stock"
+)
+
+// the standard library doesn't ship a no-op handler in go 1.21 so we have our own
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// default logger which throws everything away, handy for tests
+var discardLogger = slog.New(discardHandler{})
+
+// returns the given logger or the no-op logger when it is nil
+func loggerOrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return discardLogger
+	}
+	return logger
+}
+
+// classifies an error so log records can be grouped by the type of failure
+// errors we don't recognise are given the fallback kind
+func errorKind(err error, fallback string) string {
+	switch {
+	case errors.Is(err, sku.ErrNoSpecialCharacters):
+		return errorKindInvalidSKU
+	case errors.Is(err, errUnknownItemScanned):
+		return errorKindUnknownItem
+	case errors.As(err, new(*LimitExceededError)):
+		return errorKindLimit
+	case errors.Is(err, ErrApprovalRequired):
+		return errorKindApproval
+	case errors.Is(err, ErrInactiveProduct):
+		return errorKindInactive
+	case errors.Is(err, inventory.ErrInsufficientStock):
+		return errorKindOutOfStock
+	default:
+		return fallback
+	}
+}
+
+// builds the standard attributes for an error
+func errorAttrs(err error, fallbackKind string) []any {
+	return []any{slog.String(logKeyErrorKind, errorKind(err, fallbackKind)), slog.Any(logKeyError, err)}
+}
@@ -0,0 +1,75 @@
+package checkout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func Test_checkout_ScanItems_logging(t *testing.T) {
+
+	skuGen := func(r rune) sku.SKU {
+		return skuGenerator(t, r)
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	scanner, err := NewSkuScanner(strings.NewReader("A$C"), WithScannerLogger(logger))
+	if err != nil {
+		t.Fatalf("failed to init scanner: %v", err)
+	}
+
+	pricingRules := &MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuGen('A'): 10}}
+
+	c, err := NewCheckout(pricingRules, NewBasket(), scanner, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	if err := c.ScanItems(); err != nil {
+		t.Fatalf("checkout.ScanItems() error = %v", err)
+	}
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		record := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log record is not valid json: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	want := []map[string]any{
+		{logKeyPosition: float64(1), logKeyErrorKind: errorKindInvalidSKU},
+		{logKeySKU: "C", logKeyQuantity: float64(1), logKeyErrorKind: errorKindUnknownItem},
+	}
+
+	if len(records) != len(want) {
+		t.Fatalf("got %d log records, want %d: %s", len(records), len(want), buf.String())
+	}
+
+	for i, attrs := range want {
+		for key, value := range attrs {
+			if records[i][key] != value {
+				t.Errorf("record %d attribute %q = %v, want %v", i, key, records[i][key], value)
+			}
+		}
+	}
+}
+
+func Test_loggerOrDiscard(t *testing.T) {
+	if got := loggerOrDiscard(nil); got != discardLogger {
+		t.Errorf("expected the discard logger when no logger is given")
+	}
+
+	if discardLogger.Enabled(context.Background(), slog.LevelError) {
+		t.Errorf("discard logger should never be enabled")
+	}
+}
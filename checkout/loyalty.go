@@ -0,0 +1,56 @@
+package checkout
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/Joshswooft/thinkmoney-test/loyalty"
+)
+
+var (
+	errNoLoyalty       = errors.New("loyalty cards are not accepted at this checkout")
+	errCardAlreadyUsed = errors.New("a different loyalty card has already been scanned")
+)
+
+// Loyalty looks up customer loyalty cards, the loyalty store satisfies this
+type Loyalty interface {
+	Account(card string) (loyalty.Account, error)
+}
+
+// ScanCard scans a customer's loyalty card, the rest of the transaction is priced with member prices
+func (c *checkout) ScanCard(card string) error {
+	if err := c.checkOpen(); err != nil {
+		return err
+	}
+
+	if c.loyalty == nil {
+		return errNoLoyalty
+	}
+
+	if _, err := c.loyalty.Account(card); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.member != "" && c.member != card {
+		c.mu.Unlock()
+		return errCardAlreadyUsed
+	}
+	c.member = card
+	c.mu.Unlock()
+
+	c.log().Info("loyalty card scanned", slog.String(logKeyCard, loyalty.MaskCard(card)))
+
+	if c.events.hasSubscribers() {
+		c.events.publish(Event{Kind: EventTotalRecalculated, Subtotal: c.total()})
+	}
+
+	return nil
+}
+
+// Member is the loyalty card scanned into the checkout, empty when there isn't one
+func (c *checkout) Member() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.member
+}
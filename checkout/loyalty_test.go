@@ -0,0 +1,137 @@
+package checkout
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/experiment"
+	"github.com/Joshswooft/thinkmoney-test/loyalty"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func Test_checkout_ScanCard(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	const card = "6331100012345678"
+
+	rules := &pricing.SpecialPricing{Config: map[sku.SKU]pricing.PricingData{
+		skuA: {UnitPrice: 50, MemberPrice: 40},
+	}}
+
+	accounts, err := loyalty.NewFileStore(filepath.Join(t.TempDir(), "accounts.json"), loyalty.DefaultScheme)
+	if err != nil {
+		t.Fatalf("failed to create loyalty store: %v", err)
+	}
+	if _, err := accounts.Open(card, "Test Customer"); err != nil {
+		t.Fatalf("failed to open account: %v", err)
+	}
+
+	c, err := NewCheckout(rules, NewBasket(), &MockScanner{}, WithLoyalty(accounts))
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	if err := c.Scan(skuA, *quantity.New(2)); err != nil {
+		t.Fatalf("checkout.Scan() error = %v", err)
+	}
+
+	if got := c.GetTotalPrice(); got != 100 {
+		t.Errorf("checkout.GetTotalPrice() before the card = %d, want %d", got, 100)
+	}
+
+	if err := c.ScanCard("unknown"); !errors.Is(err, loyalty.ErrAccountNotFound) {
+		t.Errorf("checkout.ScanCard() error = %v, want %v", err, loyalty.ErrAccountNotFound)
+	}
+
+	if err := c.ScanCard(card); err != nil {
+		t.Fatalf("checkout.ScanCard() error = %v", err)
+	}
+
+	// items scanned before the card get member prices too
+	if got := c.GetTotalPrice(); got != 80 {
+		t.Errorf("checkout.GetTotalPrice() after the card = %d, want %d", got, 80)
+	}
+
+	if got := c.Sale().Member; got != "************5678" {
+		t.Errorf("sale member = %q, want the masked card", got)
+	}
+
+	// the card goes with the transaction when it is suspended
+	store, err := NewFileSuspendStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	code, err := c.Suspend(store)
+	if err != nil {
+		t.Fatalf("checkout.Suspend() error = %v", err)
+	}
+
+	resumed, err := Resume(store, code, rules, NewBasket(), &MockScanner{}, WithLoyalty(accounts))
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	if resumed.Member() != card || resumed.GetTotalPrice() != 80 {
+		t.Errorf("resumed checkout member = %q total = %d, want %q and 80", resumed.Member(), resumed.GetTotalPrice(), card)
+	}
+}
+
+func Test_checkout_ScanCard_withoutLoyalty(t *testing.T) {
+	c, err := NewCheckout(&MockPricingRules{}, NewBasket(), &MockScanner{})
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	if err := c.ScanCard("6331100012345678"); !errors.Is(err, errNoLoyalty) {
+		t.Errorf("checkout.ScanCard() error = %v, want %v", err, errNoLoyalty)
+	}
+}
+
+func Test_checkout_ScanCard_priceExperiment(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	const card = "6331100012345678"
+
+	trial, err := experiment.New("cheaper-a", experiment.Variant{Name: "trial", Weight: 1, Pricing: &pricing.SpecialPricing{Config: map[sku.SKU]pricing.PricingData{
+		skuA: {UnitPrice: 45, MemberPrice: 35},
+	}}})
+	if err != nil {
+		t.Fatalf("experiment.New() error = %v", err)
+	}
+	session, err := trial.ForSession("till-1-0001")
+	if err != nil {
+		t.Fatalf("ForSession() error = %v", err)
+	}
+
+	accounts, err := loyalty.NewFileStore(filepath.Join(t.TempDir(), "accounts.json"), loyalty.DefaultScheme)
+	if err != nil {
+		t.Fatalf("failed to create loyalty store: %v", err)
+	}
+	if _, err := accounts.Open(card, "Test Customer"); err != nil {
+		t.Fatalf("failed to open account: %v", err)
+	}
+
+	c, err := NewCheckout(session, NewBasket(), &MockScanner{}, WithLoyalty(accounts))
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	if err := c.Scan(skuA, *quantity.New(2)); err != nil {
+		t.Fatalf("checkout.Scan() error = %v", err)
+	}
+	if err := c.ScanCard(card); err != nil {
+		t.Fatalf("checkout.ScanCard() error = %v", err)
+	}
+
+	// the customer stays in the experiment and gets the variant's member prices
+	sale := c.Sale()
+	if sale.Variant != "cheaper-a/trial" {
+		t.Errorf("sale variant = %q, want %q", sale.Variant, "cheaper-a/trial")
+	}
+	if sale.Total != 70 {
+		t.Errorf("sale total = %d, want %d", sale.Total, 70)
+	}
+}
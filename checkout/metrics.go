@@ -0,0 +1,42 @@
+package checkout
+
+import (
+	"github.com/Joshswooft/thinkmoney-test/metrics"
+)
+
+// names of the metrics recorded by the checkout
+const (
+	MetricItemsScanned       = "checkout_items_scanned_total"
+	MetricUnknownSKUs        = "checkout_unknown_skus_total"
+	MetricScanErrors         = "checkout_scan_errors_total"
+	MetricTotalPriceDuration = "checkout_get_total_price_duration_seconds"
+	MetricBasketSize         = "checkout_basket_size"
+	MetricApprovalsRequired  = "checkout_approvals_required_total"
+	MetricStockShortages     = "checkout_stock_shortages_total"
+)
+
+// buckets for the number of items in a basket when it is priced
+var basketSizeBuckets = []float64{1, 2, 5, 10, 20, 50, 100, 200}
+
+// DescribeMetrics registers help text and bucket sizes for the checkout metrics
+func DescribeMetrics(r *metrics.Registry) {
+	r.Describe(MetricItemsScanned, "Number of items successfully scanned into a basket.")
+	r.Describe(MetricUnknownSKUs, "Number of scanned items which had no pricing rules.")
+	r.Describe(MetricScanErrors, "Number of scan failures by error kind.")
+	r.Describe(MetricTotalPriceDuration, "Time taken to price the basket in GetTotalPrice.")
+	r.Describe(MetricBasketSize, "Number of items in the basket when it is priced.")
+	r.Describe(MetricApprovalsRequired, "Number of transactions which needed a supervisor to approve age restricted items.")
+	r.Describe(MetricStockShortages, "Number of items scanned when the inventory said there was no stock left.")
+	r.SetBuckets(MetricBasketSize, basketSizeBuckets)
+}
+
+func (c *checkout) metrics() metrics.Recorder {
+	if c.recorder == nil {
+		return metrics.Discard
+	}
+	return c.recorder
+}
+
+func (c *checkout) recordScanError(err error, fallbackKind string) {
+	c.metrics().IncCounter(MetricScanErrors, metrics.L("kind", errorKind(err, fallbackKind)))
+}
@@ -0,0 +1,63 @@
+package checkout
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/metrics"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func Test_checkout_metrics(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	registry := metrics.NewRegistry()
+	DescribeMetrics(registry)
+
+	scanner, err := NewSkuScanner(strings.NewReader("A1AB"))
+	if err != nil {
+		t.Fatalf("failed to init scanner: %v", err)
+	}
+
+	c, err := NewCheckout(&MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 10}}, NewBasket(), scanner, WithMetrics(registry))
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	if err := c.ScanItems(); err != nil {
+		t.Fatalf("checkout.ScanItems() error = %v", err)
+	}
+
+	// items scanned counts every item, not every scan
+	if err := c.Scan(skuA, *quantity.New(3)); err != nil {
+		t.Fatalf("checkout.Scan() error = %v", err)
+	}
+
+	c.GetTotalPrice()
+
+	counters := []struct {
+		name   string
+		labels []metrics.Label
+		want   float64
+	}{
+		{name: MetricItemsScanned, want: 5},
+		{name: MetricUnknownSKUs, want: 1},
+		{name: MetricScanErrors, labels: []metrics.Label{metrics.L("kind", errorKindInvalidSKU)}, want: 1},
+		{name: MetricScanErrors, labels: []metrics.Label{metrics.L("kind", errorKindUnknownItem)}, want: 1},
+	}
+	for _, tt := range counters {
+		if got := registry.Counter(tt.name, tt.labels...); got != tt.want {
+			t.Errorf("counter %s%v = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+
+	if got := registry.Histogram(MetricTotalPriceDuration).Count; got != 1 {
+		t.Errorf("expected one total price latency observation, got %d", got)
+	}
+
+	if got := registry.Histogram(MetricBasketSize).Sum; got != 5 {
+		t.Errorf("expected a basket size of 5, got %v", got)
+	}
+}
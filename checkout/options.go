@@ -0,0 +1,100 @@
+package checkout
+
+import (
+	"log/slog"
+
+	"github.com/Joshswooft/thinkmoney-test/metrics"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+)
+
+// Option configures optional behaviour of the checkout
+type Option func(*checkout)
+
+// WithLogger sets the structured logger used by the checkout, by default nothing is logged
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *checkout) {
+		c.logger = logger
+	}
+}
+
+// WithMetrics sets where the checkout records its metrics, by default nothing is recorded
+func WithMetrics(recorder metrics.Recorder) Option {
+	return func(c *checkout) {
+		c.recorder = recorder
+	}
+}
+
+// WithItemOrder sets the order items appear in on receipts and snapshots, by default they are sorted by sku
+func WithItemOrder(order Order) Option {
+	return func(c *checkout) {
+		c.itemOrder = order
+	}
+}
+
+// WithItemRules sets purchase limits and age restrictions for products
+func WithItemRules(rules ItemRules) Option {
+	return func(c *checkout) {
+		c.itemRules = rules
+	}
+}
+
+// WithCatalog sets where the checkout looks up product information, inactive products are rejected and receipts show product names
+func WithCatalog(catalog Catalog) Option {
+	return func(c *checkout) {
+		c.catalog = catalog
+	}
+}
+
+// WithPromotions sets basket wide promotions e.g. category discounts, they are applied in order after per sku pricing
+func WithPromotions(promotions ...pricing.Promotion) Option {
+	return func(c *checkout) {
+		c.promotions = append(c.promotions, promotions...)
+	}
+}
+
+// WithSpendThresholds sets basket level discounts for spending enough e.g. "£5 off when you spend £40"
+// only the threshold saving the customer the most is applied, after any basket wide promotions
+func WithSpendThresholds(thresholds ...*pricing.SpendThreshold) Option {
+	return func(c *checkout) {
+		c.thresholds = append(c.thresholds, thresholds...)
+	}
+}
+
+// WithLoyalty accepts loyalty cards, once a card is scanned the pricing rules are told the customer is a member
+// (see pricing.ContextPricer) and charge member prices
+func WithLoyalty(accounts Loyalty) Option {
+	return func(c *checkout) {
+		c.loyalty = accounts
+	}
+}
+
+// WithInventory reserves stock as items are scanned, Complete takes it out of the inventory and Abandon gives it back
+func WithInventory(inventory Inventory) Option {
+	return func(c *checkout) {
+		c.inventory = inventory
+	}
+}
+
+// ScannerOption configures optional behaviour of the sku scanner
+type ScannerOption func(*skuScanner)
+
+// WithScannerLogger sets the structured logger used by the scanner, by default nothing is logged
+func WithScannerLogger(logger *slog.Logger) ScannerOption {
+	return func(s *skuScanner) {
+		s.logger = logger
+	}
+}
+
+// WithJournal sets the journal scans are recorded in, by default each checkout has its own
+func WithJournal(journal *Journal) Option {
+	return func(c *checkout) {
+		c.journal = journal
+	}
+}
+
+// WithScannerName sets the name recorded as the source of each scan in the journal
+func WithScannerName(name string) ScannerOption {
+	return func(s *skuScanner) {
+		s.name = name
+	}
+}
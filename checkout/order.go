@@ -0,0 +1,91 @@
+package checkout
+
+import (
+	"sort"
+
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+)
+
+// Item is a single basket line
+type Item struct {
+	SKU      itemID
+	Quantity quantity.Quantity
+}
+
+type orderKind int
+
+const (
+	scanOrder orderKind = iota
+	skuOrder
+	customOrder
+)
+
+// Order decides which order a basket iterates its items in, the zero value is ByScanOrder
+type Order struct {
+	kind    orderKind
+	compare func(a, b Item) int
+}
+
+var (
+	// ByScanOrder iterates items in the order they were first added to the basket
+	ByScanOrder = Order{kind: scanOrder}
+	// BySKU iterates items alphabetically by sku
+	BySKU = Order{kind: skuOrder}
+)
+
+// ByComparator iterates items using a custom comparison, like sort.Slice it should return a negative number when a comes before b
+// items which compare equal are left in scan order
+func ByComparator(compare func(a, b Item) int) Order {
+	if compare == nil {
+		return ByScanOrder
+	}
+	return Order{kind: customOrder, compare: compare}
+}
+
+// sortItems puts items which are already in scan order into the requested order
+func sortItems(items []Item, order Order) {
+	switch order.kind {
+	case skuOrder:
+		sort.SliceStable(items, func(i, j int) bool { return items[i].SKU.Value() < items[j].SKU.Value() })
+	case customOrder:
+		sort.SliceStable(items, func(i, j int) bool { return order.compare(items[i], items[j]) < 0 })
+	}
+}
+
+// orderedItems lists the items of a map based basket in the requested order
+// skus missing from scanned (e.g. a basket built directly from a map) go after the scanned ones sorted by sku
+func orderedItems(items map[itemID]quantity.Quantity, scanned []itemID, order Order) []Item {
+	ordered := make([]Item, 0, len(items))
+	seen := make(map[itemID]bool, len(items))
+
+	for _, id := range scanned {
+		qty, exists := items[id]
+		if !exists || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ordered = append(ordered, Item{SKU: id, Quantity: qty})
+	}
+
+	var unscanned []Item
+	for id, qty := range items {
+		if !seen[id] {
+			unscanned = append(unscanned, Item{SKU: id, Quantity: qty})
+		}
+	}
+	sortItems(unscanned, BySKU)
+
+	ordered = append(ordered, unscanned...)
+	sortItems(ordered, order)
+
+	return ordered
+}
+
+// Items lists every item in the basket in the given order
+func Items(b Basket, order Order) []Item {
+	var items []Item
+	b.RangeOrdered(order, func(id itemID, qty quantity.Quantity) {
+		items = append(items, Item{SKU: id, Quantity: qty})
+	})
+	return items
+}
@@ -0,0 +1,109 @@
+package checkout
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func Test_basket_RangeOrdered(t *testing.T) {
+	skuGen := func(r rune) sku.SKU {
+		return skuGenerator(t, r)
+	}
+
+	byQuantityDesc := ByComparator(func(a, b Item) int {
+		return b.Quantity.Value() - a.Quantity.Value()
+	})
+
+	tests := []struct {
+		name  string
+		order Order
+		want  string
+	}{
+		{name: "first scan order", order: ByScanOrder, want: "CAB"},
+		{name: "sku order", order: BySKU, want: "ABC"},
+		{name: "custom comparator", order: byQuantityDesc, want: "BAC"},
+		{name: "zero value is scan order", order: Order{}, want: "CAB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baskets := map[string]Basket{
+				"basket":      NewBasket(),
+				"mock basket": &MockBasketStorage{Items: map[sku.SKU]quantity.Quantity{}},
+			}
+
+			for name, b := range baskets {
+				// re-adding A must not move it to the end
+				for _, r := range "CABA" {
+					qty, _ := b.GetItem(skuGen(r))
+					qty.Add(1)
+					b.AddItem(skuGen(r), qty)
+				}
+				b.AddItem(skuGen('B'), *quantity.New(5))
+
+				got := ""
+				for _, item := range Items(b, tt.order) {
+					got += item.SKU.String()
+				}
+
+				if got != tt.want {
+					t.Errorf("%s: RangeOrdered() = %s, want %s", name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func Test_orderedItems_unscanned(t *testing.T) {
+	skuGen := func(r rune) sku.SKU {
+		return skuGenerator(t, r)
+	}
+
+	items := map[sku.SKU]quantity.Quantity{skuGen('D'): *quantity.New(1), skuGen('B'): *quantity.New(1), skuGen('C'): *quantity.New(1)}
+
+	want := []Item{
+		{SKU: skuGen('C'), Quantity: *quantity.New(1)},
+		{SKU: skuGen('B'), Quantity: *quantity.New(1)},
+		{SKU: skuGen('D'), Quantity: *quantity.New(1)},
+	}
+
+	// skus we don't know the scan order of go last, sorted by sku
+	if got := orderedItems(items, []sku.SKU{skuGen('C')}, ByScanOrder); !reflect.DeepEqual(got, want) {
+		t.Errorf("orderedItems() = %v, want %v", got, want)
+	}
+}
+
+func Test_checkout_Lines_order(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+
+	c, err := NewCheckout(&MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 50, skuB: 30}}, NewBasket(), &MockScanner{}, WithItemOrder(ByScanOrder))
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	for _, s := range []sku.SKU{skuB, skuA, skuB} {
+		if err := c.Scan(s, *quantity.New(1)); err != nil {
+			t.Fatalf("checkout.Scan() error = %v", err)
+		}
+	}
+
+	var gotLines, gotSnapshot []sku.SKU
+	for _, line := range c.Lines() {
+		gotLines = append(gotLines, line.SKU)
+	}
+	for _, item := range c.Snapshot().Items {
+		gotSnapshot = append(gotSnapshot, item.SKU)
+	}
+
+	want := []sku.SKU{skuB, skuA}
+	if !reflect.DeepEqual(gotLines, want) {
+		t.Errorf("receipt lines order = %v, want %v", gotLines, want)
+	}
+	if !reflect.DeepEqual(gotSnapshot, want) {
+		t.Errorf("snapshot order = %v, want %v", gotSnapshot, want)
+	}
+}
@@ -0,0 +1,67 @@
+package checkout
+
+import (
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+)
+
+// prices each basket line ready for basket wide promotions, sorted by sku so promotions always see the same order
+func (c *checkout) pricedLines() []pricing.Line {
+	var lines []pricing.Line
+	rules := c.rules()
+	c.basket.RangeOrdered(BySKU, func(id itemID, qty quantity.Quantity) {
+		lines = append(lines, pricing.Line{
+			SKU:       id,
+			Quantity:  qty,
+			UnitPrice: rules.GetPrice(id, *quantity.New(1)),
+			Price:     rules.GetPrice(id, qty),
+		})
+	})
+	return lines
+}
+
+// prices the whole basket: per sku pricing, then basket wide promotions and finally the best spend threshold
+func (c *checkout) price() (discounts []pricing.Discount, spend pricing.Spend) {
+	spend.BeforePromotions = c.subtotal()
+	spend.AfterPromotions = spend.BeforePromotions
+
+	if len(c.promotions) > 0 {
+		var amount currency.Pence
+		discounts, amount = pricing.ApplyPromotions(c.pricedLines(), c.promotions)
+		spend.AfterPromotions -= amount
+	}
+
+	if threshold, ok := pricing.ApplyThresholds(spend, c.thresholds); ok {
+		discounts = append(discounts, threshold)
+	}
+
+	return discounts, spend
+}
+
+// Discounts lists the basket wide promotions and spend threshold which apply to the basket
+func (c *checkout) Discounts() []pricing.Discount {
+	discounts, _ := c.price()
+	return discounts
+}
+
+// NextThreshold reports how much more the customer needs to spend to reach the next spend threshold
+func (c *checkout) NextThreshold() (pricing.ThresholdProgress, bool) {
+	if len(c.thresholds) == 0 {
+		return pricing.ThresholdProgress{}, false
+	}
+
+	_, spend := c.price()
+	return pricing.NextThreshold(spend, c.thresholds)
+}
+
+// total is the subtotal less any basket wide promotions and spend threshold
+func (c *checkout) total() currency.Pence {
+	discounts, spend := c.price()
+
+	total := spend.BeforePromotions
+	for _, discount := range discounts {
+		total -= discount.Amount
+	}
+	return total
+}
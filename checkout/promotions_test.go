@@ -0,0 +1,101 @@
+package checkout
+
+import (
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/catalog"
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func Test_checkout_promotions(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+
+	products, err := catalog.New(
+		catalog.Product{SKU: skuA, Name: "Apple", Category: "fruit"},
+		catalog.Product{SKU: skuB, Name: "Bread", Category: "bakery"},
+	)
+	if err != nil {
+		t.Fatalf("failed to create catalog: %v", err)
+	}
+
+	bakery, err := pricing.NewPercentOff("10% off bakery", pricing.Target{Category: "bakery"}, 10, products)
+	if err != nil {
+		t.Fatalf("failed to create promotion: %v", err)
+	}
+
+	c, err := NewCheckout(
+		&MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 50, skuB: 200}},
+		NewBasket(),
+		&MockScanner{},
+		WithPromotions(bakery),
+	)
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	var lastSubtotal currency.Pence
+	c.Subscribe(func(e Event) { lastSubtotal = e.Subtotal })
+
+	for _, s := range []sku.SKU{skuA, skuB} {
+		if err := c.Scan(s, *quantity.New(1)); err != nil {
+			t.Fatalf("checkout.Scan() error = %v", err)
+		}
+	}
+
+	if got := c.GetTotalPrice(); got != 50+200-20 {
+		t.Errorf("checkout.GetTotalPrice() = %d, want %d", got, 50+200-20)
+	}
+
+	if lastSubtotal != 230 {
+		t.Errorf("event subtotal = %d, want %d", lastSubtotal, 230)
+	}
+
+	discounts := c.ReceiptDiscounts()
+	if len(discounts) != 1 || discounts[0].Description != "10% off bakery" || discounts[0].Amount != 20 {
+		t.Errorf("checkout.ReceiptDiscounts() = %+v", discounts)
+	}
+}
+
+func Test_checkout_spendThresholds(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	fiver, err := pricing.NewAmountOffThreshold("£5 off £40", 4000, 500, pricing.MeasureBeforePromotions)
+	if err != nil {
+		t.Fatalf("failed to create threshold: %v", err)
+	}
+
+	c, err := NewCheckout(
+		&MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 1000}},
+		NewBasket(),
+		&MockScanner{},
+		WithSpendThresholds(fiver),
+	)
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	if err := c.Scan(skuA, *quantity.New(3)); err != nil {
+		t.Fatalf("checkout.Scan() error = %v", err)
+	}
+
+	sale := c.Sale()
+	if sale.Total != 3000 || len(sale.Discounts) != 0 {
+		t.Errorf("sale below threshold = %+v", sale)
+	}
+	if len(sale.Nudges) != 1 || sale.Nudges[0].Remaining != 1000 || sale.Nudges[0].Description != "£5 off £40" {
+		t.Errorf("sale nudges = %+v, want 1000 more for £5 off £40", sale.Nudges)
+	}
+
+	if err := c.Scan(skuA, *quantity.New(1)); err != nil {
+		t.Fatalf("checkout.Scan() error = %v", err)
+	}
+
+	sale = c.Sale()
+	if sale.Total != 3500 || len(sale.Nudges) != 0 {
+		t.Errorf("sale at threshold = %+v", sale)
+	}
+}
@@ -0,0 +1,81 @@
+package checkout
+
+import (
+	"github.com/Joshswooft/thinkmoney-test/loyalty"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/receipt"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// Lines prices every item in the basket ready to go on a receipt, in the checkout's item order
+// product names come from the catalog when there is one
+func (c *checkout) Lines() []receipt.Line {
+	quantities := make(map[sku.SKU]quantity.Quantity)
+	skus := make([]sku.SKU, 0)
+
+	c.basket.RangeOrdered(c.itemOrder, func(id itemID, qty quantity.Quantity) {
+		quantities[id] = qty
+		skus = append(skus, id)
+	})
+
+	lines := receipt.PriceLines(c.rules(), skus, quantities)
+	for i := range lines {
+		if product, found := c.product(lines[i].SKU); found {
+			lines[i].Name = product.Name
+		}
+	}
+
+	return lines
+}
+
+// ReceiptDiscounts lists the basket wide promotions ready to go on a receipt
+func (c *checkout) ReceiptDiscounts() []receipt.Discount {
+	var discounts []receipt.Discount
+	for _, d := range c.Discounts() {
+		discounts = append(discounts, receipt.Discount{Description: d.Promotion, Amount: d.Amount, Amounts: d.Amounts})
+	}
+	return discounts
+}
+
+// ReceiptNudges tells the customer how far they are from the next spend threshold
+func (c *checkout) ReceiptNudges() []receipt.Nudge {
+	next, ok := c.NextThreshold()
+	if !ok {
+		return nil
+	}
+	return []receipt.Nudge{{Description: next.Threshold.Label, Remaining: next.Remaining}}
+}
+
+// ReceiptSuggestions lists the offers the customer nearly qualified for, biggest saving first
+func (c *checkout) ReceiptSuggestions() []receipt.Suggestion {
+	var suggestions []receipt.Suggestion
+	for _, s := range c.Suggestions() {
+		suggestion := receipt.Suggestion{SKU: s.SKU, Add: s.Add, Saving: s.Saving}
+		if product, found := c.product(s.SKU); found {
+			suggestion.Name = product.Name
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions
+}
+
+// Sale prices the basket ready to issue a sale receipt
+func (c *checkout) Sale() receipt.SaleDetails {
+	sale := receipt.SaleDetails{
+		Lines:       c.Lines(),
+		Discounts:   c.ReceiptDiscounts(),
+		Total:       c.GetTotalPrice(),
+		Nudges:      c.ReceiptNudges(),
+		Suggestions: c.ReceiptSuggestions(),
+		Pricing:     c.rules(),
+		Promotions:  c.promotions,
+		Thresholds:  c.thresholds,
+	}
+	if member := c.Member(); member != "" {
+		sale.Member = loyalty.MaskCard(member)
+	}
+	if experimental, ok := c.pricingRules.(Experimental); ok {
+		sale.Variant = experimental.Variant()
+	}
+	return sale
+}
@@ -0,0 +1,100 @@
+package checkout
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/catalog"
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/receipt"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func Test_checkout_Lines(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+
+	c := &checkout{
+		basket:       &basket{items: map[sku.SKU]quantity.Quantity{skuB: *quantity.New(2), skuA: *quantity.New(1)}},
+		pricingRules: &MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 50, skuB: 30}},
+	}
+
+	want := []receipt.Line{
+		{SKU: skuA, Quantity: *quantity.New(1), Price: 50},
+		{SKU: skuB, Quantity: *quantity.New(2), Price: 60},
+	}
+
+	if got := c.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("checkout.Lines() = %v, want %v", got, want)
+	}
+}
+
+func Test_checkout_catalog(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+	skuD := skuGenerator(t, 'D')
+
+	products, err := catalog.New(
+		catalog.Product{SKU: skuA, Name: "Pineapple", Category: "fruit"},
+		catalog.Product{SKU: skuD, Name: "Doughnut", Category: "bakery", Discontinued: true},
+	)
+	if err != nil {
+		t.Fatalf("failed to create catalog: %v", err)
+	}
+
+	c, err := NewCheckout(
+		&MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 50, skuB: 30, skuD: 80}},
+		NewBasket(),
+		&MockScanner{},
+		WithCatalog(products),
+		WithItemOrder(ByScanOrder),
+	)
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	if err := c.Scan(skuD, *quantity.New(1)); !errors.Is(err, ErrInactiveProduct) {
+		t.Errorf("checkout.Scan() error = %v, want %v", err, ErrInactiveProduct)
+	}
+
+	// products missing from the catalog can still be sold
+	for _, s := range []sku.SKU{skuA, skuB} {
+		if err := c.Scan(s, *quantity.New(1)); err != nil {
+			t.Fatalf("checkout.Scan() error = %v", err)
+		}
+	}
+
+	want := []receipt.Line{
+		{SKU: skuA, Name: "Pineapple", Quantity: *quantity.New(1), Price: 50},
+		{SKU: skuB, Quantity: *quantity.New(1), Price: 30},
+	}
+
+	if got := c.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("checkout.Lines() = %v, want %v", got, want)
+	}
+}
+
+// pricing rules in a price experiment
+type experimentPricing struct {
+	MockPricingRules
+	variant string
+}
+
+func (p *experimentPricing) Variant() string {
+	return p.variant
+}
+
+func Test_checkout_Sale_variant(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	c := &checkout{
+		basket:       &basket{items: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(1)}},
+		pricingRules: &experimentPricing{MockPricingRules: MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 50}}, variant: "cheaper-a/trial"},
+	}
+
+	if got := c.Sale().Variant; got != "cheaper-a/trial" {
+		t.Errorf("checkout.Sale() variant = %q, want %q", got, "cheaper-a/trial")
+	}
+}
@@ -0,0 +1,102 @@
+package checkout
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+var (
+	ErrApprovalRequired  = errors.New("a supervisor must approve the age restricted items before scanning can continue")
+	errNoApprovalPending = errors.New("there is nothing waiting for approval")
+	errNoSupervisorGiven = errors.New("a supervisor id is required to approve")
+	errNegativeItemLimit = errors.New("item limits can't be negative")
+)
+
+// ItemRule is the restrictions on buying a product
+type ItemRule struct {
+	// the most a customer can buy in one transaction, zero means no limit
+	MaxPerCustomer int
+	// scanning the item needs a supervisor to approve the sale e.g. alcohol
+	AgeRestricted bool
+}
+
+// ItemRules are the restrictions for each product, products without a rule are unrestricted
+type ItemRules map[sku.SKU]ItemRule
+
+// LimitExceededError is returned when a scan would take an item over its purchase limit, the basket is left unchanged
+type LimitExceededError struct {
+	SKU       sku.SKU
+	Limit     int
+	Requested int
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("sku %s is limited to %d per customer, requested %d", e.SKU, e.Limit, e.Requested)
+}
+
+// checks the new quantity of an item is within its limit
+func (c *checkout) checkLimit(sku sku.SKU, requested int) error {
+	rule, exists := c.itemRules[sku]
+	if !exists || rule.MaxPerCustomer == 0 || requested <= rule.MaxPerCustomer {
+		return nil
+	}
+	return &LimitExceededError{SKU: sku, Limit: rule.MaxPerCustomer, Requested: requested}
+}
+
+// puts the checkout into the approval required state if the item is age restricted and nobody has approved the transaction yet
+func (c *checkout) requireApproval(sku sku.SKU) {
+	if !c.itemRules[sku].AgeRestricted {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.approvedBy != "" || c.approvalPending {
+		return
+	}
+
+	c.approvalPending = true
+	c.metrics().IncCounter(MetricApprovalsRequired)
+	c.log().Info("age restricted item needs approval", slog.String(logKeySKU, sku.String()))
+}
+
+// ApprovalRequired is true when an age restricted item has been scanned and no supervisor has approved it yet
+// the checkout won't scan anything else until Approve is called
+func (c *checkout) ApprovalRequired() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.approvalPending
+}
+
+// Approve records a supervisor approving the age restricted items, the approval lasts for the rest of the transaction
+func (c *checkout) Approve(supervisorID string) error {
+	if supervisorID == "" {
+		return errNoSupervisorGiven
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.approvalPending {
+		return errNoApprovalPending
+	}
+
+	c.approvalPending = false
+	c.approvedBy = supervisorID
+	c.log().Info("age restricted items approved", slog.String(logKeySupervisor, supervisorID))
+
+	return nil
+}
+
+func validateItemRules(rules ItemRules) error {
+	for id, rule := range rules {
+		if rule.MaxPerCustomer < 0 {
+			return fmt.Errorf("%w: sku %s", errNegativeItemLimit, id)
+		}
+	}
+	return nil
+}
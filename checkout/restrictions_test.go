@@ -0,0 +1,129 @@
+package checkout
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func Test_checkout_Scan_limits(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+
+	rules := ItemRules{skuA: {MaxPerCustomer: 3}}
+
+	tests := []struct {
+		name    string
+		scans   []int
+		wantErr error
+		wantQty int
+	}{
+		{name: "scanning up to the limit is fine", scans: []int{1, 2}, wantQty: 3},
+		{name: "going over the limit leaves the basket unchanged", scans: []int{2, 2}, wantErr: &LimitExceededError{SKU: skuA, Limit: 3, Requested: 4}, wantQty: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCheckout(&MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 10, skuB: 10}}, NewBasket(), &MockScanner{}, WithItemRules(rules))
+			if err != nil {
+				t.Fatalf("failed to init checkout: %v", err)
+			}
+
+			var err2 error
+			for _, qty := range tt.scans {
+				err2 = c.Scan(skuA, *quantity.New(qty))
+			}
+
+			if tt.wantErr == nil && err2 != nil {
+				t.Fatalf("checkout.Scan() error = %v", err2)
+			}
+
+			if tt.wantErr != nil {
+				var limitErr *LimitExceededError
+				if !errors.As(err2, &limitErr) || *limitErr != *tt.wantErr.(*LimitExceededError) {
+					t.Fatalf("checkout.Scan() error = %v, want %v", err2, tt.wantErr)
+				}
+			}
+
+			if qty, _ := c.basket.GetItem(skuA); qty.Value() != tt.wantQty {
+				t.Errorf("basket quantity = %d, want %d", qty.Value(), tt.wantQty)
+			}
+
+			// unlimited items are unaffected
+			if err := c.Scan(skuB, *quantity.New(100)); err != nil {
+				t.Errorf("scanning an unlimited item failed: %v", err)
+			}
+		})
+	}
+}
+
+func Test_checkout_Approve(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	wine := skuGenerator(t, 'W')
+
+	scanner, err := NewSkuScanner(strings.NewReader("AWAW"))
+	if err != nil {
+		t.Fatalf("failed to init scanner: %v", err)
+	}
+
+	c, err := NewCheckout(
+		&MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 10, wine: 700}},
+		NewBasket(),
+		scanner,
+		WithItemRules(ItemRules{wine: {AgeRestricted: true}}),
+	)
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	if err := c.Approve("sup-1"); !errors.Is(err, errNoApprovalPending) {
+		t.Errorf("checkout.Approve() error = %v, want %v", err, errNoApprovalPending)
+	}
+
+	// stops straight after the wine so the next item isn't read
+	if err := c.ScanItems(); !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("checkout.ScanItems() error = %v, want %v", err, ErrApprovalRequired)
+	}
+
+	if !c.ApprovalRequired() {
+		t.Fatalf("expected the checkout to need approval")
+	}
+
+	if err := c.Scan(skuA, *quantity.New(1)); !errors.Is(err, ErrApprovalRequired) {
+		t.Errorf("checkout.Scan() error = %v, want %v", err, ErrApprovalRequired)
+	}
+
+	// scanning again before the approval doesn't read and drop what is left on the scanner
+	if err := c.ScanItems(); !errors.Is(err, ErrApprovalRequired) {
+		t.Errorf("checkout.ScanItems() error = %v, want %v", err, ErrApprovalRequired)
+	}
+
+	if err := c.Approve(""); !errors.Is(err, errNoSupervisorGiven) {
+		t.Errorf("checkout.Approve() error = %v, want %v", err, errNoSupervisorGiven)
+	}
+
+	if err := c.Approve("sup-1"); err != nil {
+		t.Fatalf("checkout.Approve() error = %v", err)
+	}
+
+	// the approval covers the rest of the transaction
+	if err := c.ScanItems(); err != nil {
+		t.Fatalf("checkout.ScanItems() error = %v", err)
+	}
+
+	if got := c.GetTotalPrice(); got != 2*10+2*700 {
+		t.Errorf("checkout.GetTotalPrice() = %d, want %d", got, 2*10+2*700)
+	}
+}
+
+func TestNewCheckout_invalidItemRules(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	_, err := NewCheckout(&MockPricingRules{}, NewBasket(), &MockScanner{}, WithItemRules(ItemRules{skuA: {MaxPerCustomer: -1}}))
+	if !errors.Is(err, errNegativeItemLimit) {
+		t.Errorf("NewCheckout() error = %v, want %v", err, errNegativeItemLimit)
+	}
+}
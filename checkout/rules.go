@@ -0,0 +1,38 @@
+package checkout
+
+import (
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// boundRules prices with a fixed context, it satisfies PricingRules so it can go anywhere the rules can
+type boundRules struct {
+	rules PricingRules
+	ctx   pricing.Context
+}
+
+func (r boundRules) GetPrice(sku sku.SKU, qty quantity.Quantity) currency.Pence {
+	return pricing.PriceIn(r.rules, r.ctx, sku, qty)
+}
+
+func (r boundRules) PriceExists(sku sku.SKU) bool {
+	return r.rules.PriceExists(sku)
+}
+
+// how many special offers the quantity qualifies for, ok is false when the rules don't count offers
+func (r boundRules) offersApplied(sku sku.SKU, qty quantity.Quantity) (int, bool) {
+	return pricing.OffersAppliedIn(r.rules, r.ctx, sku, qty)
+}
+
+// rules are the checkout's pricing rules in the transaction's current context: whether a loyalty card has been
+// scanned and a copy of the basket. The pricing rules themselves are never swapped so any wrapper the checkout was
+// built with, e.g. a price experiment, keeps working.
+func (c *checkout) rules() boundRules {
+	ctx := pricing.Context{Member: c.Member() != "", Basket: make(map[sku.SKU]quantity.Quantity)}
+	c.basket.Range(func(id itemID, qty quantity.Quantity) {
+		ctx.Basket[id] = qty
+	})
+	return boundRules{rules: c.pricingRules, ctx: ctx}
+}
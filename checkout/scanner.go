@@ -3,7 +3,7 @@ package checkout
 import (
 	"errors"
 	"io"
-	"log"
+	"log/slog"
 
 	"github.com/Joshswooft/thinkmoney-test/sku"
 )
@@ -14,16 +14,26 @@ var errNilReaderProvided = errors.New("nil reader provided")
 // reads in SKU's from a given reader this could be a buffer, file, string etc.
 type skuScanner struct {
 	reader io.Reader
+	logger *slog.Logger
+	name   string
+	// number of bytes read so far, used to point at bad input in the logs
+	position int
 }
 
-func NewSkuScanner(reader io.Reader) (*skuScanner, error) {
+func NewSkuScanner(reader io.Reader, opts ...ScannerOption) (*skuScanner, error) {
 	if reader == nil {
 		return nil, errNilReaderProvided
 	}
 
-	return &skuScanner{
+	s := &skuScanner{
 		reader: reader,
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
 }
 
 // Implements the io.Reader so it can be combined with other readers
@@ -31,6 +41,7 @@ func NewSkuScanner(reader io.Reader) (*skuScanner, error) {
 func (s *skuScanner) Read(p []byte) (int, error) {
 
 	bytesRead, err := s.reader.Read(p)
+	s.position += bytesRead
 	if err != nil {
 		return bytesRead, err
 	}
@@ -50,6 +61,11 @@ func (s *skuScanner) Read(p []byte) (int, error) {
 	return bytesRead, nil
 }
 
+// Name of the scanner recorded against each scan in the journal
+func (s *skuScanner) Name() string {
+	return s.name
+}
+
 // scans a single sku, for use wrap in an infinite loop and scan until io.EOF or internal err
 func (s *skuScanner) Scan() (sku.SKU, error) {
 	var b [1]byte
@@ -61,7 +77,9 @@ func (s *skuScanner) Scan() (sku.SKU, error) {
 	// Validate and create SKU directly from byte
 	skuInstance, err := sku.New(rune(b[0]))
 	if err != nil {
-		log.Printf("error reading in sku: err: %v, b: '%q'", err, string(b[0]))
+		// Read has already blanked out the invalid byte so the position is the useful bit
+		attrs := append([]any{slog.Int(logKeyPosition, s.position-1)}, errorAttrs(err, errorKindInvalidSKU)...)
+		loggerOrDiscard(s.logger).Debug("skipping invalid sku", attrs...)
 		return sku.SKU{}, err
 	}
 
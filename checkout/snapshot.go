@@ -0,0 +1,211 @@
+package checkout
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// SnapshotVersion is the schema version written into every snapshot
+// bump it whenever the layout changes and teach the decoders to read the old version
+const SnapshotVersion = 1
+
+var (
+	ErrUnsupportedSnapshotVersion = errors.New("unsupported basket snapshot version")
+	ErrCorruptSnapshot            = errors.New("basket snapshot is corrupt")
+)
+
+// magic bytes at the start of the binary format
+var snapshotMagic = []byte("BSK")
+
+// SnapshotItem is a single basket line
+type SnapshotItem struct {
+	SKU      sku.SKU           `json:"sku"`
+	Quantity quantity.Quantity `json:"quantity"`
+}
+
+// Snapshot is a copy of a basket's contents which can be exported to json or a compact binary format
+type Snapshot struct {
+	Version int            `json:"version"`
+	Items   []SnapshotItem `json:"items"`
+}
+
+// TakeSnapshot copies the contents of any basket, items are sorted by sku
+func TakeSnapshot(b Basket) Snapshot {
+	return TakeOrderedSnapshot(b, BySKU)
+}
+
+// TakeOrderedSnapshot copies the contents of any basket with the items in the given order
+func TakeOrderedSnapshot(b Basket, order Order) Snapshot {
+	s := Snapshot{Version: SnapshotVersion, Items: []SnapshotItem{}}
+
+	b.RangeOrdered(order, func(id itemID, qty quantity.Quantity) {
+		s.Items = append(s.Items, SnapshotItem{SKU: id, Quantity: qty})
+	})
+
+	return s
+}
+
+// Snapshot copies the checkout's basket with the items in the checkout's item order
+func (c *checkout) Snapshot() Snapshot {
+	return TakeOrderedSnapshot(c.basket, c.itemOrder)
+}
+
+// Restore puts every item from the snapshot into the basket, existing lines for the same sku are overwritten
+func Restore(b Basket, s Snapshot) error {
+	if s.Version != SnapshotVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedSnapshotVersion, s.Version)
+	}
+
+	for _, item := range s.Items {
+		if err := b.AddItem(item.SKU, item.Quantity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Merge adds every item in src to dst, quantities for the same sku are added together
+// e.g. to add the scan list from a customer's phone to the till's basket
+func Merge(dst, src Basket) error {
+	return mergeSnapshot(dst, TakeSnapshot(src))
+}
+
+func mergeSnapshot(dst Basket, s Snapshot) error {
+	for _, item := range s.Items {
+		merged, err := dst.GetItem(item.SKU)
+		if errors.Is(err, ErrItemNotFound) {
+			merged = *quantity.New(0)
+		} else if err != nil {
+			return err
+		}
+
+		merged.Add(item.Quantity.Value())
+
+		if err := dst.AddItem(item.SKU, merged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Merge scans every item from another basket into the checkout
+// unlike the package level Merge each item goes through the usual scanning rules so unknown items are rejected
+func (c *checkout) Merge(src Basket) error {
+	var errs []error
+	for _, item := range TakeSnapshot(src).Items {
+		if item.Quantity.Value() == 0 {
+			continue
+		}
+		if err := c.doScan(item.SKU, item.Quantity, SourceMerge); err != nil {
+			errs = append(errs, fmt.Errorf("sku %s: %w", item.SKU, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MarshalJSON is only here to make sure the version is always written
+func (s Snapshot) MarshalJSON() ([]byte, error) {
+	type plain Snapshot
+	if s.Version == 0 {
+		s.Version = SnapshotVersion
+	}
+	if s.Items == nil {
+		s.Items = []SnapshotItem{}
+	}
+	return json.Marshal(plain(s))
+}
+
+// UnmarshalJSON rejects snapshots written with a schema version we don't understand
+func (s *Snapshot) UnmarshalJSON(data []byte) error {
+	type plain Snapshot
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	if decoded.Version != SnapshotVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedSnapshotVersion, decoded.Version)
+	}
+
+	*s = Snapshot(decoded)
+	return nil
+}
+
+// MarshalBinary encodes the snapshot as: magic, version byte, item count, then the sku and quantity of each item
+// all numbers are uvarints which keeps a basket of single letter skus to a couple of bytes per line
+func (s Snapshot) MarshalBinary() ([]byte, error) {
+	version := s.Version
+	if version == 0 {
+		version = SnapshotVersion
+	}
+
+	buf := bytes.NewBuffer(append([]byte(nil), snapshotMagic...))
+	buf.WriteByte(byte(version))
+	buf.Write(binary.AppendUvarint(nil, uint64(len(s.Items))))
+
+	for _, item := range s.Items {
+		buf.Write(binary.AppendUvarint(nil, uint64(item.SKU.Value())))
+		buf.Write(binary.AppendUvarint(nil, uint64(item.Quantity.Value())))
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the format written by MarshalBinary
+func (s *Snapshot) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, snapshotMagic) || len(data) < len(snapshotMagic)+1 {
+		return ErrCorruptSnapshot
+	}
+
+	r := bytes.NewReader(data[len(snapshotMagic):])
+
+	version, _ := r.ReadByte()
+	if int(version) != SnapshotVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedSnapshotVersion, version)
+	}
+
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return ErrCorruptSnapshot
+	}
+
+	// every item takes at least two bytes so this stops a bad count allocating loads of memory
+	if count > uint64(r.Len()) {
+		return ErrCorruptSnapshot
+	}
+
+	decoded := Snapshot{Version: int(version), Items: make([]SnapshotItem, 0, count)}
+
+	for i := uint64(0); i < count; i++ {
+		value, err := binary.ReadUvarint(r)
+		if err != nil {
+			return ErrCorruptSnapshot
+		}
+
+		qty, err := binary.ReadUvarint(r)
+		if err != nil {
+			return ErrCorruptSnapshot
+		}
+
+		id, err := sku.New(rune(value))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrCorruptSnapshot, err)
+		}
+
+		decoded.Items = append(decoded.Items, SnapshotItem{SKU: id, Quantity: *quantity.New(int(qty))})
+	}
+
+	if r.Len() != 0 {
+		return ErrCorruptSnapshot
+	}
+
+	*s = decoded
+	return nil
+}
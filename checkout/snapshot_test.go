@@ -0,0 +1,148 @@
+package checkout
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func TestSnapshot_roundTrip(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+
+	original := &basket{items: map[sku.SKU]quantity.Quantity{skuB: *quantity.New(300), skuA: *quantity.New(2)}}
+	snapshot := TakeSnapshot(original)
+
+	want := Snapshot{
+		Version: SnapshotVersion,
+		Items:   []SnapshotItem{{SKU: skuA, Quantity: *quantity.New(2)}, {SKU: skuB, Quantity: *quantity.New(300)}},
+	}
+
+	if !reflect.DeepEqual(snapshot, want) {
+		t.Fatalf("TakeSnapshot() = %v, want %v", snapshot, want)
+	}
+
+	tests := []struct {
+		name   string
+		encode func(Snapshot) ([]byte, error)
+		decode func([]byte, *Snapshot) error
+	}{
+		{
+			name:   "json",
+			encode: func(s Snapshot) ([]byte, error) { return json.Marshal(s) },
+			decode: func(data []byte, s *Snapshot) error { return json.Unmarshal(data, s) },
+		},
+		{
+			name:   "binary",
+			encode: func(s Snapshot) ([]byte, error) { return s.MarshalBinary() },
+			decode: func(data []byte, s *Snapshot) error { return s.UnmarshalBinary(data) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.encode(snapshot)
+			if err != nil {
+				t.Fatalf("encode error = %v", err)
+			}
+
+			var decoded Snapshot
+			if err := tt.decode(data, &decoded); err != nil {
+				t.Fatalf("decode error = %v", err)
+			}
+
+			restored := NewBasket()
+			if err := Restore(restored, decoded); err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(restored.items, original.items) {
+				t.Errorf("restored basket = %v, want %v", restored.items, original.items)
+			}
+		})
+	}
+}
+
+func TestSnapshot_decodeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		decode  func(*Snapshot) error
+		wantErr error
+	}{
+		{
+			name:    "json with a future version",
+			decode:  func(s *Snapshot) error { return json.Unmarshal([]byte(`{"version":2,"items":[]}`), s) },
+			wantErr: ErrUnsupportedSnapshotVersion,
+		},
+		{
+			name:    "binary with a future version",
+			decode:  func(s *Snapshot) error { return s.UnmarshalBinary([]byte("BSK\x02\x00")) },
+			wantErr: ErrUnsupportedSnapshotVersion,
+		},
+		{
+			name:    "binary without the magic bytes",
+			decode:  func(s *Snapshot) error { return s.UnmarshalBinary([]byte("{}")) },
+			wantErr: ErrCorruptSnapshot,
+		},
+		{
+			name:    "binary which is cut short",
+			decode:  func(s *Snapshot) error { return s.UnmarshalBinary([]byte("BSK\x01\x02\x41\x01")) },
+			wantErr: ErrCorruptSnapshot,
+		},
+		{
+			name:    "binary with an invalid sku",
+			decode:  func(s *Snapshot) error { return s.UnmarshalBinary([]byte("BSK\x01\x01\x24\x01")) },
+			wantErr: ErrCorruptSnapshot,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Snapshot
+			if err := tt.decode(&s); !errors.Is(err, tt.wantErr) {
+				t.Errorf("decode error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+	skuC := skuGenerator(t, 'C')
+
+	till := &basket{items: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(1), skuB: *quantity.New(2)}}
+	phone := &basket{items: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(2), skuC: *quantity.New(1)}}
+
+	if err := Merge(till, phone); err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+
+	want := map[sku.SKU]quantity.Quantity{skuA: *quantity.New(3), skuB: *quantity.New(2), skuC: *quantity.New(1)}
+	if !reflect.DeepEqual(till.items, want) {
+		t.Errorf("merged basket = %v, want %v", till.items, want)
+	}
+}
+
+func Test_checkout_Merge(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuZ := skuGenerator(t, 'Z')
+
+	c, err := NewCheckout(&MockPricingRules{Prices: map[sku.SKU]currency.Pence{skuA: 10}}, NewBasket(), &MockScanner{})
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	phone := &basket{items: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(2), skuZ: *quantity.New(1)}}
+
+	if err := c.Merge(phone); !errors.Is(err, errUnknownItemScanned) {
+		t.Errorf("checkout.Merge() error = %v, want %v", err, errUnknownItemScanned)
+	}
+
+	if got := c.GetTotalPrice(); got != 20 {
+		t.Errorf("checkout.GetTotalPrice() = %d, want %d", got, 20)
+	}
+}
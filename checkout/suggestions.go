@@ -0,0 +1,60 @@
+package checkout
+
+import (
+	"sort"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// how many more of an item we look at when searching for an offer the customer has nearly reached
+const suggestionLookahead = 10
+
+// Suggestion is an upsell e.g. "add 1 more A to save 20p"
+type Suggestion struct {
+	SKU sku.SKU
+	// how many more to add
+	Add int
+	// how much more the customer saves off the list price by adding them
+	Saving currency.Pence
+}
+
+// saving off the list price for a quantity of an item
+func saving(rules PricingRules, sku sku.SKU, qty int) currency.Pence {
+	unitPrice := rules.GetPrice(sku, *quantity.New(1))
+	return unitPrice*currency.Pence(qty) - rules.GetPrice(sku, *quantity.New(qty))
+}
+
+// Suggestions looks ahead at adding more of each item in the basket and suggests the fewest extra items which unlock
+// a bigger saving, it only relies on the pricing rules so works for any kind of offer.
+// Suggestions are ranked by saving, the biggest first, and never take an item over its purchase limit.
+func (c *checkout) Suggestions() []Suggestion {
+	var suggestions []Suggestion
+	rules := c.rules()
+
+	c.basket.RangeOrdered(BySKU, func(id itemID, qty quantity.Quantity) {
+		current := saving(rules, id, qty.Value())
+
+		for add := 1; add <= suggestionLookahead; add++ {
+			if c.checkLimit(id, qty.Value()+add) != nil {
+				return
+			}
+
+			if saving := saving(rules, id, qty.Value()+add) - current; saving > 0 {
+				suggestions = append(suggestions, Suggestion{SKU: id, Add: add, Saving: saving})
+				return
+			}
+		}
+	})
+
+	// stable so equal savings stay in sku order
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Saving != suggestions[j].Saving {
+			return suggestions[i].Saving > suggestions[j].Saving
+		}
+		return suggestions[i].Add < suggestions[j].Add
+	})
+
+	return suggestions
+}
@@ -0,0 +1,73 @@
+package checkout
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func Test_checkout_Suggestions(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+	skuC := skuGenerator(t, 'C')
+
+	pricingRules := &pricing.SpecialPricing{Config: map[sku.SKU]pricing.PricingData{
+		skuA: {UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3)},
+		skuB: {UnitPrice: 30, SpecialPrice: 45, SpecialQuantity: *quantity.New(2)},
+		skuC: {UnitPrice: 20},
+	}}
+
+	tests := []struct {
+		name  string
+		scans map[sku.SKU]int
+		rules ItemRules
+		want  []Suggestion
+	}{
+		{
+			name:  "one away from an offer",
+			scans: map[sku.SKU]int{skuA: 2},
+			want:  []Suggestion{{SKU: skuA, Add: 1, Saving: 20}},
+		},
+		{
+			name:  "ranked by saving",
+			scans: map[sku.SKU]int{skuA: 1, skuB: 1, skuC: 4},
+			want:  []Suggestion{{SKU: skuA, Add: 2, Saving: 20}, {SKU: skuB, Add: 1, Saving: 15}},
+		},
+		{
+			name:  "looks ahead to the next offer once one has been reached",
+			scans: map[sku.SKU]int{skuA: 3},
+			want:  []Suggestion{{SKU: skuA, Add: 3, Saving: 20}},
+		},
+		{
+			name:  "never suggests going over a purchase limit",
+			scans: map[sku.SKU]int{skuA: 2, skuB: 1},
+			rules: ItemRules{skuA: {MaxPerCustomer: 2}},
+			want:  []Suggestion{{SKU: skuB, Add: 1, Saving: 15}},
+		},
+		{
+			name:  "nothing to suggest",
+			scans: map[sku.SKU]int{skuC: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCheckout(pricingRules, NewBasket(), &MockScanner{}, WithItemRules(tt.rules))
+			if err != nil {
+				t.Fatalf("failed to init checkout: %v", err)
+			}
+
+			for s, qty := range tt.scans {
+				if err := c.Scan(s, *quantity.New(qty)); err != nil {
+					t.Fatalf("checkout.Scan() error = %v", err)
+				}
+			}
+
+			if got := c.Suggestions(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkout.Suggestions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
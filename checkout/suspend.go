@@ -0,0 +1,341 @@
+package checkout
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+)
+
+var (
+	ErrCheckoutSuspended  = errors.New("the checkout has been suspended")
+	ErrSuspendedNotFound  = errors.New("no suspended transaction for that code")
+	ErrSuspendedExpired   = errors.New("the suspended transaction has expired")
+	ErrPricingChanged     = errors.New("the pricing rules don't match the ones the transaction was suspended with")
+	errNoSuspendStore     = errors.New("no suspend store was provided")
+	errInvalidSuspendCode = errors.New("invalid suspend code")
+)
+
+// SuspendedTransaction is everything needed to carry on a checkout at another till.
+// The subtotal is kept so the resuming till can prove it prices the basket identically.
+type SuspendedTransaction struct {
+	Code     string         `json:"code"`
+	Basket   Snapshot       `json:"basket"`
+	Journal  []JournalEntry `json:"journal"`
+	Subtotal currency.Pence `json:"subtotal"`
+	// age restricted items stay approved (or waiting for approval) after resuming
+	ApprovalPending bool   `json:"approval_pending,omitempty"`
+	ApprovedBy      string `json:"approved_by,omitempty"`
+	// the loyalty card scanned before suspending, resumed checkouts need WithLoyalty to accept it
+	Member      string    `json:"member,omitempty"`
+	SuspendedAt time.Time `json:"suspended_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// reads transactions suspended before the basket was a versioned snapshot,
+// they kept the basket lines in an items field
+func (tx *SuspendedTransaction) UnmarshalJSON(data []byte) error {
+	type suspendedTransaction SuspendedTransaction
+	var aux struct {
+		suspendedTransaction
+		Basket *Snapshot      `json:"basket"`
+		Items  []SnapshotItem `json:"items"`
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*tx = SuspendedTransaction(aux.suspendedTransaction)
+
+	switch {
+	case aux.Basket != nil:
+		tx.Basket = *aux.Basket
+	case aux.Items != nil:
+		tx.Basket = Snapshot{Version: SnapshotVersion, Items: aux.Items}
+	}
+
+	return nil
+}
+
+// SuspendStore keeps suspended transactions until they are resumed
+type SuspendStore interface {
+	// saves the transaction under its code, the store sets the expiry
+	Save(tx SuspendedTransaction) (SuspendedTransaction, error)
+	// removes and returns the transaction so it can only be resumed once
+	// returns ErrSuspendedNotFound or ErrSuspendedExpired when it can't be resumed
+	Take(code string) (SuspendedTransaction, error)
+}
+
+// Suspend parks the transaction in the store so the queue can keep moving
+// the returned code is given to the customer to resume at any till, once suspended this checkout can't scan anymore
+func (c *checkout) Suspend(store SuspendStore) (code string, err error) {
+	if store == nil {
+		return "", errNoSuspendStore
+	}
+
+	if err := c.checkOpen(); err != nil {
+		return "", err
+	}
+
+	code, err = newSuspendCode()
+	if err != nil {
+		return "", err
+	}
+
+	tx := SuspendedTransaction{Code: code, Basket: TakeOrderedSnapshot(c.basket, ByScanOrder), Subtotal: c.total()}
+
+	tx.Journal = c.Journal().Entries()
+
+	c.mu.Lock()
+	tx.ApprovalPending, tx.ApprovedBy = c.approvalPending, c.approvedBy
+	tx.Member = c.member
+	c.mu.Unlock()
+
+	if _, err := store.Save(tx); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.suspended = true
+	c.mu.Unlock()
+
+	// the stock isn't held while the transaction is suspended, it is reserved again when it is resumed
+	if err := c.releaseStock(); err != nil {
+		c.log().Warn("failed to release stock for suspended checkout", errorAttrs(err, errorKindBasket)...)
+	}
+
+	return code, nil
+}
+
+// Resume carries on a suspended transaction in a new checkout
+// the basket is filled with the suspended items and the pricing rules must price them exactly as they were when suspended,
+// if they don't ErrPricingChanged is returned and the transaction is left in the store
+func Resume(store SuspendStore, code string, pricingRules PricingRules, basket Basket, scanner Scanner, opts ...Option) (*checkout, error) {
+	if store == nil {
+		return nil, errNoSuspendStore
+	}
+
+	if basket == nil {
+		return nil, errNoBasketProvided
+	}
+
+	// everything is restored and checked against a scratch basket first
+	// so a failed resume doesn't leave the till's basket half filled
+	scratch := NewBasket()
+
+	c, err := NewCheckout(pricingRules, scratch, scanner, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := store.Take(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Restore(scratch, tx.Basket); err != nil {
+		return nil, errors.Join(err, putBack(store, tx))
+	}
+
+	if tx.Member != "" {
+		if err := c.ScanCard(tx.Member); err != nil {
+			return nil, errors.Join(err, putBack(store, tx))
+		}
+	}
+
+	if subtotal := c.total(); subtotal != tx.Subtotal {
+		err := fmt.Errorf("%w: suspended at %d, resumed at %d", ErrPricingChanged, tx.Subtotal, subtotal)
+		return nil, errors.Join(err, putBack(store, tx))
+	}
+
+	if err := c.Journal().load(tx.Journal); err != nil {
+		return nil, errors.Join(err, putBack(store, tx))
+	}
+
+	if err := c.reserveBasket(); err != nil {
+		return nil, errors.Join(err, c.releaseStock(), putBack(store, tx))
+	}
+
+	// the transaction checks out so it is committed to the till's basket
+	if err := Restore(basket, TakeOrderedSnapshot(scratch, ByScanOrder)); err != nil {
+		return nil, errors.Join(err, c.releaseStock(), putBack(store, tx))
+	}
+	c.basket = basket
+
+	c.approvalPending, c.approvedBy = tx.ApprovalPending, tx.ApprovedBy
+
+	return c, nil
+}
+
+// puts a transaction back into the store after a failed resume
+func putBack(store SuspendStore, tx SuspendedTransaction) error {
+	_, err := store.Save(tx)
+	return err
+}
+
+// codes avoid characters which are easily confused when read out e.g. 0 and O
+const suspendCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+const suspendCodeLength = 8
+
+func newSuspendCode() (string, error) {
+	b := make([]byte, suspendCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	for i := range b {
+		b[i] = suspendCodeAlphabet[int(b[i])%len(suspendCodeAlphabet)]
+	}
+
+	return string(b), nil
+}
+
+func validSuspendCode(code string) bool {
+	if len(code) != suspendCodeLength {
+		return false
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(suspendCodeAlphabet, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// FileSuspendStore keeps each suspended transaction as a json file in a local directory
+// so any till sharing the directory can resume it, operation is go-routine safe
+type FileSuspendStore struct {
+	mu  sync.Mutex
+	dir string
+	ttl time.Duration
+	now func() time.Time
+	// removes a transaction's file, only replaced in tests
+	remove func(path string) error
+}
+
+// creates a store in the given directory, suspended transactions expire after the ttl
+func NewFileSuspendStore(dir string, ttl time.Duration) (*FileSuspendStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileSuspendStore{dir: dir, ttl: ttl, now: time.Now, remove: os.Remove}, nil
+}
+
+func (s *FileSuspendStore) path(code string) string {
+	return filepath.Join(s.dir, code+".json")
+}
+
+func (s *FileSuspendStore) Save(tx SuspendedTransaction) (SuspendedTransaction, error) {
+	if !validSuspendCode(tx.Code) {
+		return tx, errInvalidSuspendCode
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tx.SuspendedAt.IsZero() {
+		tx.SuspendedAt = s.now()
+	}
+	tx.ExpiresAt = tx.SuspendedAt.Add(s.ttl)
+
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return tx, err
+	}
+
+	// write then rename so another till never reads half a file
+	tmp := s.path(tx.Code) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return tx, err
+	}
+
+	return tx, os.Rename(tmp, s.path(tx.Code))
+}
+
+func (s *FileSuspendStore) Take(code string) (SuspendedTransaction, error) {
+	if !validSuspendCode(code) {
+		return SuspendedTransaction{}, ErrSuspendedNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.read(s.path(code))
+	if errors.Is(err, fs.ErrNotExist) {
+		return SuspendedTransaction{}, ErrSuspendedNotFound
+	}
+	if err != nil {
+		return SuspendedTransaction{}, err
+	}
+
+	// the mutex only covers this process, other tills sharing the directory may be taking the same transaction.
+	// Removing the file is what claims it, so only one of them can succeed and the rest find it gone.
+	if err := s.remove(s.path(code)); errors.Is(err, fs.ErrNotExist) {
+		return SuspendedTransaction{}, ErrSuspendedNotFound
+	} else if err != nil {
+		return SuspendedTransaction{}, err
+	}
+
+	if !s.now().Before(tx.ExpiresAt) {
+		return SuspendedTransaction{}, ErrSuspendedExpired
+	}
+
+	return tx, nil
+}
+
+// Purge deletes every expired transaction and returns how many were removed
+func (s *FileSuspendStore) Purge() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, path := range paths {
+		// another till may have resumed or purged it since the directory was listed
+		tx, err := s.read(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return purged, err
+		}
+
+		if s.now().Before(tx.ExpiresAt) {
+			continue
+		}
+
+		if err := os.Remove(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (s *FileSuspendStore) read(path string) (SuspendedTransaction, error) {
+	var tx SuspendedTransaction
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return tx, err
+	}
+
+	return tx, json.Unmarshal(data, &tx)
+}
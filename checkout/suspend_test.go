@@ -0,0 +1,263 @@
+package checkout
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func TestSuspendAndResume(t *testing.T) {
+	skuGen := func(r rune) sku.SKU {
+		return skuGenerator(t, r)
+	}
+
+	pricingRules := &pricing.SpecialPricing{
+		Config: map[sku.SKU]pricing.PricingData{
+			skuGen('A'): {UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3)},
+			skuGen('B'): {UnitPrice: 30},
+		},
+	}
+
+	store, err := NewFileSuspendStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	till1, err := NewCheckout(pricingRules, NewBasket(), &MockScanner{})
+	if err != nil {
+		t.Fatalf("failed to init checkout: %v", err)
+	}
+
+	for _, r := range "ABAA" {
+		if err := till1.Scan(skuGen(r), *quantity.New(1)); err != nil {
+			t.Fatalf("checkout.Scan() error = %v", err)
+		}
+	}
+
+	code, err := till1.Suspend(store)
+	if err != nil {
+		t.Fatalf("checkout.Suspend() error = %v", err)
+	}
+
+	if err := till1.Scan(skuGen('B'), *quantity.New(1)); !errors.Is(err, ErrCheckoutSuspended) {
+		t.Errorf("expected scanning a suspended checkout to fail, got %v", err)
+	}
+
+	// different prices at the other till are refused and the transaction is kept
+	cheaper := &pricing.SimplePricing{UnitPrices: map[sku.SKU]currency.Pence{skuGen('A'): 1, skuGen('B'): 1}}
+	refused := NewBasket()
+	if _, err := Resume(store, code, cheaper, refused, &MockScanner{}); !errors.Is(err, ErrPricingChanged) {
+		t.Fatalf("Resume() error = %v, want %v", err, ErrPricingChanged)
+	}
+
+	// and the till's basket is left as it was
+	if items := TakeSnapshot(refused).Items; len(items) != 0 {
+		t.Errorf("basket after a refused resume = %v, want it empty", items)
+	}
+
+	till2, err := Resume(store, code, pricingRules, NewBasket(), &MockScanner{})
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	if got, want := till2.GetTotalPrice(), till1.GetTotalPrice(); got != want {
+		t.Errorf("resumed total = %d, want %d", got, want)
+	}
+
+	if got, want := till2.Journal().Entries(), till1.Journal().Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("resumed journal = %v, want %v", got, want)
+	}
+
+	if got := till2.Journal().Len(); got != 4 {
+		t.Errorf("resumed journal has %d entries, want %d", got, 4)
+	}
+
+	// a transaction can only be resumed once
+	if _, err := Resume(store, code, pricingRules, NewBasket(), &MockScanner{}); !errors.Is(err, ErrSuspendedNotFound) {
+		t.Errorf("Resume() error = %v, want %v", err, ErrSuspendedNotFound)
+	}
+}
+
+func TestFileSuspendStore_legacyItems(t *testing.T) {
+	skuGen := func(r rune) sku.SKU {
+		return skuGenerator(t, r)
+	}
+
+	dir := t.TempDir()
+	store, err := NewFileSuspendStore(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	// transactions suspended before the basket snapshot kept the lines in items
+	const code = "ABCDEFGH"
+	legacy := `{"code":"ABCDEFGH","items":[{"sku":"A","quantity":3},{"sku":"B","quantity":1}],"subtotal":160,` +
+		`"suspended_at":"2024-01-01T12:00:00Z","expires_at":"2999-01-01T12:00:00Z"}`
+	if err := os.WriteFile(filepath.Join(dir, code+".json"), []byte(legacy), 0o644); err != nil {
+		t.Fatalf("failed to write legacy transaction: %v", err)
+	}
+
+	pricingRules := &pricing.SpecialPricing{
+		Config: map[sku.SKU]pricing.PricingData{
+			skuGen('A'): {UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3)},
+			skuGen('B'): {UnitPrice: 30},
+		},
+	}
+
+	till, err := Resume(store, code, pricingRules, NewBasket(), &MockScanner{})
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+
+	want := []SnapshotItem{{SKU: skuGen('A'), Quantity: *quantity.New(3)}, {SKU: skuGen('B'), Quantity: *quantity.New(1)}}
+	if got := till.Snapshot().Items; !reflect.DeepEqual(got, want) {
+		t.Errorf("resumed basket = %v, want %v", got, want)
+	}
+}
+
+func TestFileSuspendStore_Take_sharedDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	// each till has its own store on the shared directory so they don't share a mutex
+	tills := make([]*FileSuspendStore, 8)
+	for i := range tills {
+		store, err := NewFileSuspendStore(dir, time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+		tills[i] = store
+	}
+
+	for round := 0; round < 20; round++ {
+		code, err := newSuspendCode()
+		if err != nil {
+			t.Fatalf("failed to create code: %v", err)
+		}
+		if _, err := tills[0].Save(SuspendedTransaction{Code: code}); err != nil {
+			t.Fatalf("FileSuspendStore.Save() error = %v", err)
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, len(tills))
+		for _, store := range tills {
+			wg.Add(1)
+			go func(store *FileSuspendStore) {
+				defer wg.Done()
+				_, err := store.Take(code)
+				errs <- err
+			}(store)
+		}
+		wg.Wait()
+		close(errs)
+
+		taken := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				taken++
+			case !errors.Is(err, ErrSuspendedNotFound):
+				t.Errorf("FileSuspendStore.Take() error = %v, want %v", err, ErrSuspendedNotFound)
+			}
+		}
+		if taken != 1 {
+			t.Fatalf("transaction was taken %d times, want 1", taken)
+		}
+	}
+
+	// another till takes the transaction after this one has read it but before it removes it
+	code, err := newSuspendCode()
+	if err != nil {
+		t.Fatalf("failed to create code: %v", err)
+	}
+	if _, err := tills[0].Save(SuspendedTransaction{Code: code}); err != nil {
+		t.Fatalf("FileSuspendStore.Save() error = %v", err)
+	}
+
+	tills[1].remove = func(path string) error {
+		if _, err := tills[0].Take(code); err != nil {
+			t.Fatalf("FileSuspendStore.Take() error = %v", err)
+		}
+		return os.Remove(path)
+	}
+	if _, err := tills[1].Take(code); !errors.Is(err, ErrSuspendedNotFound) {
+		t.Errorf("FileSuspendStore.Take() error = %v, want %v", err, ErrSuspendedNotFound)
+	}
+}
+
+func TestFileSuspendStore_expiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := NewFileSuspendStore(t.TempDir(), 30*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	store.now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		after   time.Duration
+		wantErr error
+	}{
+		{name: "can be taken before the ttl", after: 29 * time.Minute, wantErr: nil},
+		{name: "expires after the ttl", after: 30 * time.Minute, wantErr: ErrSuspendedExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := newSuspendCode()
+			if err != nil {
+				t.Fatalf("failed to create code: %v", err)
+			}
+
+			saved, err := store.Save(SuspendedTransaction{Code: code, SuspendedAt: now})
+			if err != nil {
+				t.Fatalf("FileSuspendStore.Save() error = %v", err)
+			}
+
+			if want := now.Add(30 * time.Minute); !saved.ExpiresAt.Equal(want) {
+				t.Errorf("expires at = %v, want %v", saved.ExpiresAt, want)
+			}
+
+			store.now = func() time.Time { return now.Add(tt.after) }
+			defer func() { store.now = func() time.Time { return now } }()
+
+			if _, err := store.Take(code); !errors.Is(err, tt.wantErr) {
+				t.Errorf("FileSuspendStore.Take() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileSuspendStore_Purge(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := NewFileSuspendStore(t.TempDir(), time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	store.now = func() time.Time { return now }
+
+	for _, suspendedAt := range []time.Time{now.Add(-2 * time.Minute), now.Add(-time.Hour), now} {
+		code, _ := newSuspendCode()
+		if _, err := store.Save(SuspendedTransaction{Code: code, SuspendedAt: suspendedAt}); err != nil {
+			t.Fatalf("FileSuspendStore.Save() error = %v", err)
+		}
+	}
+
+	purged, err := store.Purge()
+	if err != nil {
+		t.Fatalf("FileSuspendStore.Purge() error = %v", err)
+	}
+
+	if purged != 2 {
+		t.Errorf("FileSuspendStore.Purge() = %d, want %d", purged, 2)
+	}
+}
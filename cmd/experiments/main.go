@@ -0,0 +1,46 @@
+// Command experiments summarises price experiments from the experiment journal.
+//
+// For every variant it prints how many sessions it had, how many completed, the conversion rate and the takings:
+//
+//	go run ./cmd/experiments -journal experiments.jsonl
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/Joshswooft/thinkmoney-test/experiment"
+)
+
+func main() {
+	journalPath := flag.String("journal", "", "experiment journal written by the checkout")
+	jsonOutput := flag.Bool("json", false, "print the summaries as json")
+	flag.Parse()
+
+	if *journalPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	records, err := experiment.ReadRecordsFile(*journalPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	summaries := experiment.Summarise(records)
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(summaries); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := experiment.Format(os.Stdout, summaries); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,80 @@
+// Command pricediff previews a price change before it is published.
+//
+// It lists the skus and offers added, removed and changed between two json price lists and replays historical
+// baskets through both to show how the totals shift:
+//
+//	go run ./cmd/pricediff -old prices.json -new prices-next.json -baskets baskets.jsonl journal1.jsonl journal2.jsonl
+//
+// Baskets come from scan journal exports (one basket per file, given as arguments) and/or a json lines file of
+// basket snapshots (-baskets).
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+)
+
+func main() {
+	oldPath := flag.String("old", "", "json price list currently in use")
+	newPath := flag.String("new", "", "json price list about to be published")
+	basketsPath := flag.String("baskets", "", "json lines file of basket snapshots to replay")
+	jsonOutput := flag.Bool("json", false, "print the report as json")
+	flag.Parse()
+
+	if *oldPath == "" || *newPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	old, err := pricing.LoadFile(*oldPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	next, err := pricing.LoadFile(*newPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the loader refuses errors, warnings are worth knowing about before publishing
+	for _, problem := range pricing.Validate(next) {
+		log.Printf("%s: %s", *newPath, problem)
+	}
+
+	var baskets []Basket
+
+	if *basketsPath != "" {
+		loaded, err := loadBaskets(*basketsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		baskets = append(baskets, loaded...)
+	}
+
+	for _, path := range flag.Args() {
+		basket, err := loadJournal(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		baskets = append(baskets, basket)
+	}
+
+	report := Compare(old, next, baskets)
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := report.Format(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
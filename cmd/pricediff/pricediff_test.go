@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/checkout"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func TestCompare(t *testing.T) {
+	old, err := pricing.LoadFile("../../pricing/testdata/prices.json")
+	if err != nil {
+		t.Fatalf("failed to load old prices: %v", err)
+	}
+
+	next, err := pricing.LoadFile("testdata/new.json")
+	if err != nil {
+		t.Fatalf("failed to load new prices: %v", err)
+	}
+
+	baskets, err := loadBaskets("testdata/baskets.jsonl")
+	if err != nil {
+		t.Fatalf("loadBaskets() error = %v", err)
+	}
+
+	// 2 A's and a D are left after the journal
+	journal, err := loadJournal("testdata/journal.jsonl")
+	if err != nil {
+		t.Fatalf("loadJournal() error = %v", err)
+	}
+	baskets = append(baskets, journal)
+
+	report := Compare(old, next, baskets)
+
+	wantShifts := []Shift{
+		{Basket: "testdata/baskets.jsonl:2", Old: 45, New: 60, Delta: 15},
+		{Basket: "testdata/baskets.jsonl:1", Old: 90, New: 95, Delta: 5},
+		{Basket: "testdata/journal.jsonl", Old: 115, New: 110, Delta: -5, Unpriced: []string{"D"}},
+		{Basket: "testdata/baskets.jsonl:3", Old: 20, New: 20, Delta: 0},
+	}
+
+	if !reflect.DeepEqual(report.Shifts, wantShifts) {
+		t.Errorf("Compare() shifts = %+v, want %+v", report.Shifts, wantShifts)
+	}
+
+	wantSummary := Summary{Baskets: 4, Increased: 2, Decreased: 1, Unchanged: 1, OldTotal: 270, NewTotal: 285, MaxIncrease: 15, MaxDecrease: 5}
+	if report.Summary != wantSummary {
+		t.Errorf("Compare() summary = %+v, want %+v", report.Summary, wantSummary)
+	}
+
+	if len(report.Changes) != 4 {
+		t.Errorf("Compare() changes = %v, want 4", report.ChangeList)
+	}
+
+	var out bytes.Buffer
+	if err := report.Format(&out); err != nil {
+		t.Fatalf("Report.Format() error = %v", err)
+	}
+
+	for _, want := range []string{"4 sku changes", "total 270 -> 285 (+15)", "no longer priced: [D]"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Report.Format() missing %q in:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestCompare_unpriced(t *testing.T) {
+	old, err := pricing.LoadFile("../../pricing/testdata/prices.json")
+	if err != nil {
+		t.Fatalf("failed to load old prices: %v", err)
+	}
+
+	next, err := pricing.LoadFile("testdata/new.json")
+	if err != nil {
+		t.Fatalf("failed to load new prices: %v", err)
+	}
+
+	item := func(r rune) checkout.Item {
+		s, err := sku.New(r)
+		if err != nil {
+			t.Fatalf("failed to make sku: %v", err)
+		}
+		return checkout.Item{SKU: s, Quantity: *quantity.New(1)}
+	}
+
+	// D is only in the old list, Z isn't in either so it was never priced and can't have been dropped
+	report := Compare(old, next, []Basket{{ID: "basket", Items: []checkout.Item{item('D'), item('Z')}}})
+
+	if got := report.Shifts[0].Unpriced; !reflect.DeepEqual(got, []string{"D"}) {
+		t.Errorf("Compare() unpriced = %v, want %v", got, []string{"D"})
+	}
+}
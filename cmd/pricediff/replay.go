@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/Joshswooft/thinkmoney-test/checkout"
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+)
+
+// Basket is a historical basket replayed through both price lists
+type Basket struct {
+	ID    string
+	Items []checkout.Item
+}
+
+// loads a scan journal export as a single basket named after the file
+func loadJournal(path string) (Basket, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Basket{}, err
+	}
+	defer f.Close()
+
+	journal, err := checkout.ReadJournal(f)
+	if err != nil {
+		return Basket{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	basket := checkout.NewBasket()
+	if err := journal.Replay(basket); err != nil {
+		return Basket{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return Basket{ID: path, Items: checkout.Items(basket, checkout.BySKU)}, nil
+}
+
+// loads a json lines file of basket snapshots, each basket is named after its line
+func loadBaskets(path string) ([]Basket, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readBaskets(f, path)
+}
+
+func readBaskets(r io.Reader, name string) ([]Basket, error) {
+	var baskets []Basket
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var snapshot checkout.Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snapshot); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+
+		basket := Basket{ID: fmt.Sprintf("%s:%d", name, line)}
+		for _, item := range snapshot.Items {
+			basket.Items = append(basket.Items, checkout.Item{SKU: item.SKU, Quantity: item.Quantity})
+		}
+		baskets = append(baskets, basket)
+	}
+
+	return baskets, scanner.Err()
+}
+
+// Shift is how a basket's total moves from the old to the new prices
+type Shift struct {
+	Basket string         `json:"basket"`
+	Old    currency.Pence `json:"old"`
+	New    currency.Pence `json:"new"`
+	Delta  currency.Pence `json:"delta"`
+	// items with a price in the old list but not in the new one, they are left out of the new total.
+	// Items priced in neither list are left out of both totals and aren't listed.
+	Unpriced []string `json:"unpriced,omitempty"`
+}
+
+// Summary of every basket replayed
+type Summary struct {
+	Baskets   int            `json:"baskets"`
+	Increased int            `json:"increased"`
+	Decreased int            `json:"decreased"`
+	Unchanged int            `json:"unchanged"`
+	OldTotal  currency.Pence `json:"old_total"`
+	NewTotal  currency.Pence `json:"new_total"`
+	// the biggest single increase and decrease
+	MaxIncrease currency.Pence `json:"max_increase"`
+	MaxDecrease currency.Pence `json:"max_decrease"`
+}
+
+// Report is the full comparison of two price lists
+type Report struct {
+	Changes []pricing.Change `json:"-"`
+	// the changes written as text so the json output is readable
+	ChangeList []string `json:"changes"`
+	Shifts     []Shift  `json:"shifts"`
+	Summary    Summary  `json:"summary"`
+}
+
+// items without a price are left out of the total
+func total(rules *pricing.SpecialPricing, items []checkout.Item) (total currency.Pence) {
+	for _, item := range items {
+		if rules.PriceExists(item.SKU) {
+			total += rules.GetPrice(item.SKU, item.Quantity)
+		}
+	}
+	return total
+}
+
+// the items priced in the old list which the next one no longer prices
+func dropped(old, next *pricing.SpecialPricing, items []checkout.Item) (unpriced []string) {
+	for _, item := range items {
+		if old.PriceExists(item.SKU) && !next.PriceExists(item.SKU) {
+			unpriced = append(unpriced, item.SKU.String())
+		}
+	}
+	return unpriced
+}
+
+// Compare diffs the price lists and replays every basket through both of them
+func Compare(old, next *pricing.SpecialPricing, baskets []Basket) Report {
+	report := Report{Changes: pricing.Diff(old, next), ChangeList: []string{}, Shifts: []Shift{}}
+
+	for _, change := range report.Changes {
+		report.ChangeList = append(report.ChangeList, change.String())
+	}
+
+	for _, basket := range baskets {
+		oldTotal := total(old, basket.Items)
+		newTotal := total(next, basket.Items)
+
+		shift := Shift{Basket: basket.ID, Old: oldTotal, New: newTotal, Delta: newTotal - oldTotal, Unpriced: dropped(old, next, basket.Items)}
+		report.Shifts = append(report.Shifts, shift)
+
+		summary := &report.Summary
+		summary.Baskets++
+		summary.OldTotal += oldTotal
+		summary.NewTotal += newTotal
+
+		switch {
+		case shift.Delta > 0:
+			summary.Increased++
+			summary.MaxIncrease = max(summary.MaxIncrease, shift.Delta)
+		case shift.Delta < 0:
+			summary.Decreased++
+			summary.MaxDecrease = max(summary.MaxDecrease, -shift.Delta)
+		default:
+			summary.Unchanged++
+		}
+	}
+
+	// biggest movers first
+	sort.SliceStable(report.Shifts, func(i, j int) bool {
+		return abs(report.Shifts[i].Delta) > abs(report.Shifts[j].Delta)
+	})
+
+	return report
+}
+
+func abs(p currency.Pence) currency.Pence {
+	if p < 0 {
+		return -p
+	}
+	return p
+}
+
+// writes the report as plain text
+func (r Report) Format(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "%d sku changes\n", len(r.Changes))
+	for _, change := range r.ChangeList {
+		fmt.Fprintf(bw, "  %s\n", change)
+	}
+
+	if r.Summary.Baskets > 0 {
+		s := r.Summary
+		fmt.Fprintf(bw, "\n%d baskets replayed: %d increased, %d decreased, %d unchanged\n", s.Baskets, s.Increased, s.Decreased, s.Unchanged)
+		fmt.Fprintf(bw, "total %d -> %d (%+d)\n", s.OldTotal, s.NewTotal, s.NewTotal-s.OldTotal)
+		fmt.Fprintf(bw, "biggest increase %d, biggest decrease %d\n", s.MaxIncrease, s.MaxDecrease)
+
+		for _, shift := range r.Shifts {
+			if shift.Delta == 0 && len(shift.Unpriced) == 0 {
+				continue
+			}
+			fmt.Fprintf(bw, "  %s: %d -> %d (%+d)", shift.Basket, shift.Old, shift.New, shift.Delta)
+			if len(shift.Unpriced) > 0 {
+				fmt.Fprintf(bw, " no longer priced: %v", shift.Unpriced)
+			}
+			fmt.Fprintln(bw)
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,117 @@
+// Command pricehistory publishes price lists to the price history and looks up the prices in force at any time.
+//
+// Publish a json price list, straight away or from a later time:
+//
+//	go run ./cmd/pricehistory -history price-history.jsonl -publish prices.json -from 2026-11-01
+//
+// Show the prices in force at a time, or just one sku's:
+//
+//	go run ./cmd/pricehistory -history price-history.jsonl -at 2026-10-05T14:30:00Z -sku A
+//
+// With neither it lists every version.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func main() {
+	historyPath := flag.String("history", "", "price history file")
+	publishPath := flag.String("publish", "", "json price list to publish")
+	from := flag.String("from", "", "when the published prices take effect, a date or RFC3339 time, defaults to now")
+	at := flag.String("at", "", "show the prices in force at this date or RFC3339 time")
+	item := flag.String("sku", "", "only show this sku's price with -at")
+	flag.Parse()
+
+	if *historyPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	history, err := pricing.OpenHistory(*historyPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer history.Close()
+
+	switch {
+	case *publishPath != "":
+		prices, err := pricing.LoadFile(*publishPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var effectiveFrom time.Time
+		if *from != "" {
+			if effectiveFrom, err = parseTime(*from); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		v, err := history.Publish(prices, effectiveFrom)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("published version %d effective from %s\n", v.Number, v.EffectiveFrom.Format(time.RFC3339))
+
+	case *at != "":
+		t, err := parseTime(*at)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		v, err := history.At(t)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("version %d effective from %s\n", v.Number, v.EffectiveFrom.Format(time.RFC3339))
+
+		if *item == "" {
+			if err := pricing.Write(os.Stdout, v.Pricing); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		var id sku.SKU
+		if err := id.UnmarshalText([]byte(*item)); err != nil {
+			log.Fatal(err)
+		}
+		data, exists := v.Pricing.Config[id]
+		if !exists {
+			log.Fatalf("sku %s wasn't priced at %s", id, t.Format(time.RFC3339))
+		}
+		line := fmt.Sprintf("%s: %d each", id, data.UnitPrice)
+		if data.HasSpecialOffer() {
+			line += fmt.Sprintf(", %d for %d", data.SpecialQuantity.Value(), data.SpecialPrice)
+		}
+		if data.MemberPrice != 0 {
+			line += fmt.Sprintf(", members %d", data.MemberPrice)
+		}
+		fmt.Println(line)
+
+	default:
+		versions, err := history.Versions()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, v := range versions {
+			fmt.Printf("version %d effective from %s published %s, %d skus\n", v.Number, v.EffectiveFrom.Format(time.RFC3339), v.Published.Format(time.RFC3339), len(v.Pricing.Config))
+		}
+	}
+}
+
+// a date is the start of that day in utc
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
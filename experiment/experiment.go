@@ -0,0 +1,160 @@
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+var (
+	errNoName           = errors.New("an experiment needs a name")
+	errNoVariants       = errors.New("an experiment needs at least one variant")
+	errInvalidWeight    = errors.New("variant weights must be greater than zero")
+	errDuplicateVariant = errors.New("variant names must be unique")
+	errNoPricing        = errors.New("every variant needs pricing rules")
+	errNoSession        = errors.New("a session id is required")
+)
+
+// PricingRules is the pricing each variant uses, it matches checkout.PricingRules
+type PricingRules interface {
+	GetPrice(sku sku.SKU, quantity quantity.Quantity) currency.Pence
+	PriceExists(sku sku.SKU) bool
+}
+
+// Variant is one set of prices being trialled, Weight is its share of the sessions compared to the other variants
+type Variant struct {
+	Name    string
+	Weight  int
+	Pricing PricingRules
+}
+
+// Experiment splits checkout sessions between variants with different prices
+type Experiment struct {
+	name     string
+	variants []Variant
+	total    int
+}
+
+// New creates an experiment e.g. sending 10% of sessions to new prices
+//
+//	experiment.New("cheaper-b", experiment.Variant{Name: "control", Weight: 90, Pricing: current}, experiment.Variant{Name: "cheaper", Weight: 10, Pricing: trial})
+func New(name string, variants ...Variant) (*Experiment, error) {
+	if name == "" {
+		return nil, errNoName
+	}
+	if len(variants) == 0 {
+		return nil, errNoVariants
+	}
+
+	e := &Experiment{name: name}
+	seen := make(map[string]bool)
+	for _, v := range variants {
+		if v.Weight <= 0 {
+			return nil, fmt.Errorf("%w: %s has %d", errInvalidWeight, v.Name, v.Weight)
+		}
+		if seen[v.Name] {
+			return nil, fmt.Errorf("%w: %s", errDuplicateVariant, v.Name)
+		}
+		if v.Pricing == nil {
+			return nil, fmt.Errorf("%w: %s", errNoPricing, v.Name)
+		}
+		seen[v.Name] = true
+		e.total += v.Weight
+	}
+	e.variants = append(e.variants, variants...)
+
+	return e, nil
+}
+
+func (e *Experiment) Name() string {
+	return e.name
+}
+
+// Assign picks the session's variant by hashing the session id with the experiment name,
+// the same session always gets the same variant and different experiments split sessions independently
+func (e *Experiment) Assign(sessionID string) Variant {
+	h := fnv.New64a()
+	h.Write([]byte(e.name))
+	h.Write([]byte{0})
+	h.Write([]byte(sessionID))
+
+	bucket := int(h.Sum64() % uint64(e.total))
+	for _, v := range e.variants {
+		if bucket < v.Weight {
+			return v
+		}
+		bucket -= v.Weight
+	}
+
+	// unreachable as the buckets add up to the total weight
+	return e.variants[len(e.variants)-1]
+}
+
+// Assignment is the variant a session was put in
+type Assignment struct {
+	Session    string `json:"session"`
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+}
+
+func (a Assignment) String() string {
+	return a.Experiment + "/" + a.Variant
+}
+
+// Session is the pricing rules for one checkout session, it prices everything with its variant's pricing
+// and can be passed straight to the checkout
+type Session struct {
+	Assignment Assignment
+	pricing    PricingRules
+}
+
+// ForSession assigns the session to a variant and returns its pricing
+func (e *Experiment) ForSession(sessionID string) (*Session, error) {
+	if sessionID == "" {
+		return nil, errNoSession
+	}
+
+	v := e.Assign(sessionID)
+	return &Session{
+		Assignment: Assignment{Session: sessionID, Experiment: e.name, Variant: v.Name},
+		pricing:    v.Pricing,
+	}, nil
+}
+
+func (s *Session) GetPrice(id sku.SKU, qty quantity.Quantity) currency.Pence {
+	return s.GetPriceIn(pricing.Context{}, id, qty)
+}
+
+// GetPriceIn passes the context on to the variant's pricing so e.g. member prices come from the variant
+func (s *Session) GetPriceIn(ctx pricing.Context, id sku.SKU, qty quantity.Quantity) currency.Pence {
+	if s == nil {
+		return 0
+	}
+	return pricing.PriceIn(s.pricing, ctx, id, qty)
+}
+
+func (s *Session) PriceExists(id sku.SKU) bool {
+	if s == nil {
+		return false
+	}
+	return s.pricing.PriceExists(id)
+}
+
+// OffersAppliedIn passes through to the variant's pricing when it knows about offers
+func (s *Session) OffersAppliedIn(ctx pricing.Context, id sku.SKU, qty quantity.Quantity) int {
+	if s == nil {
+		return 0
+	}
+	offers, _ := pricing.OffersAppliedIn(s.pricing, ctx, id, qty)
+	return offers
+}
+
+// Variant names the experiment and variant the session is in, the checkout prints it on the receipt
+func (s *Session) Variant() string {
+	return s.Assignment.String()
+}
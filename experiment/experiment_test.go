@@ -0,0 +1,106 @@
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func newTestExperiment(t *testing.T, controlWeight, trialWeight int) *Experiment {
+	t.Helper()
+
+	skuA, _ := sku.New('A')
+	control := &pricing.SpecialPricing{Config: map[sku.SKU]pricing.PricingData{skuA: {UnitPrice: 50, MemberPrice: 40}}}
+	trial := &pricing.SpecialPricing{Config: map[sku.SKU]pricing.PricingData{skuA: {UnitPrice: 45, MemberPrice: 35}}}
+
+	e, err := New("cheaper-a", Variant{Name: "control", Weight: controlWeight, Pricing: control}, Variant{Name: "trial", Weight: trialWeight, Pricing: trial})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return e
+}
+
+func TestExperiment_ForSession(t *testing.T) {
+	e := newTestExperiment(t, 50, 50)
+	skuA, _ := sku.New('A')
+
+	prices := map[string]currency.Pence{"control": 50, "trial": 45}
+	memberPrices := map[string]currency.Pence{"control": 40, "trial": 35}
+	seen := make(map[string]bool)
+
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("session-%d", i)
+
+		session, err := e.ForSession(id)
+		if err != nil {
+			t.Fatalf("ForSession() error = %v", err)
+		}
+
+		// the same session always gets the same variant
+		again, _ := e.ForSession(id)
+		if again.Assignment != session.Assignment {
+			t.Fatalf("ForSession(%q) = %v then %v", id, session.Assignment, again.Assignment)
+		}
+
+		variant := session.Assignment.Variant
+		seen[variant] = true
+		if got := session.GetPrice(skuA, *quantity.New(1)); got != prices[variant] {
+			t.Errorf("%s GetPrice() = %d, want %d", variant, got, prices[variant])
+		}
+		// members get the variant's member prices
+		if got := session.GetPriceIn(pricing.Context{Member: true}, skuA, *quantity.New(1)); got != memberPrices[variant] {
+			t.Errorf("%s GetPriceIn() for a member = %d, want %d", variant, got, memberPrices[variant])
+		}
+		if got := session.Variant(); got != "cheaper-a/"+variant {
+			t.Errorf("Variant() = %q", got)
+		}
+	}
+
+	if !seen["control"] || !seen["trial"] {
+		t.Errorf("expected sessions in both variants, got %v", seen)
+	}
+}
+
+func TestExperiment_Assign_weights(t *testing.T) {
+	e := newTestExperiment(t, 90, 10)
+
+	const sessions = 10000
+	trial := 0
+	for i := 0; i < sessions; i++ {
+		if e.Assign(fmt.Sprintf("session-%d", i)).Name == "trial" {
+			trial++
+		}
+	}
+
+	if share := float64(trial) / sessions; math.Abs(share-0.1) > 0.02 {
+		t.Errorf("trial got %.3f of the sessions, want about 0.1", share)
+	}
+}
+
+func TestNew_errors(t *testing.T) {
+	rules := &pricing.SpecialPricing{}
+
+	tests := []struct {
+		name     string
+		variants []Variant
+		wantErr  error
+	}{
+		{name: "no variants", wantErr: errNoVariants},
+		{name: "zero weight", variants: []Variant{{Name: "control", Pricing: rules}}, wantErr: errInvalidWeight},
+		{name: "duplicate", variants: []Variant{{Name: "control", Weight: 1, Pricing: rules}, {Name: "control", Weight: 1, Pricing: rules}}, wantErr: errDuplicateVariant},
+		{name: "no pricing", variants: []Variant{{Name: "control", Weight: 1}}, wantErr: errNoPricing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New("test", tt.variants...); !errors.Is(err, tt.wantErr) {
+				t.Errorf("New() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
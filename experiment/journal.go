@@ -0,0 +1,142 @@
+package experiment
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+)
+
+// Outcome of a session in an experiment
+type Outcome string
+
+const (
+	// the customer paid
+	OutcomeCompleted Outcome = "completed"
+	// the customer left without paying
+	OutcomeAbandoned Outcome = "abandoned"
+)
+
+var errUnknownOutcome = errors.New("unknown session outcome")
+
+// Record is how a session in an experiment ended
+type Record struct {
+	Time time.Time `json:"time"`
+	Assignment
+	Outcome Outcome `json:"outcome"`
+	// what the customer paid, zero for abandoned sessions
+	Total currency.Pence `json:"total"`
+}
+
+// Journal is an append-only json lines file of how each session in an experiment ended
+type Journal struct {
+	mu   sync.Mutex
+	file *os.File
+	now  func() time.Time
+}
+
+// OpenJournal opens the journal at the path, creating it if it doesn't exist
+func OpenJournal(path string) (*Journal, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{file: file, now: time.Now}, nil
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// Completed records the session's sale
+func (j *Journal) Completed(session *Session, total currency.Pence) (Record, error) {
+	return j.append(session, OutcomeCompleted, total)
+}
+
+// Abandoned records the customer leaving without paying
+func (j *Journal) Abandoned(session *Session) (Record, error) {
+	return j.append(session, OutcomeAbandoned, 0)
+}
+
+// SessionJournal records the outcome of one session, pass it to checkout.WithExperimentOutcomes so the checkout records
+// it when the session really ends
+type SessionJournal struct {
+	journal *Journal
+	session *Session
+}
+
+// ForSession records outcomes for the session
+func (j *Journal) ForSession(session *Session) SessionJournal {
+	return SessionJournal{journal: j, session: session}
+}
+
+func (s SessionJournal) Completed(total currency.Pence) error {
+	_, err := s.journal.Completed(s.session, total)
+	return err
+}
+
+func (s SessionJournal) Abandoned() error {
+	_, err := s.journal.Abandoned(s.session)
+	return err
+}
+
+func (j *Journal) append(session *Session, outcome Outcome, total currency.Pence) (Record, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	r := Record{Time: j.now().UTC().Round(0), Assignment: session.Assignment, Outcome: outcome, Total: total}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return Record{}, err
+	}
+
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return Record{}, err
+	}
+	return r, j.file.Sync()
+}
+
+// ReadRecords reads the json lines written by a journal
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("failed to read experiment record %d: %w", len(records)+1, err)
+		}
+		if record.Outcome != OutcomeCompleted && record.Outcome != OutcomeAbandoned {
+			return nil, fmt.Errorf("experiment record %d: %w %q", len(records)+1, errUnknownOutcome, record.Outcome)
+		}
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
+
+// ReadRecordsFile reads a journal file
+func ReadRecordsFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadRecords(f)
+}
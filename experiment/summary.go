@@ -0,0 +1,86 @@
+package experiment
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+)
+
+// Summary is how a variant is doing
+type Summary struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+	Sessions   int    `json:"sessions"`
+	Completed  int    `json:"completed"`
+	Abandoned  int    `json:"abandoned"`
+	// share of the sessions which completed, 0 to 1
+	Conversion float64 `json:"conversion"`
+	// takings from the completed sessions and the average of them
+	Total   currency.Pence `json:"total"`
+	Average currency.Pence `json:"average"`
+}
+
+// Summarise works out the totals and conversion for every variant in the records, sorted by experiment then variant.
+// Each session is only counted once, if it has more than one record the last one wins e.g. a retried sale.
+func Summarise(records []Record) []Summary {
+	type key struct{ experiment, session string }
+
+	last := make(map[key]Record)
+	for _, r := range records {
+		last[key{r.Experiment, r.Session}] = r
+	}
+
+	type variantKey struct{ experiment, variant string }
+	summaries := make(map[variantKey]*Summary)
+
+	for _, r := range last {
+		k := variantKey{r.Experiment, r.Variant}
+		s, exists := summaries[k]
+		if !exists {
+			s = &Summary{Experiment: r.Experiment, Variant: r.Variant}
+			summaries[k] = s
+		}
+
+		s.Sessions++
+		if r.Outcome == OutcomeCompleted {
+			s.Completed++
+			s.Total += r.Total
+		} else {
+			s.Abandoned++
+		}
+	}
+
+	result := make([]Summary, 0, len(summaries))
+	for _, s := range summaries {
+		s.Conversion = float64(s.Completed) / float64(s.Sessions)
+		if s.Completed > 0 {
+			s.Average = s.Total / currency.Pence(s.Completed)
+		}
+		result = append(result, *s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Experiment != result[j].Experiment {
+			return result[i].Experiment < result[j].Experiment
+		}
+		return result[i].Variant < result[j].Variant
+	})
+
+	return result
+}
+
+// Format writes the summaries as a plain text table
+func Format(w io.Writer, summaries []Summary) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%-24s %8s %9s %10s %10s %8s\n", "variant", "sessions", "completed", "conversion", "total", "average")
+	for _, s := range summaries {
+		fmt.Fprintf(&b, "%-24s %8d %9d %9.1f%% %10d %8d\n", s.Experiment+"/"+s.Variant, s.Sessions, s.Completed, s.Conversion*100, s.Total, s.Average)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
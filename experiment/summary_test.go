@@ -0,0 +1,62 @@
+package experiment
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSummarise(t *testing.T) {
+	records, err := ReadRecordsFile("testdata/journal.jsonl")
+	if err != nil {
+		t.Fatalf("ReadRecordsFile() error = %v", err)
+	}
+
+	want := []Summary{
+		{Experiment: "cheaper-b", Variant: "control", Sessions: 2, Completed: 1, Abandoned: 1, Conversion: 0.5, Total: 120, Average: 120},
+		// s4 abandoned then came back and paid, it only counts once
+		{Experiment: "cheaper-b", Variant: "trial", Sessions: 2, Completed: 2, Conversion: 1, Total: 200, Average: 100},
+	}
+
+	if got := Summarise(records); !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarise() = %+v, want %+v", got, want)
+	}
+}
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "experiments.jsonl")
+	e := newTestExperiment(t, 50, 50)
+
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+
+	first, _ := e.ForSession("first")
+	second, _ := e.ForSession("second")
+
+	if _, err := journal.Completed(first, 150); err != nil {
+		t.Fatalf("Completed() error = %v", err)
+	}
+	if _, err := journal.Abandoned(second); err != nil {
+		t.Fatalf("Abandoned() error = %v", err)
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := ReadRecordsFile(path)
+	if err != nil {
+		t.Fatalf("ReadRecordsFile() error = %v", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want %d", len(records), 2)
+	}
+	if records[0].Assignment != first.Assignment || records[0].Outcome != OutcomeCompleted || records[0].Total != 150 {
+		t.Errorf("first record = %+v", records[0])
+	}
+	if records[1].Assignment != second.Assignment || records[1].Outcome != OutcomeAbandoned {
+		t.Errorf("second record = %+v", records[1])
+	}
+}
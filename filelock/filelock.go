@@ -0,0 +1,14 @@
+// Package filelock takes advisory locks on open files so processes sharing an append-only file take turns writing.
+package filelock
+
+import "os"
+
+// Lock takes an exclusive lock on the file, blocking until any other process has unlocked it
+func Lock(f *os.File) error {
+	return lock(f)
+}
+
+// Unlock gives up the lock taken by Lock
+func Unlock(f *os.File) error {
+	return unlock(f)
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package filelock
+
+import "os"
+
+// without flock only the locks inside the process protect the file
+func lock(*os.File) error {
+	return nil
+}
+
+func unlock(*os.File) error {
+	return nil
+}
@@ -0,0 +1,58 @@
+//go:build unix
+
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locked")
+
+	open := func() *os.File {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+		if err != nil {
+			t.Fatalf("failed to open file: %v", err)
+		}
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+
+	// each open file is locked separately, the same as two processes
+	first, second := open(), open()
+
+	if err := Lock(first); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	locked := make(chan error)
+	go func() {
+		locked <- Lock(second)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("second Lock() returned while the file was locked")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := Unlock(first); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	select {
+	case err := <-locked:
+		if err != nil {
+			t.Fatalf("second Lock() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second Lock() didn't return once the file was unlocked")
+	}
+
+	if err := Unlock(second); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+}
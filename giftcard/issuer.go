@@ -0,0 +1,107 @@
+package giftcard
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/receipt"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+const cardNumberLength = 16
+
+var (
+	ErrSaleAlreadyActivated = errors.New("gift cards on the sale have already been activated")
+	errNoGiftCardSKUs       = errors.New("at least one gift card sku is required")
+	errNotASale             = errors.New("gift cards are only activated by a sale")
+)
+
+// Card is a gift card activated by a sale
+type Card struct {
+	Number  string
+	SKU     sku.SKU
+	Balance currency.Pence
+}
+
+// Issuer activates gift cards when a gift card product is sold, the price the customer paid is loaded onto the card
+type Issuer struct {
+	ledger *Ledger
+	skus   map[sku.SKU]bool
+	// generates card numbers, swapped out in tests
+	newCardNumber func() (string, error)
+}
+
+func NewIssuer(ledger *Ledger, giftCardSKUs ...sku.SKU) (*Issuer, error) {
+	if len(giftCardSKUs) == 0 {
+		return nil, errNoGiftCardSKUs
+	}
+
+	skus := make(map[sku.SKU]bool, len(giftCardSKUs))
+	for _, s := range giftCardSKUs {
+		skus[s] = true
+	}
+
+	return &Issuer{ledger: ledger, skus: skus, newCardNumber: newCardNumber}, nil
+}
+
+// IsGiftCard reports whether the sku is a gift card product
+func (i *Issuer) IsGiftCard(sku sku.SKU) bool {
+	return i.skus[sku]
+}
+
+// Activate issues a card for every gift card sold on the receipt, the receipt id is recorded as the reference
+// so a sale can only activate its cards once. The cards are activated together so either all of them are or none.
+func (i *Issuer) Activate(sale *receipt.Receipt) ([]Card, error) {
+	if sale.Kind != receipt.Sale {
+		return nil, errNotASale
+	}
+
+	var (
+		cards       []Card
+		activations []Activation
+	)
+	for _, line := range sale.Lines {
+		qty := line.Quantity.Value()
+		if !i.IsGiftCard(line.SKU) || qty == 0 {
+			continue
+		}
+
+		// each card gets its share of the line price, the first cards get any pennies left over
+		value := line.Price / currency.Pence(qty)
+		extra := int(line.Price % currency.Pence(qty))
+
+		for n := 0; n < qty; n++ {
+			number, err := i.newCardNumber()
+			if err != nil {
+				return nil, err
+			}
+
+			balance := value
+			if n < extra {
+				balance++
+			}
+
+			cards = append(cards, Card{Number: number, SKU: line.SKU, Balance: balance})
+			activations = append(activations, Activation{Card: number, Value: balance})
+		}
+	}
+
+	if _, err := i.ledger.ActivateAll(sale.ID, activations); err != nil {
+		return nil, err
+	}
+
+	return cards, nil
+}
+
+// random 16 digit card number
+func newCardNumber() (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(cardNumberLength), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", cardNumberLength, n), nil
+}
@@ -0,0 +1,150 @@
+package giftcard
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/receipt"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func TestIssuer_Activate(t *testing.T) {
+	ledger, path := openLedger(t)
+
+	skuA, _ := sku.New('A')
+	skuG, _ := sku.New('G')
+
+	issuer, err := NewIssuer(ledger, skuG)
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+
+	next := 0
+	issuer.newCardNumber = func() (string, error) {
+		next++
+		return fmt.Sprintf("%016d", next), nil
+	}
+
+	store := receipt.NewStore()
+	sale := store.IssueSale(receipt.SaleDetails{
+		Lines: []receipt.Line{
+			{SKU: skuA, Quantity: *quantity.New(1), Price: 50},
+			{SKU: skuG, Quantity: *quantity.New(2), Price: 4000},
+		},
+		Total: 4050,
+	})
+
+	cards, err := issuer.Activate(sale)
+	if err != nil {
+		t.Fatalf("Issuer.Activate() error = %v", err)
+	}
+
+	if len(cards) != 2 {
+		t.Fatalf("Issuer.Activate() = %+v, want 2 cards", cards)
+	}
+
+	for _, card := range cards {
+		if balance, err := ledger.Balance(card.Number); err != nil || balance != 2000 {
+			t.Errorf("card %s balance = %d, %v, want %d", card.Number, balance, err, 2000)
+		}
+	}
+
+	if _, err := issuer.Activate(sale); !errors.Is(err, ErrSaleAlreadyActivated) {
+		t.Errorf("activating the sale twice error = %v, want %v", err, ErrSaleAlreadyActivated)
+	}
+
+	assertReconciles(t, ledger, path)
+}
+
+func TestNewCardNumber(t *testing.T) {
+	number, err := newCardNumber()
+	if err != nil {
+		t.Fatalf("newCardNumber() error = %v", err)
+	}
+	if len(number) != cardNumberLength {
+		t.Errorf("newCardNumber() = %s, want %d digits", number, cardNumberLength)
+	}
+}
+
+func TestIssuer_Activate_remainder(t *testing.T) {
+	ledger, path := openLedger(t)
+	skuG, _ := sku.New('G')
+
+	issuer, err := NewIssuer(ledger, skuG)
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+
+	sale := receipt.NewStore().IssueSale(receipt.SaleDetails{
+		Lines: []receipt.Line{{SKU: skuG, Quantity: *quantity.New(3), Price: 1001}},
+		Total: 1001,
+	})
+
+	cards, err := issuer.Activate(sale)
+	if err != nil {
+		t.Fatalf("Issuer.Activate() error = %v", err)
+	}
+
+	// no penny of what the customer paid is lost
+	loaded := currency.Pence(0)
+	for _, card := range cards {
+		loaded += card.Balance
+	}
+	if loaded != 1001 || cards[0].Balance != 334 || cards[2].Balance != 333 {
+		t.Errorf("Issuer.Activate() loaded %d onto %+v, want 1001", loaded, cards)
+	}
+
+	assertReconciles(t, ledger, path)
+}
+
+func TestIssuer_Activate_sharedLedger(t *testing.T) {
+	ledger, path := openLedger(t)
+	skuG, _ := sku.New('G')
+
+	// another process with the same ledger file open
+	other, err := OpenLedger(path)
+	if err != nil {
+		t.Fatalf("OpenLedger() error = %v", err)
+	}
+	defer other.Close()
+
+	sale := receipt.NewStore().IssueSale(receipt.SaleDetails{
+		Lines: []receipt.Line{{SKU: skuG, Quantity: *quantity.New(1), Price: 2000}},
+		Total: 2000,
+	})
+
+	var (
+		wg        sync.WaitGroup
+		activated atomic.Int32
+	)
+	for _, l := range []*Ledger{ledger, other, ledger, other} {
+		issuer, err := NewIssuer(l, skuG)
+		if err != nil {
+			t.Fatalf("NewIssuer() error = %v", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := issuer.Activate(sale)
+			switch {
+			case err == nil:
+				activated.Add(1)
+			case !errors.Is(err, ErrSaleAlreadyActivated):
+				t.Errorf("Issuer.Activate() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := activated.Load(); got != 1 {
+		t.Errorf("the sale's cards were activated %d times, want once", got)
+	}
+
+	assertReconciles(t, ledger, path)
+}
@@ -0,0 +1,409 @@
+package giftcard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/filelock"
+	"github.com/Joshswooft/thinkmoney-test/payment"
+)
+
+var (
+	ErrCardNotFound        = errors.New("gift card not found")
+	ErrAlreadyActive       = errors.New("gift card has already been activated")
+	ErrInsufficientBalance = errors.New("not enough left on the gift card")
+	ErrInvalidAmount       = errors.New("amount must be greater than zero")
+	errNoCardNumber        = errors.New("a card number is required")
+)
+
+// Kind of ledger entry
+type Kind string
+
+const (
+	// a card was sold and its balance loaded
+	KindActivate Kind = "activate"
+	// the card was used to pay
+	KindRedeem Kind = "redeem"
+	// a redemption which was refused by the transaction is credited back
+	KindReversal Kind = "reversal"
+)
+
+// Entry is a single change to a gift card's balance, entries are never changed once written
+type Entry struct {
+	Seq  int64     `json:"seq"`
+	Time time.Time `json:"time"`
+	Card string    `json:"card"`
+	Kind Kind      `json:"kind"`
+	// credits are positive and debits negative
+	Amount currency.Pence `json:"amount"`
+	// the card's balance after the entry
+	Balance currency.Pence `json:"balance"`
+	// e.g. the receipt the card was sold or redeemed on
+	Reference string `json:"reference,omitempty"`
+}
+
+// Tenderer takes payment, a payment.Transaction satisfies this
+type Tenderer interface {
+	Tender(tender payment.Tender) error
+}
+
+// Ledger is an append-only record of every gift card operation kept in a json lines file.
+// Balances are only ever worked out from the ledger and every operation holds the lock while it checks the balance and
+// writes its entry, so checkouts sharing a ledger can never spend the same balance twice. The file is locked too and
+// read again before each write so processes sharing the file are kept in step.
+type Ledger struct {
+	mu       sync.Mutex
+	file     *os.File
+	balances map[string]currency.Pence
+	entries  []Entry
+	now      func() time.Time
+}
+
+// OpenLedger opens the ledger at the path, creating it if it doesn't exist.
+// The existing entries are reconciled first and a ledger which doesn't add up is refused.
+func OpenLedger(path string) (*Ledger, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ReadEntries(file)
+	if err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+
+	balances, err := Reconcile(entries)
+	if err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+
+	return &Ledger{file: file, balances: balances, entries: entries, now: time.Now}, nil
+}
+
+// Close closes the ledger file
+func (l *Ledger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// ReadEntries reads the json lines written by a ledger.
+// A last line which was cut short by a crash part way through writing it is skipped, the next write truncates it.
+func ReadEntries(r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, _, err := parseEntries(data)
+	return entries, err
+}
+
+// parses every complete entry, complete is how many bytes of the data they take up. Only an unterminated last line
+// can be cut short so any other line which isn't an entry is an error.
+func parseEntries(data []byte) (entries []Entry, complete int, err error) {
+	for complete < len(data) {
+		line := data[complete:]
+		end := bytes.IndexByte(line, '\n')
+		if end >= 0 {
+			line = line[:end]
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			var e Entry
+			if err := json.Unmarshal(line, &e); err != nil {
+				if end < 0 {
+					return entries, complete, nil
+				}
+				return nil, 0, fmt.Errorf("failed to read ledger entry %d: %w", len(entries)+1, err)
+			}
+			entries = append(entries, e)
+		}
+
+		if end < 0 {
+			return entries, len(data), nil
+		}
+		complete += end + 1
+	}
+
+	return entries, complete, nil
+}
+
+// runs the write holding the file lock, with whatever other processes have written read in first.
+// must be called with the lock held
+func (l *Ledger) locked(write func() error) (err error) {
+	if err := filelock.Lock(l.file); err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, filelock.Unlock(l.file))
+	}()
+
+	if err := l.reload(); err != nil {
+		return err
+	}
+
+	return write()
+}
+
+// reads the file again and repairs a last entry which was cut short so the next one starts on its own line.
+// must be called with the file locked
+func (l *Ledger) reload() error {
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(l.file)
+	if err != nil {
+		return err
+	}
+
+	entries, complete, err := parseEntries(data)
+	if err != nil {
+		return err
+	}
+	balances, err := Reconcile(entries)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case complete < len(data):
+		if err := l.file.Truncate(int64(complete)); err != nil {
+			return err
+		}
+	case len(data) > 0 && data[len(data)-1] != '\n':
+		// the entry was written but not its newline
+		if _, err := l.file.Write([]byte{'\n'}); err != nil {
+			return err
+		}
+	}
+
+	l.entries, l.balances = entries, balances
+	return nil
+}
+
+// writes the entry to disk before it is counted in any balance
+func (l *Ledger) append(card string, kind Kind, amount currency.Pence, reference string) (Entry, error) {
+	e := Entry{
+		Seq:       int64(len(l.entries)) + 1,
+		Time:      l.now().UTC().Round(0),
+		Card:      card,
+		Kind:      kind,
+		Amount:    amount,
+		Balance:   l.balances[card] + amount,
+		Reference: reference,
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return Entry{}, err
+	}
+	if err := l.file.Sync(); err != nil {
+		return Entry{}, err
+	}
+
+	l.entries = append(l.entries, e)
+	l.balances[card] = e.Balance
+
+	return e, nil
+}
+
+// Activate loads the value onto a newly sold card
+func (l *Ledger) Activate(card string, value currency.Pence, reference string) (Entry, error) {
+	if card == "" {
+		return Entry{}, errNoCardNumber
+	}
+	if value <= 0 {
+		return Entry{}, ErrInvalidAmount
+	}
+
+	entries, err := l.ActivateAll(reference, []Activation{{Card: card, Value: value}})
+	if err != nil {
+		return Entry{}, err
+	}
+	return entries[0], nil
+}
+
+// Activation is a card to activate and the value to load onto it
+type Activation struct {
+	Card  string
+	Value currency.Pence
+}
+
+// ActivateAll activates the cards sold under the reference in one go. It is refused with ErrSaleAlreadyActivated if
+// the reference has activated cards before and nothing is written if any of the cards is already active.
+func (l *Ledger) ActivateAll(reference string, cards []Activation) ([]Entry, error) {
+	for _, card := range cards {
+		if card.Card == "" {
+			return nil, errNoCardNumber
+		}
+		if card.Value <= 0 {
+			return nil, ErrInvalidAmount
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []Entry
+	err := l.locked(func() error {
+		for _, e := range l.entries {
+			if e.Kind == KindActivate && e.Reference == reference && reference != "" {
+				return fmt.Errorf("%w: %s", ErrSaleAlreadyActivated, reference)
+			}
+		}
+
+		seen := make(map[string]bool, len(cards))
+		for _, card := range cards {
+			if _, exists := l.balances[card.Card]; exists || seen[card.Card] {
+				return fmt.Errorf("%w: %s", ErrAlreadyActive, card.Card)
+			}
+			seen[card.Card] = true
+		}
+
+		for _, card := range cards {
+			e, err := l.append(card.Card, KindActivate, card.Value, reference)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// Redeem pays some or all of the card's balance towards the transaction.
+// The debit is written before tendering, if the transaction refuses the tender the amount is credited back.
+func (l *Ledger) Redeem(tx Tenderer, card string, amount currency.Pence, reference string) (Entry, error) {
+	if amount <= 0 {
+		return Entry{}, ErrInvalidAmount
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var debit Entry
+	err := l.locked(func() error {
+		balance, exists := l.balances[card]
+		if !exists {
+			return ErrCardNotFound
+		}
+
+		if amount > balance {
+			return fmt.Errorf("%w: redeeming %d but only %d left", ErrInsufficientBalance, amount, balance)
+		}
+
+		var err error
+		if debit, err = l.append(card, KindRedeem, -amount, reference); err != nil {
+			return err
+		}
+
+		if err := tx.Tender(payment.Tender{Method: payment.GiftCard, Amount: amount, Reference: card}); err != nil {
+			_, reversalErr := l.append(card, KindReversal, amount, reference)
+			return errors.Join(err, reversalErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return Entry{}, err
+	}
+	return debit, nil
+}
+
+// Balance of a card
+func (l *Ledger) Balance(card string) (currency.Pence, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	balance, exists := l.balances[card]
+	if !exists {
+		return 0, ErrCardNotFound
+	}
+	return balance, nil
+}
+
+// Entries returns a copy of every entry in the order they were written
+func (l *Ledger) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]Entry, len(l.entries))
+	copy(entries, l.entries)
+	return entries
+}
+
+// ReconcileError explains the first entry which doesn't add up
+type ReconcileError struct {
+	Seq    int64
+	Reason string
+}
+
+func (e *ReconcileError) Error() string {
+	return fmt.Sprintf("gift card ledger doesn't reconcile at entry %d: %s", e.Seq, e.Reason)
+}
+
+// Reconcile replays the entries and works out every card's balance, checking that the sequence has no gaps, each
+// card is activated once before it is used, every recorded balance matches the running total and no card is overspent.
+func Reconcile(entries []Entry) (map[string]currency.Pence, error) {
+	balances := make(map[string]currency.Pence)
+
+	for i, e := range entries {
+		fail := func(format string, args ...any) error {
+			return &ReconcileError{Seq: e.Seq, Reason: fmt.Sprintf(format, args...)}
+		}
+
+		if e.Seq != int64(i)+1 {
+			return nil, fail("expected sequence %d", i+1)
+		}
+
+		balance, active := balances[e.Card]
+
+		switch e.Kind {
+		case KindActivate:
+			if active {
+				return nil, fail("card %s activated twice", e.Card)
+			}
+			if e.Amount <= 0 {
+				return nil, fail("activated with %d", e.Amount)
+			}
+		case KindRedeem:
+			if e.Amount >= 0 {
+				return nil, fail("redemption of %d isn't a debit", e.Amount)
+			}
+		case KindReversal:
+			if e.Amount <= 0 {
+				return nil, fail("reversal of %d isn't a credit", e.Amount)
+			}
+		default:
+			return nil, fail("unknown kind %q", e.Kind)
+		}
+
+		if e.Kind != KindActivate && !active {
+			return nil, fail("card %s used before it was activated", e.Card)
+		}
+
+		balance += e.Amount
+		if balance < 0 {
+			return nil, fail("card %s overspent by %d", e.Card, -balance)
+		}
+		if balance != e.Balance {
+			return nil, fail("card %s balance is %d but the entry says %d", e.Card, balance, e.Balance)
+		}
+
+		balances[e.Card] = balance
+	}
+
+	return balances, nil
+}
@@ -0,0 +1,301 @@
+package giftcard
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/payment"
+)
+
+func openLedger(t *testing.T) (*Ledger, string) {
+	path := filepath.Join(t.TempDir(), "giftcards.jsonl")
+
+	ledger, err := OpenLedger(path)
+	if err != nil {
+		t.Fatalf("OpenLedger() error = %v", err)
+	}
+	t.Cleanup(func() { ledger.Close() })
+
+	return ledger, path
+}
+
+func newTransaction(t *testing.T, total currency.Pence) *payment.Transaction {
+	tx, err := payment.NewTransaction(total)
+	if err != nil {
+		t.Fatalf("payment.NewTransaction() error = %v", err)
+	}
+	return tx
+}
+
+// checks the ledger's balances match replaying the file from scratch
+func assertReconciles(t *testing.T, ledger *Ledger, path string) {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open ledger file: %v", err)
+	}
+	defer file.Close()
+
+	entries, err := ReadEntries(file)
+	if err != nil {
+		t.Fatalf("ReadEntries() error = %v", err)
+	}
+
+	balances, err := Reconcile(entries)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	for card, want := range balances {
+		if got, err := ledger.Balance(card); err != nil || got != want {
+			t.Errorf("ledger balance for %s = %d, %v, reconciled balance is %d", card, got, err, want)
+		}
+	}
+}
+
+func TestLedger_Redeem(t *testing.T) {
+	ledger, path := openLedger(t)
+
+	if _, err := ledger.Activate("1111", 2000, "R000001"); err != nil {
+		t.Fatalf("Ledger.Activate() error = %v", err)
+	}
+
+	if _, err := ledger.Activate("1111", 2000, "R000002"); !errors.Is(err, ErrAlreadyActive) {
+		t.Errorf("activating twice error = %v, want %v", err, ErrAlreadyActive)
+	}
+
+	// partial use
+	tx := newTransaction(t, 1500)
+	if _, err := ledger.Redeem(tx, "1111", 1500, "R000003"); err != nil {
+		t.Fatalf("Ledger.Redeem() error = %v", err)
+	}
+
+	if balance, _ := ledger.Balance("1111"); balance != 500 {
+		t.Errorf("balance after partial use = %d, want %d", balance, 500)
+	}
+
+	if _, err := ledger.Redeem(newTransaction(t, 1000), "1111", 501, "R000004"); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("overspending error = %v, want %v", err, ErrInsufficientBalance)
+	}
+
+	// the transaction refuses more than is left to pay so the debit is reversed
+	if _, err := ledger.Redeem(newTransaction(t, 100), "1111", 500, "R000005"); !errors.Is(err, payment.ErrOverpayment) {
+		t.Errorf("refused tender error = %v, want %v", err, payment.ErrOverpayment)
+	}
+
+	if balance, _ := ledger.Balance("1111"); balance != 500 {
+		t.Errorf("balance after refused tender = %d, want %d", balance, 500)
+	}
+
+	if _, err := ledger.Redeem(tx, "2222", 100, "R000006"); !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("unknown card error = %v, want %v", err, ErrCardNotFound)
+	}
+
+	kinds := []Kind{}
+	for _, e := range ledger.Entries() {
+		kinds = append(kinds, e.Kind)
+	}
+	if want := []Kind{KindActivate, KindRedeem, KindRedeem, KindReversal}; len(kinds) != len(want) || kinds[3] != KindReversal {
+		t.Errorf("ledger entries = %v, want %v", kinds, want)
+	}
+
+	assertReconciles(t, ledger, path)
+}
+
+func TestLedger_Redeem_concurrentCheckouts(t *testing.T) {
+	ledger, path := openLedger(t)
+
+	if _, err := ledger.Activate("1111", 1000, "R000001"); err != nil {
+		t.Fatalf("Ledger.Activate() error = %v", err)
+	}
+
+	// 20 checkouts all try to spend £3 of the £10 card at the same time
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		redeemed currency.Pence
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ledger.Redeem(newTransaction(t, 300), "1111", 300, "till"); err == nil {
+				mu.Lock()
+				redeemed += 300
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if redeemed != 900 {
+		t.Errorf("redeemed %d across all checkouts, want %d", redeemed, 900)
+	}
+
+	if balance, _ := ledger.Balance("1111"); balance != 100 {
+		t.Errorf("balance = %d, want %d", balance, 100)
+	}
+
+	assertReconciles(t, ledger, path)
+}
+
+func TestOpenLedger_reopen(t *testing.T) {
+	ledger, path := openLedger(t)
+
+	if _, err := ledger.Activate("1111", 1000, "R000001"); err != nil {
+		t.Fatalf("Ledger.Activate() error = %v", err)
+	}
+	if _, err := ledger.Redeem(newTransaction(t, 400), "1111", 400, "R000002"); err != nil {
+		t.Fatalf("Ledger.Redeem() error = %v", err)
+	}
+	ledger.Close()
+
+	reopened, err := OpenLedger(path)
+	if err != nil {
+		t.Fatalf("OpenLedger() error = %v", err)
+	}
+	defer reopened.Close()
+
+	if balance, _ := reopened.Balance("1111"); balance != 600 {
+		t.Errorf("balance after reopening = %d, want %d", balance, 600)
+	}
+
+	// new entries carry on the sequence
+	entry, err := reopened.Redeem(newTransaction(t, 100), "1111", 100, "R000003")
+	if err != nil || entry.Seq != 3 || entry.Balance != 500 {
+		t.Errorf("Ledger.Redeem() = %+v, %v, want sequence 3 with 500 left", entry, err)
+	}
+}
+
+func TestOpenLedger_tampered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "giftcards.jsonl")
+
+	// someone has edited the balance of the redemption
+	data := `{"seq":1,"card":"1111","kind":"activate","amount":1000,"balance":1000}
+{"seq":2,"card":"1111","kind":"redeem","amount":-400,"balance":900}
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	var reconcileErr *ReconcileError
+	if _, err := OpenLedger(path); !errors.As(err, &reconcileErr) || reconcileErr.Seq != 2 {
+		t.Errorf("OpenLedger() error = %v, want a reconcile error at entry 2", err)
+	}
+}
+
+func TestOpenLedger_tornEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "giftcards.jsonl")
+
+	// the process crashed part way through writing the second entry
+	data := `{"seq":1,"card":"1111","kind":"activate","amount":1000,"balance":1000}
+{"seq":2,"card":"1111","kind":"red`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	ledger, err := OpenLedger(path)
+	if err != nil {
+		t.Fatalf("OpenLedger() error = %v", err)
+	}
+	defer ledger.Close()
+
+	if balance, _ := ledger.Balance("1111"); balance != 1000 {
+		t.Errorf("balance after the torn entry = %d, want %d", balance, 1000)
+	}
+
+	// the next write replaces the torn entry
+	entry, err := ledger.Redeem(newTransaction(t, 400), "1111", 400, "R000002")
+	if err != nil || entry.Seq != 2 || entry.Balance != 600 {
+		t.Errorf("Ledger.Redeem() = %+v, %v, want sequence 2 with 600 left", entry, err)
+	}
+
+	assertReconciles(t, ledger, path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open ledger file: %v", err)
+	}
+	defer file.Close()
+
+	if entries, err := ReadEntries(file); err != nil || len(entries) != 2 {
+		t.Errorf("ReadEntries() = %d entries, %v, want 2", len(entries), err)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	activate := Entry{Seq: 1, Card: "1111", Kind: KindActivate, Amount: 1000, Balance: 1000}
+
+	tests := []struct {
+		name    string
+		entries []Entry
+		want    map[string]currency.Pence
+		wantSeq int64
+	}{
+		{
+			name: "balances add up",
+			entries: []Entry{
+				activate,
+				{Seq: 2, Card: "2222", Kind: KindActivate, Amount: 500, Balance: 500},
+				{Seq: 3, Card: "1111", Kind: KindRedeem, Amount: -1000, Balance: 0},
+				{Seq: 4, Card: "1111", Kind: KindReversal, Amount: 250, Balance: 250},
+			},
+			want: map[string]currency.Pence{"1111": 250, "2222": 500},
+		},
+		{
+			name:    "gap in the sequence",
+			entries: []Entry{activate, {Seq: 3, Card: "1111", Kind: KindRedeem, Amount: -100, Balance: 900}},
+			wantSeq: 3,
+		},
+		{
+			name:    "activated twice",
+			entries: []Entry{activate, {Seq: 2, Card: "1111", Kind: KindActivate, Amount: 100, Balance: 1100}},
+			wantSeq: 2,
+		},
+		{
+			name:    "used before activation",
+			entries: []Entry{{Seq: 1, Card: "1111", Kind: KindRedeem, Amount: -100, Balance: -100}},
+			wantSeq: 1,
+		},
+		{
+			name:    "overspent",
+			entries: []Entry{activate, {Seq: 2, Card: "1111", Kind: KindRedeem, Amount: -1001, Balance: -1}},
+			wantSeq: 2,
+		},
+		{
+			name:    "redemption that credits the card",
+			entries: []Entry{activate, {Seq: 2, Card: "1111", Kind: KindRedeem, Amount: 100, Balance: 1100}},
+			wantSeq: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Reconcile(tt.entries)
+
+			if tt.wantSeq != 0 {
+				var reconcileErr *ReconcileError
+				if !errors.As(err, &reconcileErr) || reconcileErr.Seq != tt.wantSeq {
+					t.Fatalf("Reconcile() error = %v, want a reconcile error at entry %d", err, tt.wantSeq)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Reconcile() = %v, want %v", got, tt.want)
+			}
+			for card, balance := range tt.want {
+				if got[card] != balance {
+					t.Errorf("Reconcile() balance for %s = %d, want %d", card, got[card], balance)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+var (
+	ErrInsufficientStock  = errors.New("not enough stock")
+	ErrReservationUnknown = errors.New("reservation not found")
+	errNegativeStock      = errors.New("stock can't be negative")
+	errNoReservationID    = errors.New("a reservation id is required")
+)
+
+// Policy decides what happens when a checkout scans more than is in stock
+type Policy int
+
+const (
+	// the item is reserved anyway and the shortfall reported, the stock count is probably wrong rather than the customer
+	PolicyWarn Policy = iota
+	// the scan is refused with an *InsufficientStockError
+	PolicyError
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyWarn:
+		return "warn"
+	case PolicyError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// InsufficientStockError is returned by PolicyError when a reservation is more than the stock available
+type InsufficientStockError struct {
+	SKU       sku.SKU
+	Requested int
+	Available int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("sku %s has %d available, requested %d", e.SKU, e.Available, e.Requested)
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
+// Inventory holds the stock on hand for each sku and the stock reserved by checkouts which haven't finished yet.
+// Stock is reserved as items are scanned, committed when the sale completes and released when the basket is abandoned.
+// Operation is go-routine safe so one inventory can be shared by every checkout in a store.
+type Inventory struct {
+	mu     sync.Mutex
+	policy Policy
+	onHand map[sku.SKU]int
+	// total reserved for each sku across every reservation
+	reserved     map[sku.SKU]int
+	reservations map[string]map[sku.SKU]int
+}
+
+func New(policy Policy) *Inventory {
+	return &Inventory{
+		policy:       policy,
+		onHand:       make(map[sku.SKU]int),
+		reserved:     make(map[sku.SKU]int),
+		reservations: make(map[string]map[sku.SKU]int),
+	}
+}
+
+// SetStock sets the stock on hand for a sku e.g. after a stock count
+func (i *Inventory) SetStock(sku sku.SKU, quantity int) error {
+	if quantity < 0 {
+		return errNegativeStock
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.onHand[sku] = quantity
+	return nil
+}
+
+// Receive adds a delivery to the stock on hand
+func (i *Inventory) Receive(sku sku.SKU, quantity int) error {
+	if quantity < 0 {
+		return errNegativeStock
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.onHand[sku] += quantity
+	return nil
+}
+
+// OnHand is the stock in the store including anything reserved, it can be negative when PolicyWarn sold more than
+// was recorded
+func (i *Inventory) OnHand(sku sku.SKU) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.onHand[sku]
+}
+
+// Available is the stock on hand which hasn't been reserved by a checkout
+func (i *Inventory) Available(sku sku.SKU) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.available(sku)
+}
+
+func (i *Inventory) available(sku sku.SKU) int {
+	return i.onHand[sku] - i.reserved[sku]
+}
+
+// Reserve changes how much of a sku the reservation holds, a negative delta gives stock back e.g. an item removed from
+// the basket. The reservation is created by its first call.
+//
+// When the delta is more than is available PolicyError refuses it with an *InsufficientStockError and PolicyWarn
+// reserves it anyway and returns how many it was short by.
+func (i *Inventory) Reserve(reservation string, item sku.SKU, delta int) (shortfall int, err error) {
+	if reservation == "" {
+		return 0, errNoReservationID
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	held := i.reservations[reservation]
+
+	if delta < 0 && -delta > held[item] {
+		delta = -held[item]
+	}
+
+	if delta > 0 {
+		if available := i.available(item); delta > available {
+			if i.policy == PolicyError {
+				return 0, &InsufficientStockError{SKU: item, Requested: delta, Available: max(available, 0)}
+			}
+			shortfall = delta - max(available, 0)
+		}
+	}
+
+	if held == nil {
+		held = make(map[sku.SKU]int)
+		i.reservations[reservation] = held
+	}
+
+	held[item] += delta
+	i.reserved[item] += delta
+
+	return shortfall, nil
+}
+
+// Reserved is how much of a sku the reservation holds
+func (i *Inventory) Reserved(reservation string, sku sku.SKU) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.reservations[reservation][sku]
+}
+
+// Commit takes the reserved stock off the stock on hand once the sale is complete
+func (i *Inventory) Commit(reservation string) error {
+	return i.finish(reservation, true)
+}
+
+// Release gives the reserved stock back when a basket is abandoned
+func (i *Inventory) Release(reservation string) error {
+	return i.finish(reservation, false)
+}
+
+func (i *Inventory) finish(reservation string, sold bool) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	held, exists := i.reservations[reservation]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrReservationUnknown, reservation)
+	}
+
+	for s, qty := range held {
+		i.reserved[s] -= qty
+		if sold {
+			i.onHand[s] -= qty
+		}
+	}
+
+	delete(i.reservations, reservation)
+	return nil
+}
@@ -0,0 +1,177 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func skuGenerator(t *testing.T, r rune) sku.SKU {
+	s, err := sku.New(r)
+	if err != nil {
+		t.Fatalf("failed to make sku, input: %c, err: %v", r, err)
+	}
+	return s
+}
+
+func TestInventory_Reserve(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	tests := []struct {
+		name          string
+		policy        Policy
+		reserve       []int
+		wantShortfall int
+		wantErr       error
+		wantReserved  int
+		wantAvailable int
+	}{
+		{
+			name:          "within stock",
+			policy:        PolicyError,
+			reserve:       []int{3, 2},
+			wantReserved:  5,
+			wantAvailable: 5,
+		},
+		{
+			name:          "error policy refuses the reservation",
+			policy:        PolicyError,
+			reserve:       []int{8, 3},
+			wantErr:       ErrInsufficientStock,
+			wantReserved:  8,
+			wantAvailable: 2,
+		},
+		{
+			name:          "warn policy reserves anyway",
+			policy:        PolicyWarn,
+			reserve:       []int{8, 3},
+			wantShortfall: 1,
+			wantReserved:  11,
+			wantAvailable: -1,
+		},
+		{
+			name:          "negative deltas give stock back",
+			policy:        PolicyError,
+			reserve:       []int{4, -1},
+			wantReserved:  3,
+			wantAvailable: 7,
+		},
+		{
+			name:          "can't give back more than was reserved",
+			policy:        PolicyError,
+			reserve:       []int{2, -5},
+			wantReserved:  0,
+			wantAvailable: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := New(tt.policy)
+			if err := inv.SetStock(skuA, 10); err != nil {
+				t.Fatalf("Inventory.SetStock() error = %v", err)
+			}
+
+			var (
+				shortfall int
+				err       error
+			)
+			for _, delta := range tt.reserve {
+				shortfall, err = inv.Reserve("till-1", skuA, delta)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Inventory.Reserve() error = %v, want %v", err, tt.wantErr)
+			}
+			if shortfall != tt.wantShortfall {
+				t.Errorf("Inventory.Reserve() shortfall = %d, want %d", shortfall, tt.wantShortfall)
+			}
+			if got := inv.Reserved("till-1", skuA); got != tt.wantReserved {
+				t.Errorf("Inventory.Reserved() = %d, want %d", got, tt.wantReserved)
+			}
+			if got := inv.Available(skuA); got != tt.wantAvailable {
+				t.Errorf("Inventory.Available() = %d, want %d", got, tt.wantAvailable)
+			}
+		})
+	}
+}
+
+func TestInventory_CommitAndRelease(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	inv := New(PolicyError)
+	if err := inv.SetStock(skuA, 10); err != nil {
+		t.Fatalf("Inventory.SetStock() error = %v", err)
+	}
+
+	if _, err := inv.Reserve("sold", skuA, 3); err != nil {
+		t.Fatalf("Inventory.Reserve() error = %v", err)
+	}
+	if _, err := inv.Reserve("abandoned", skuA, 4); err != nil {
+		t.Fatalf("Inventory.Reserve() error = %v", err)
+	}
+
+	if err := inv.Commit("sold"); err != nil {
+		t.Fatalf("Inventory.Commit() error = %v", err)
+	}
+	if err := inv.Release("abandoned"); err != nil {
+		t.Fatalf("Inventory.Release() error = %v", err)
+	}
+
+	if got := inv.OnHand(skuA); got != 7 {
+		t.Errorf("Inventory.OnHand() = %d, want %d", got, 7)
+	}
+	if got := inv.Available(skuA); got != 7 {
+		t.Errorf("Inventory.Available() = %d, want %d", got, 7)
+	}
+
+	if err := inv.Commit("sold"); !errors.Is(err, ErrReservationUnknown) {
+		t.Errorf("committing twice error = %v, want %v", err, ErrReservationUnknown)
+	}
+}
+
+func TestInventory_concurrentCheckouts(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	inv := New(PolicyError)
+	if err := inv.SetStock(skuA, 50); err != nil {
+		t.Fatalf("Inventory.SetStock() error = %v", err)
+	}
+
+	// 20 checkouts each try to buy 5, only 10 of them can
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		sold int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reservation := fmt.Sprintf("till-%d", i)
+
+			for n := 0; n < 5; n++ {
+				if _, err := inv.Reserve(reservation, skuA, 1); err != nil {
+					inv.Release(reservation)
+					return
+				}
+			}
+
+			if err := inv.Commit(reservation); err == nil {
+				mu.Lock()
+				sold += 5
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := inv.OnHand(skuA); got != 50-sold || got < 0 {
+		t.Errorf("Inventory.OnHand() = %d after selling %d of 50", got, sold)
+	}
+	if got := inv.Available(skuA); got != inv.OnHand(skuA) {
+		t.Errorf("Inventory.Available() = %d, want everything reserved to be committed or released", got)
+	}
+}
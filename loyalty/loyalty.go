@@ -0,0 +1,81 @@
+package loyalty
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+)
+
+var (
+	ErrAccountNotFound    = errors.New("loyalty account not found")
+	ErrStatementNotFound  = errors.New("no points statement for the transaction")
+	ErrAccountExists      = errors.New("a loyalty account already exists for the card")
+	ErrInsufficientPoints = errors.New("not enough points in the account")
+	ErrInvalidPoints      = errors.New("points must be greater than zero")
+	ErrAlreadyEarned      = errors.New("points have already been earned for the transaction")
+	errNoCardNumber       = errors.New("a card number is required")
+	errNoTransactionID    = errors.New("a transaction id is required")
+)
+
+// Account is a customer's loyalty card and their points balance
+type Account struct {
+	CardNumber string `json:"card_number"`
+	Name       string `json:"name,omitempty"`
+	Points     int    `json:"points"`
+}
+
+// Scheme is how points are earned and what they are worth
+type Scheme struct {
+	// points earned for every whole pound spent
+	PointsPerPound int
+	// what a single point is worth when it is redeemed
+	PointValue currency.Pence
+}
+
+// DefaultScheme earns 1 point per pound and each point is worth a penny
+var DefaultScheme = Scheme{PointsPerPound: 1, PointValue: 1}
+
+// Earned is the points earned for spending the total, part pounds don't earn anything
+func (s Scheme) Earned(total currency.Pence) int {
+	if total <= 0 {
+		return 0
+	}
+	return int(total/100) * s.PointsPerPound
+}
+
+// Value is what the points are worth when redeemed
+func (s Scheme) Value(points int) currency.Pence {
+	return currency.Pence(points) * s.PointValue
+}
+
+// Statement is what happened to a card's points in a single transaction
+type Statement struct {
+	TransactionID string `json:"transaction_id"`
+	CardNumber    string `json:"card_number"`
+	// balance before the transaction
+	Opening int `json:"opening"`
+	// the final total points were earned on
+	EarnedOn currency.Pence `json:"earned_on,omitempty"`
+	Earned   int            `json:"earned"`
+	Redeemed int            `json:"redeemed"`
+	// balance after the transaction
+	Closing int `json:"closing"`
+}
+
+func (s Statement) String() string {
+	return fmt.Sprintf("card %s: %d points, earned %d, redeemed %d, balance %d", MaskCard(s.CardNumber), s.Opening, s.Earned, s.Redeemed, s.Closing)
+}
+
+// MaskCard hides all but the last 4 digits of a card number so it can be printed
+func MaskCard(card string) string {
+	if len(card) <= 4 {
+		return card
+	}
+	masked := make([]byte, len(card))
+	for i := range masked {
+		masked[i] = '*'
+	}
+	copy(masked[len(card)-4:], card[len(card)-4:])
+	return string(masked)
+}
@@ -0,0 +1,258 @@
+package loyalty
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/payment"
+)
+
+// Tenderer takes payment, a payment.Transaction satisfies this
+type Tenderer interface {
+	Tender(tender payment.Tender) error
+}
+
+// FileStore keeps loyalty accounts and points statements in a local json file, operation is go-routine safe
+// every change is written to disk before it returns
+type FileStore struct {
+	mu         sync.Mutex
+	path       string
+	scheme     Scheme
+	accounts   map[string]Account
+	statements map[string]Statement
+}
+
+// the json file the store is saved as
+type storeFile struct {
+	Accounts   []Account   `json:"accounts"`
+	Statements []Statement `json:"statements"`
+}
+
+// opens the store saved at the path, a new store is created when the file doesn't exist yet
+func NewFileStore(path string, scheme Scheme) (*FileStore, error) {
+	s := &FileStore{
+		path:       path,
+		scheme:     scheme,
+		accounts:   make(map[string]Account),
+		statements: make(map[string]Statement),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var contents storeFile
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return nil, fmt.Errorf("failed to read loyalty store %s: %w", path, err)
+	}
+
+	for _, account := range contents.Accounts {
+		s.accounts[account.CardNumber] = account
+	}
+	for _, statement := range contents.Statements {
+		s.statements[statement.TransactionID] = statement
+	}
+
+	return s, nil
+}
+
+// writes everything to disk, write then rename so the file is never left half written
+func (s *FileStore) save() error {
+	contents := storeFile{Accounts: make([]Account, 0, len(s.accounts)), Statements: make([]Statement, 0, len(s.statements))}
+	for _, account := range s.accounts {
+		contents.Accounts = append(contents.Accounts, account)
+	}
+	for _, statement := range s.statements {
+		contents.Statements = append(contents.Statements, statement)
+	}
+
+	sort.Slice(contents.Accounts, func(i, j int) bool { return contents.Accounts[i].CardNumber < contents.Accounts[j].CardNumber })
+	sort.Slice(contents.Statements, func(i, j int) bool {
+		return contents.Statements[i].TransactionID < contents.Statements[j].TransactionID
+	})
+
+	data, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// update changes an account and its statement for a transaction then saves them, nothing is changed if saving fails
+func (s *FileStore) update(account Account, statement Statement) error {
+	previousAccount := s.accounts[account.CardNumber]
+	previousStatement, hadStatement := s.statements[statement.TransactionID]
+
+	s.accounts[account.CardNumber] = account
+	s.statements[statement.TransactionID] = statement
+
+	if err := s.save(); err != nil {
+		s.accounts[account.CardNumber] = previousAccount
+		if hadStatement {
+			s.statements[statement.TransactionID] = previousStatement
+		} else {
+			delete(s.statements, statement.TransactionID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// the statement for a transaction, a new one starts from the account's current balance
+func (s *FileStore) statement(account Account, transactionID string) (Statement, error) {
+	statement, exists := s.statements[transactionID]
+	if !exists {
+		return Statement{TransactionID: transactionID, CardNumber: account.CardNumber, Opening: account.Points, Closing: account.Points}, nil
+	}
+	if statement.CardNumber != account.CardNumber {
+		return Statement{}, fmt.Errorf("transaction %s already belongs to card %s", transactionID, MaskCard(statement.CardNumber))
+	}
+	return statement, nil
+}
+
+func (s *FileStore) lookup(card, transactionID string) (Account, Statement, error) {
+	if transactionID == "" {
+		return Account{}, Statement{}, errNoTransactionID
+	}
+
+	account, found := s.accounts[card]
+	if !found {
+		return Account{}, Statement{}, ErrAccountNotFound
+	}
+
+	statement, err := s.statement(account, transactionID)
+	return account, statement, err
+}
+
+// Open creates a new account for a card with no points
+func (s *FileStore) Open(card, name string) (Account, error) {
+	if card == "" {
+		return Account{}, errNoCardNumber
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.accounts[card]; exists {
+		return Account{}, ErrAccountExists
+	}
+
+	account := Account{CardNumber: card, Name: name}
+	s.accounts[card] = account
+
+	if err := s.save(); err != nil {
+		delete(s.accounts, card)
+		return Account{}, err
+	}
+
+	return account, nil
+}
+
+// Account looks up the account for a card
+func (s *FileStore) Account(card string) (Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, found := s.accounts[card]
+	if !found {
+		return Account{}, ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// Earn adds the points for a transaction's final total, points can only be earned once per transaction
+func (s *FileStore) Earn(card, transactionID string, total currency.Pence) (Statement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, statement, err := s.lookup(card, transactionID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	if statement.EarnedOn > 0 || statement.Earned > 0 {
+		return Statement{}, ErrAlreadyEarned
+	}
+
+	earned := s.scheme.Earned(total)
+	account.Points += earned
+	statement.EarnedOn = total
+	statement.Earned = earned
+	statement.Closing = account.Points
+
+	if err := s.update(account, statement); err != nil {
+		return Statement{}, err
+	}
+
+	return statement, nil
+}
+
+// Redeem spends points as a tender against the transaction. The points are only taken when the tender is accepted,
+// a tender for more than is left to pay is refused like any other non cash tender.
+func (s *FileStore) Redeem(tx Tenderer, card, transactionID string, points int) (Statement, error) {
+	if points <= 0 {
+		return Statement{}, ErrInvalidPoints
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, statement, err := s.lookup(card, transactionID)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	if points > account.Points {
+		return Statement{}, fmt.Errorf("%w: redeeming %d but only %d available", ErrInsufficientPoints, points, account.Points)
+	}
+
+	previousAccount, previousStatement := account, statement
+
+	account.Points -= points
+	statement.Redeemed += points
+	statement.Closing = account.Points
+
+	// save before tendering, if the tender is refused the points are given back
+	if err := s.update(account, statement); err != nil {
+		return Statement{}, err
+	}
+
+	tender := payment.Tender{Method: payment.Points, Amount: s.scheme.Value(points), Reference: MaskCard(card)}
+	if err := tx.Tender(tender); err != nil {
+		return Statement{}, errors.Join(err, s.update(previousAccount, previousStatement))
+	}
+
+	return statement, nil
+}
+
+// Statement returns the points statement for a transaction
+func (s *FileStore) Statement(transactionID string) (Statement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statement, found := s.statements[transactionID]
+	if !found {
+		return Statement{}, ErrStatementNotFound
+	}
+	return statement, nil
+}
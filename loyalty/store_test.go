@@ -0,0 +1,183 @@
+package loyalty
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/payment"
+)
+
+const card = "6331100012345678"
+
+// creates a store with one account holding the given points
+func newStore(t *testing.T, points int) (*FileStore, string) {
+	path := filepath.Join(t.TempDir(), "accounts.json")
+
+	store, err := NewFileStore(path, DefaultScheme)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	if _, err := store.Open(card, "Test Customer"); err != nil {
+		t.Fatalf("FileStore.Open() error = %v", err)
+	}
+
+	if points > 0 {
+		if _, err := store.Earn(card, "opening", currency.Pence(points*100)); err != nil {
+			t.Fatalf("FileStore.Earn() error = %v", err)
+		}
+	}
+
+	return store, path
+}
+
+func TestScheme_Earned(t *testing.T) {
+	tests := []struct {
+		total currency.Pence
+		want  int
+	}{
+		{total: 0, want: 0},
+		{total: 99, want: 0},
+		{total: 100, want: 1},
+		{total: 1099, want: 10},
+		{total: -500, want: 0},
+	}
+	for _, tt := range tests {
+		if got := DefaultScheme.Earned(tt.total); got != tt.want {
+			t.Errorf("Scheme.Earned(%d) = %d, want %d", tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestFileStore_Earn(t *testing.T) {
+	store, path := newStore(t, 100)
+
+	statement, err := store.Earn(card, "R000001", 1250)
+	if err != nil {
+		t.Fatalf("FileStore.Earn() error = %v", err)
+	}
+
+	want := Statement{TransactionID: "R000001", CardNumber: card, Opening: 100, EarnedOn: 1250, Earned: 12, Closing: 112}
+	if statement != want {
+		t.Errorf("FileStore.Earn() = %+v, want %+v", statement, want)
+	}
+
+	if _, err := store.Earn(card, "R000001", 1250); !errors.Is(err, ErrAlreadyEarned) {
+		t.Errorf("earning twice error = %v, want %v", err, ErrAlreadyEarned)
+	}
+
+	if _, err := store.Earn("unknown", "R000002", 1250); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("unknown card error = %v, want %v", err, ErrAccountNotFound)
+	}
+
+	// everything survives reopening the file
+	reopened, err := NewFileStore(path, DefaultScheme)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	account, err := reopened.Account(card)
+	if err != nil || account.Points != 112 {
+		t.Errorf("reopened account = %+v, %v, want 112 points", account, err)
+	}
+
+	if got, err := reopened.Statement("R000001"); err != nil || got != want {
+		t.Errorf("reopened statement = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func TestFileStore_Redeem(t *testing.T) {
+	store, _ := newStore(t, 500)
+
+	tx, err := payment.NewTransaction(1000)
+	if err != nil {
+		t.Fatalf("payment.NewTransaction() error = %v", err)
+	}
+
+	statement, err := store.Redeem(tx, card, "R000001", 300)
+	if err != nil {
+		t.Fatalf("FileStore.Redeem() error = %v", err)
+	}
+
+	if statement.Redeemed != 300 || statement.Closing != 200 {
+		t.Errorf("FileStore.Redeem() = %+v, want 300 redeemed and 200 left", statement)
+	}
+
+	if remaining := tx.Remaining(); remaining != 700 {
+		t.Errorf("transaction remaining = %d, want %d", remaining, 700)
+	}
+
+	if _, err := store.Redeem(tx, card, "R000001", 201); !errors.Is(err, ErrInsufficientPoints) {
+		t.Errorf("redeeming too many points error = %v, want %v", err, ErrInsufficientPoints)
+	}
+
+	if _, err := store.Redeem(tx, card, "R000001", 0); !errors.Is(err, ErrInvalidPoints) {
+		t.Errorf("redeeming no points error = %v, want %v", err, ErrInvalidPoints)
+	}
+
+	// pay the rest by card then earn on the final total
+	if err := tx.Tender(payment.Tender{Method: payment.Card, Amount: 700}); err != nil {
+		t.Fatalf("Transaction.Tender() error = %v", err)
+	}
+
+	settlement, err := tx.Complete()
+	if err != nil {
+		t.Fatalf("Transaction.Complete() error = %v", err)
+	}
+
+	if settlement.Tenders[0].Method != payment.Points || settlement.Tenders[0].Amount != 300 {
+		t.Errorf("points tender = %+v", settlement.Tenders[0])
+	}
+
+	statement, err = store.Earn(card, "R000001", settlement.Total)
+	if err != nil {
+		t.Fatalf("FileStore.Earn() error = %v", err)
+	}
+
+	want := Statement{TransactionID: "R000001", CardNumber: card, Opening: 500, EarnedOn: 1000, Earned: 10, Redeemed: 300, Closing: 210}
+	if statement != want {
+		t.Errorf("statement = %+v, want %+v", statement, want)
+	}
+}
+
+func TestFileStore_Redeem_refusedTender(t *testing.T) {
+	store, _ := newStore(t, 500)
+
+	tx, err := payment.NewTransaction(100)
+	if err != nil {
+		t.Fatalf("payment.NewTransaction() error = %v", err)
+	}
+
+	// points can't be overpaid like cash so the points are given back
+	if _, err := store.Redeem(tx, card, "R000001", 200); !errors.Is(err, payment.ErrOverpayment) {
+		t.Fatalf("FileStore.Redeem() error = %v, want %v", err, payment.ErrOverpayment)
+	}
+
+	account, err := store.Account(card)
+	if err != nil || account.Points != 500 {
+		t.Errorf("account after refused tender = %+v, %v, want 500 points", account, err)
+	}
+}
+
+func TestFileStore_Open(t *testing.T) {
+	store, _ := newStore(t, 0)
+
+	if _, err := store.Open(card, "Someone else"); !errors.Is(err, ErrAccountExists) {
+		t.Errorf("FileStore.Open() error = %v, want %v", err, ErrAccountExists)
+	}
+
+	if _, err := store.Open("", "Nobody"); !errors.Is(err, errNoCardNumber) {
+		t.Errorf("FileStore.Open() error = %v, want %v", err, errNoCardNumber)
+	}
+}
+
+func TestMaskCard(t *testing.T) {
+	if got := MaskCard(card); got != "************5678" {
+		t.Errorf("MaskCard() = %s", got)
+	}
+	if got := MaskCard("123"); got != "123" {
+		t.Errorf("MaskCard() = %s", got)
+	}
+}
@@ -1,21 +1,65 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
 
 	"strings"
+	"time"
 
+	"github.com/Joshswooft/thinkmoney-test/catalog"
 	"github.com/Joshswooft/thinkmoney-test/checkout"
+	"github.com/Joshswooft/thinkmoney-test/experiment"
+	"github.com/Joshswooft/thinkmoney-test/loyalty"
+	"github.com/Joshswooft/thinkmoney-test/metrics"
 	"github.com/Joshswooft/thinkmoney-test/pricing"
 	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/receipt"
+	"github.com/Joshswooft/thinkmoney-test/rules"
+	"github.com/Joshswooft/thinkmoney-test/script"
 	"github.com/Joshswooft/thinkmoney-test/sku"
 )
 
 func main() {
+	metricsAddr := flag.String("metrics-addr", "", "serve prometheus metrics on this address e.g. :9090, keeps the process running")
+	jsonOutput := flag.Bool("json", false, "print the receipt as json")
+	orderFlag := flag.String("order", "sku", "order of the receipt lines: sku or scan")
+	catalogPath := flag.String("catalog", "", "json file of products used for names on the receipt")
+	loyaltyPath := flag.String("loyalty", "loyalty.json", "json file the loyalty accounts are kept in")
+	card := flag.String("card", "", "loyalty card to scan, the account must already exist in the loyalty file")
+	scriptsPath := flag.String("scripts", "", "json file of pricing scripts for skus the normal pricing can't express e.g. script/testdata/scripts.json")
+	trialPricesPath := flag.String("trial-prices", "", "json price list to trial against the current prices on half of the sessions")
+	session := flag.String("session", "", "checkout session id used to pick the price experiment variant, random when not given")
+	experimentJournalPath := flag.String("experiment-journal", "experiments.jsonl", "file the outcome of each experiment session is appended to")
+	rulesPath := flag.String("rules", "", "pricing rules file to use instead of the built in prices e.g. rules/testdata/prices.rules")
+	flag.Parse()
+
+	itemOrder := checkout.BySKU
+	switch *orderFlag {
+	case "sku":
+	case "scan":
+		itemOrder = checkout.ByScanOrder
+	default:
+		log.Fatalf("unknown order %q, expected sku or scan", *orderFlag)
+	}
+
+	registry := metrics.NewRegistry()
+	checkout.DescribeMetrics(registry)
+
 	input := "a69B$42*0(Cdb"
-	fmt.Println("input: ", input)
-	scanner, err := checkout.NewSkuScanner(strings.NewReader(input))
+	if !*jsonOutput {
+		fmt.Println("input: ", input)
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	scanner, err := checkout.NewSkuScanner(strings.NewReader(input), checkout.WithScannerLogger(logger))
 
 	if err != nil {
 		log.Fatal(err)
@@ -29,23 +73,170 @@ func main() {
 		Config: map[sku.SKU]pricing.PricingData{
 			skuA: {UnitPrice: 10},
 			skuB: {UnitPrice: 20, SpecialPrice: 10, SpecialQuantity: *quantity.New(2)},
-			skuC: {UnitPrice: 50, SpecialPrice: 30, SpecialQuantity: *quantity.New(5)},
+			skuC: {UnitPrice: 50, SpecialPrice: 30, SpecialQuantity: *quantity.New(5), MemberPrice: 40},
 		},
 	}
 
+	opts := []checkout.Option{checkout.WithLogger(logger), checkout.WithMetrics(registry), checkout.WithItemOrder(itemOrder)}
+
+	if *rulesPath != "" {
+		src, err := os.ReadFile(*rulesPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		compiled, err := rules.Compile(string(src), time.Now())
+		if err != nil {
+			log.Fatalf("%s:\n%v", *rulesPath, err)
+		}
+		for _, warning := range compiled.Warnings {
+			logger.Warn("pricing rule warning", slog.String("file", *rulesPath), slog.String("position", warning.Pos.String()), slog.String("message", warning.Msg))
+		}
+		pricingRules = *compiled.Pricing
+		opts = append(opts, checkout.WithSpendThresholds(compiled.Thresholds...))
+	}
+
+	var checks []pricing.ValidateOption
+
+	if *catalogPath != "" {
+		products, err := catalog.LoadFile(*catalogPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, checkout.WithCatalog(products))
+		checks = append(checks, pricing.CheckCatalog(products))
+	}
+
+	problems := pricing.Validate(&pricingRules, checks...)
+	for _, problem := range problems {
+		logger.Warn("pricing problem", slog.String("sku", problem.SKU.String()), slog.String("severity", problem.Severity.String()), slog.String("code", problem.Code), slog.String("message", problem.Message))
+	}
+	if err := problems.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	var accounts *loyalty.FileStore
+	if *card != "" {
+		accounts, err = loyalty.NewFileStore(*loyaltyPath, loyalty.DefaultScheme)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, checkout.WithLoyalty(accounts))
+	}
+
 	basket := checkout.NewBasket()
-	ch, err := checkout.NewCheckout(&pricingRules, basket, scanner)
+
+	var checkoutPricing checkout.PricingRules = &pricingRules
+	if *scriptsPath != "" {
+		scripts, err := script.LoadFile(*scriptsPath, script.DefaultLimits)
+		if err != nil {
+			log.Fatal(err)
+		}
+		checkoutPricing = script.NewPricing(checkoutPricing, scripts, script.WithLogger(logger))
+	}
+
+	var (
+		trial             *experiment.Session
+		experimentJournal *experiment.Journal
+	)
+	if *trialPricesPath != "" {
+		trialPrices, err := pricing.LoadFile(*trialPricesPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		priceTrial, err := experiment.New("price-trial",
+			experiment.Variant{Name: "control", Weight: 50, Pricing: checkoutPricing},
+			experiment.Variant{Name: "trial", Weight: 50, Pricing: trialPrices},
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if *session == "" {
+			*session = fmt.Sprintf("%d", time.Now().UnixNano())
+		}
+		trial, err = priceTrial.ForSession(*session)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		experimentJournal, err = experiment.OpenJournal(*experimentJournalPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer experimentJournal.Close()
+
+		checkoutPricing = trial
+		opts = append(opts, checkout.WithExperimentOutcomes(experimentJournal.ForSession(trial)))
+	}
+
+	ch, err := checkout.NewCheckout(checkoutPricing, basket, scanner, opts...)
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if *card != "" {
+		if err := ch.ScanCard(*card); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	if err := ch.ScanItems(); err != nil {
+		if err := ch.Abandon(); err != nil {
+			logger.Error("failed to abandon the checkout", slog.Any("error", err))
+		}
+		log.Fatal(err)
+	}
+
+	sale := receipt.NewStore().IssueSale(ch.Sale())
+
+	if err := ch.Complete(); err != nil {
 		log.Fatal(err)
 	}
 
-	total := ch.GetTotalPrice()
+	var statement *loyalty.Statement
+	if *card != "" {
+		// receipt ids start again every run so points are earned on an id which is unique to this transaction
+		earned, err := accounts.Earn(*card, newTransactionID(), sale.Total)
+		if err != nil {
+			log.Fatal(err)
+		}
+		statement = &earned
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(sale); err != nil {
+			log.Fatal(err)
+		}
+		if statement != nil {
+			logger.Info("points statement", slog.Any("statement", *statement))
+		}
+	} else {
+		if err := sale.Format(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("total is: %d pence \n", sale.Total)
+		if statement != nil {
+			fmt.Println(statement)
+		}
+	}
+
+	if *metricsAddr != "" {
+		http.Handle("/metrics", metrics.Handler(registry))
+		logger.Info("serving metrics", slog.String("addr", *metricsAddr))
+		log.Fatal(http.ListenAndServe(*metricsAddr, nil))
+	}
 
-	fmt.Printf("total is: %d pence \n", total)
+}
 
+// newTransactionID is a random id for this run of the checkout
+func newTransactionID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		log.Fatal(err)
+	}
+	return hex.EncodeToString(id)
 }
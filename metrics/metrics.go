@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+)
+
+// Recorder is the small interface the rest of the application uses to record metrics
+// so we aren't tied to a particular metrics library
+type Recorder interface {
+	// adds one to the named counter
+	IncCounter(name string, labels ...Label)
+	// adds the value to the named counter, counters only go up so negative values are ignored
+	AddCounter(name string, value float64, labels ...Label)
+	// records a single observation in the named histogram
+	Observe(name string, value float64, labels ...Label)
+}
+
+// Label is a name/value pair which splits a metric into separate series
+type Label struct {
+	Name  string
+	Value string
+}
+
+// L is shorthand for creating a label
+func L(name, value string) Label {
+	return Label{Name: name, Value: value}
+}
+
+// Discard is a recorder which throws everything away
+var Discard Recorder = discard{}
+
+type discard struct{}
+
+func (discard) IncCounter(string, ...Label)          {}
+func (discard) AddCounter(string, float64, ...Label) {}
+func (discard) Observe(string, float64, ...Label)    {}
+
+// sorts the labels by name so the same set of labels always produces the same series
+func sortLabels(labels []Label) []Label {
+	sorted := make([]Label, len(labels))
+	copy(sorted, labels)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// unique key for a series, labels must already be sorted
+func seriesKey(name string, labels []Label) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, l := range labels {
+		b.WriteByte(0)
+		b.WriteString(l.Name)
+		b.WriteByte(0)
+		b.WriteString(l.Value)
+	}
+	return b.String()
+}
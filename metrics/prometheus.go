@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Handler serves the registry in the prometheus text exposition format, mount it on /metrics
+func Handler(r *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := WritePrometheus(w, r); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// WritePrometheus writes every metric in the registry in the prometheus text exposition format
+// metrics and series are sorted so the output is stable
+func WritePrometheus(w io.Writer, r *Registry) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := bufio.NewWriter(w)
+
+	counters := make(map[string][]*counter)
+	for _, c := range r.counters {
+		counters[c.name] = append(counters[c.name], c)
+	}
+
+	for _, name := range sortedKeys(counters) {
+		series := counters[name]
+		sort.Slice(series, func(i, j int) bool { return formatLabels(series[i].labels) < formatLabels(series[j].labels) })
+
+		r.writeHeader(out, name, "counter")
+		for _, c := range series {
+			fmt.Fprintf(out, "%s%s %s\n", name, formatLabels(c.labels), formatFloat(c.value))
+		}
+	}
+
+	histograms := make(map[string][]*histogram)
+	for _, h := range r.histograms {
+		histograms[h.name] = append(histograms[h.name], h)
+	}
+
+	for _, name := range sortedKeys(histograms) {
+		series := histograms[name]
+		sort.Slice(series, func(i, j int) bool { return formatLabels(series[i].labels) < formatLabels(series[j].labels) })
+
+		r.writeHeader(out, name, "histogram")
+		for _, h := range series {
+			for i, upper := range h.buckets {
+				labels := append(append([]Label{}, h.labels...), L("le", formatFloat(upper)))
+				fmt.Fprintf(out, "%s_bucket%s %d\n", name, formatLabels(labels), h.counts[i])
+			}
+			labels := append(append([]Label{}, h.labels...), L("le", "+Inf"))
+			fmt.Fprintf(out, "%s_bucket%s %d\n", name, formatLabels(labels), h.count)
+			fmt.Fprintf(out, "%s_sum%s %s\n", name, formatLabels(h.labels), formatFloat(h.sum))
+			fmt.Fprintf(out, "%s_count%s %d\n", name, formatLabels(h.labels), h.count)
+		}
+	}
+
+	return out.Flush()
+}
+
+func (r *Registry) writeHeader(w io.Writer, name, kind string) {
+	if help, exists := r.help[name]; exists {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(help))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = l.Name + `="` + labelEscaper.Replace(l.Value) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
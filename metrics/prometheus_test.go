@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.Describe("scans_total", "Number of scans.")
+	r.SetBuckets("latency_seconds", []float64{0.1, 1})
+
+	r.IncCounter("scans_total", L("kind", "ok"))
+	r.IncCounter("scans_total", L("kind", `bad "quote"`))
+	r.Observe("latency_seconds", 0.5)
+
+	server := httptest.NewServer(Handler(r))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("failed to get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+
+	want := `# HELP scans_total Number of scans.
+# TYPE scans_total counter
+scans_total{kind="bad \"quote\""} 1
+scans_total{kind="ok"} 1
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 0
+latency_seconds_bucket{le="1"} 1
+latency_seconds_bucket{le="+Inf"} 1
+latency_seconds_sum 0.5
+latency_seconds_count 1
+`
+
+	if string(body) != want {
+		t.Errorf("unexpected exposition output\ngot:\n%s\nwant:\n%s", body, want)
+	}
+
+	if got := resp.Header.Get("Content-Type"); got != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("unexpected content type: %s", got)
+	}
+}
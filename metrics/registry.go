@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+)
+
+// DefaultBuckets are the histogram bucket upper bounds used when none have been configured for a metric
+var DefaultBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 50, 100}
+
+type counter struct {
+	name   string
+	labels []Label
+	value  float64
+}
+
+type histogram struct {
+	name    string
+	labels  []Label
+	buckets []float64
+	// counts[i] is the number of observations <= buckets[i]
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// HistogramSnapshot is a copy of a histogram's state at a point in time
+type HistogramSnapshot struct {
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+}
+
+// Registry is an in-memory Recorder, it is go-routine safe
+// use it in tests to assert on what was recorded or export it with the prometheus handler
+type Registry struct {
+	mu         sync.RWMutex
+	counters   map[string]*counter
+	histograms map[string]*histogram
+	buckets    map[string][]float64
+	help       map[string]string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		counters:   make(map[string]*counter),
+		histograms: make(map[string]*histogram),
+		buckets:    make(map[string][]float64),
+		help:       make(map[string]string),
+	}
+}
+
+// SetBuckets configures the bucket upper bounds for a histogram, it must be called before the first observation
+func (r *Registry) SetBuckets(name string, buckets []float64) {
+	sorted := make([]float64, len(buckets))
+	copy(sorted, buckets)
+	sort.Float64s(sorted)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buckets[name] = sorted
+}
+
+// Describe sets the help text shown for the metric when exported
+func (r *Registry) Describe(name, help string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.help[name] = help
+}
+
+func (r *Registry) IncCounter(name string, labels ...Label) {
+	r.AddCounter(name, 1, labels...)
+}
+
+func (r *Registry) AddCounter(name string, value float64, labels ...Label) {
+	if value < 0 {
+		return
+	}
+
+	labels = sortLabels(labels)
+	key := seriesKey(name, labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.counters[key]
+	if !exists {
+		c = &counter{name: name, labels: labels}
+		r.counters[key] = c
+	}
+	c.value += value
+}
+
+func (r *Registry) Observe(name string, value float64, labels ...Label) {
+	labels = sortLabels(labels)
+	key := seriesKey(name, labels)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, exists := r.histograms[key]
+	if !exists {
+		buckets, configured := r.buckets[name]
+		if !configured {
+			buckets = DefaultBuckets
+		}
+		h = &histogram{name: name, labels: labels, buckets: buckets, counts: make([]uint64, len(buckets))}
+		r.histograms[key] = h
+	}
+
+	for i, upper := range h.buckets {
+		if value <= upper {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// Counter returns the current value of a counter, zero if it has never been incremented
+func (r *Registry) Counter(name string, labels ...Label) float64 {
+	key := seriesKey(name, sortLabels(labels))
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if c, exists := r.counters[key]; exists {
+		return c.value
+	}
+	return 0
+}
+
+// Histogram returns a snapshot of a histogram, the snapshot is empty if nothing has been observed
+func (r *Registry) Histogram(name string, labels ...Label) HistogramSnapshot {
+	key := seriesKey(name, sortLabels(labels))
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	h, exists := r.histograms[key]
+	if !exists {
+		return HistogramSnapshot{}
+	}
+
+	return h.snapshot()
+}
+
+func (h *histogram) snapshot() HistogramSnapshot {
+	counts := make([]uint64, len(h.counts))
+	copy(counts, h.counts)
+	return HistogramSnapshot{Buckets: h.buckets, Counts: counts, Count: h.count, Sum: h.sum}
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegistry_IncCounter(t *testing.T) {
+	r := NewRegistry()
+
+	r.IncCounter("scans_total")
+	r.IncCounter("scans_total")
+	r.IncCounter("errors_total", L("kind", "read"), L("source", "file"))
+	// label order shouldn't matter
+	r.IncCounter("errors_total", L("source", "file"), L("kind", "read"))
+
+	tests := []struct {
+		name   string
+		metric string
+		labels []Label
+		want   float64
+	}{
+		{name: "counts each increment", metric: "scans_total", want: 2},
+		{name: "labels in any order are the same series", metric: "errors_total", labels: []Label{L("kind", "read"), L("source", "file")}, want: 2},
+		{name: "different labels are a different series", metric: "errors_total", labels: []Label{L("kind", "basket")}, want: 0},
+		{name: "unknown counters are zero", metric: "missing_total", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Counter(tt.metric, tt.labels...); got != tt.want {
+				t.Errorf("Registry.Counter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_AddCounter(t *testing.T) {
+	r := NewRegistry()
+
+	r.AddCounter("items_total", 3)
+	r.IncCounter("items_total")
+	// counters never go down
+	r.AddCounter("items_total", -2)
+
+	if got := r.Counter("items_total"); got != 4 {
+		t.Errorf("Registry.Counter() = %v, want %v", got, 4)
+	}
+}
+
+func TestRegistry_Observe(t *testing.T) {
+	r := NewRegistry()
+	r.SetBuckets("basket_size", []float64{10, 1, 5})
+
+	for _, v := range []float64{1, 3, 7, 20} {
+		r.Observe("basket_size", v)
+	}
+
+	want := HistogramSnapshot{
+		Buckets: []float64{1, 5, 10},
+		Counts:  []uint64{1, 2, 3},
+		Count:   4,
+		Sum:     31,
+	}
+
+	if got := r.Histogram("basket_size"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Registry.Histogram() = %+v, want %+v", got, want)
+	}
+
+	if got := r.Histogram("missing"); !reflect.DeepEqual(got, HistogramSnapshot{}) {
+		t.Errorf("expected an empty snapshot for an unknown histogram, got %+v", got)
+	}
+}
@@ -0,0 +1,180 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+)
+
+var ErrCannotMakeChange = errors.New("change can't be made from the available denominations")
+
+// UKDenominations are the sterling notes and coins in pence
+var UKDenominations = []currency.Pence{5000, 2000, 1000, 500, 200, 100, 50, 20, 10, 5, 2, 1}
+
+// Denomination is a note or coin and how many of them to hand over
+type Denomination struct {
+	Value currency.Pence
+	Count int
+}
+
+// Breakdown of change, largest denomination first
+type Breakdown []Denomination
+
+// Total value of the breakdown
+func (b Breakdown) Total() currency.Pence {
+	total := currency.Pence(0)
+	for _, d := range b {
+		total += d.Value * currency.Pence(d.Count)
+	}
+	return total
+}
+
+// Pieces is the number of notes and coins in the breakdown
+func (b Breakdown) Pieces() int {
+	pieces := 0
+	for _, d := range b {
+		pieces += d.Count
+	}
+	return pieces
+}
+
+// the most change worked out with dynamic programming, which takes memory in proportion to the amount.
+// Canonical denominations such as sterling don't need it.
+const maxSearchedChange currency.Pence = 100_000
+
+// MakeChange works out the fewest notes and coins which add up to the amount.
+// Greedy (largest first) is optimal for canonical denominations like sterling and is used for them, for anything else
+// dynamic programming finds the optimal breakdown for amounts up to £1000.
+func MakeChange(amount currency.Pence, denominations []currency.Pence) (Breakdown, error) {
+	if amount < 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	if amount == 0 {
+		return Breakdown{}, nil
+	}
+
+	if canonical(denominations) {
+		return greedyChange(amount, denominations)
+	}
+
+	if amount > maxSearchedChange {
+		return nil, fmt.Errorf("%w: %d is too much change to work out with these denominations", ErrCannotMakeChange, amount)
+	}
+
+	fewest, last := searchChange(amount, denominations)
+	if fewest[amount] < 0 {
+		return nil, ErrCannotMakeChange
+	}
+
+	counts := make(map[currency.Pence]int)
+	for i := amount; i > 0; i -= last[i] {
+		counts[last[i]]++
+	}
+
+	return breakdownOf(counts), nil
+}
+
+// fewest[i] is the fewest pieces that make i pence or -1 if it can't be made, last[i] is the denomination used to get there
+func searchChange(amount currency.Pence, denominations []currency.Pence) (fewest []int, last []currency.Pence) {
+	fewest = make([]int, amount+1)
+	last = make([]currency.Pence, amount+1)
+
+	for i := currency.Pence(1); i <= amount; i++ {
+		fewest[i] = -1
+		for _, d := range denominations {
+			if d <= 0 || d > i || fewest[i-d] < 0 {
+				continue
+			}
+			if pieces := fewest[i-d] + 1; fewest[i] < 0 || pieces < fewest[i] {
+				fewest[i] = pieces
+				last[i] = d
+			}
+		}
+	}
+
+	return fewest, last
+}
+
+// takes as many of the largest denomination as possible, then the next and so on
+func greedyChange(amount currency.Pence, denominations []currency.Pence) (Breakdown, error) {
+	sorted := largestFirst(denominations)
+
+	counts := make(map[currency.Pence]int)
+	left := amount
+	for _, d := range sorted {
+		if count := int(left / d); count > 0 {
+			counts[d] += count
+			left -= d * currency.Pence(count)
+		}
+	}
+
+	if left != 0 {
+		return nil, ErrCannotMakeChange
+	}
+	return breakdownOf(counts), nil
+}
+
+// canonical denominations are the ones greedy is always optimal for. If greedy ever gives more pieces than it needs
+// to, or misses a breakdown, it does so for an amount less than the two largest denominations added together
+// (Kozen and Zaks) so only those are checked.
+func canonical(denominations []currency.Pence) bool {
+	sorted := largestFirst(denominations)
+	if len(sorted) < 2 {
+		return true
+	}
+
+	key := fmt.Sprint(sorted)
+	if known, ok := canonicalDenominations.Load(key); ok {
+		return known.(bool)
+	}
+
+	isCanonical := checkCanonical(sorted)
+	canonicalDenominations.Store(key, isCanonical)
+	return isCanonical
+}
+
+// whether sets of denominations are canonical, checking takes a while so it is only done once for each set
+var canonicalDenominations sync.Map
+
+func checkCanonical(sorted []currency.Pence) bool {
+	bound := sorted[0] + sorted[1]
+	fewest, _ := searchChange(bound, sorted)
+
+	for amount := currency.Pence(1); amount < bound; amount++ {
+		greedy, err := greedyChange(amount, sorted)
+		if fewest[amount] < 0 {
+			continue
+		}
+		if err != nil || greedy.Pieces() != fewest[amount] {
+			return false
+		}
+	}
+	return true
+}
+
+// the positive denominations without duplicates, largest first
+func largestFirst(denominations []currency.Pence) []currency.Pence {
+	sorted := make([]currency.Pence, 0, len(denominations))
+	seen := make(map[currency.Pence]bool)
+	for _, d := range denominations {
+		if d > 0 && !seen[d] {
+			seen[d] = true
+			sorted = append(sorted, d)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+	return sorted
+}
+
+func breakdownOf(counts map[currency.Pence]int) Breakdown {
+	breakdown := make(Breakdown, 0, len(counts))
+	for value, count := range counts {
+		breakdown = append(breakdown, Denomination{Value: value, Count: count})
+	}
+	sort.Slice(breakdown, func(i, j int) bool { return breakdown[i].Value > breakdown[j].Value })
+	return breakdown
+}
@@ -0,0 +1,82 @@
+package payment
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+)
+
+func TestMakeChange(t *testing.T) {
+	tests := []struct {
+		name          string
+		amount        currency.Pence
+		denominations []currency.Pence
+		want          Breakdown
+		wantErr       error
+	}{
+		{
+			name:          "no change",
+			amount:        0,
+			denominations: UKDenominations,
+			want:          Breakdown{},
+		},
+		{
+			name:          "uses the largest notes and coins",
+			amount:        3788,
+			denominations: UKDenominations,
+			want:          Breakdown{{2000, 1}, {1000, 1}, {500, 1}, {200, 1}, {50, 1}, {20, 1}, {10, 1}, {5, 1}, {2, 1}, {1, 1}},
+		},
+		{
+			name:          "repeats denominations",
+			amount:        40,
+			denominations: UKDenominations,
+			want:          Breakdown{{20, 2}},
+		},
+		{
+			name:          "finds the optimal breakdown where greedy would not",
+			amount:        6,
+			denominations: []currency.Pence{4, 3, 1},
+			want:          Breakdown{{3, 2}},
+		},
+		{
+			name:          "large amounts of sterling don't need a search",
+			amount:        100_000_000_03,
+			denominations: UKDenominations,
+			want:          Breakdown{{5000, 2_000_000}, {2, 1}, {1, 1}},
+		},
+		{
+			name:          "large amounts are refused for denominations which need a search",
+			amount:        maxSearchedChange + 1,
+			denominations: []currency.Pence{4, 3, 1},
+			wantErr:       ErrCannotMakeChange,
+		},
+		{
+			name:          "returns an error when change can't be made",
+			amount:        3,
+			denominations: []currency.Pence{2},
+			wantErr:       ErrCannotMakeChange,
+		},
+		{
+			name:          "returns an error for a negative amount",
+			amount:        -1,
+			denominations: UKDenominations,
+			wantErr:       ErrInvalidAmount,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MakeChange(tt.amount, tt.denominations)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MakeChange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MakeChange() = %v, want %v", got, tt.want)
+			}
+			if err == nil && got.Total() != tt.amount {
+				t.Errorf("breakdown total = %d, want %d", got.Total(), tt.amount)
+			}
+		})
+	}
+}
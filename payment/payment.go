@@ -0,0 +1,188 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+)
+
+var (
+	ErrInvalidAmount     = errors.New("amount must be greater than zero")
+	ErrUnknownMethod     = errors.New("unknown tender method")
+	ErrOverpayment       = errors.New("tender is more than the amount left to pay")
+	ErrUnderpayment      = errors.New("the transaction has not been paid in full")
+	ErrTransactionClosed = errors.New("the transaction is closed")
+)
+
+// Method of payment
+type Method int
+
+const (
+	Cash Method = iota + 1
+	Card
+	Voucher
+	// loyalty points redeemed against the transaction
+	Points
+	GiftCard
+)
+
+func (m Method) String() string {
+	switch m {
+	case Cash:
+		return "cash"
+	case Card:
+		return "card"
+	case Voucher:
+		return "voucher"
+	case Points:
+		return "points"
+	case GiftCard:
+		return "gift card"
+	default:
+		return "unknown"
+	}
+}
+
+// only cash can be overpaid, the customer gets the difference back as change
+func (m Method) givesChange() bool {
+	return m == Cash
+}
+
+// Tender is a single payment towards a transaction, a transaction can be split across many tenders
+type Tender struct {
+	Method Method
+	Amount currency.Pence
+	// optional e.g. the last 4 digits of a card or a voucher code
+	Reference string
+}
+
+// Settlement is the outcome of a completed transaction
+type Settlement struct {
+	Total   currency.Pence
+	Paid    currency.Pence
+	Tenders []Tender
+	Change  Breakdown
+}
+
+// Transaction takes payment for a total, operation is go-routine safe
+type Transaction struct {
+	mu            sync.Mutex
+	total         currency.Pence
+	tenders       []Tender
+	denominations []currency.Pence
+	closed        bool
+}
+
+// creates a transaction for the total price of a basket
+// change is given in the denominations provided or UKDenominations when none are given
+func NewTransaction(total currency.Pence, denominations ...currency.Pence) (*Transaction, error) {
+	if total < 0 {
+		return nil, fmt.Errorf("%w: total %d", ErrInvalidAmount, total)
+	}
+
+	if len(denominations) == 0 {
+		denominations = UKDenominations
+	}
+
+	return &Transaction{total: total, denominations: denominations}, nil
+}
+
+func (t *Transaction) paid() currency.Pence {
+	paid := currency.Pence(0)
+	for _, tender := range t.tenders {
+		paid += tender.Amount
+	}
+	return paid
+}
+
+func (t *Transaction) remaining() currency.Pence {
+	if remaining := t.total - t.paid(); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// Remaining is how much is still left to pay
+func (t *Transaction) Remaining() currency.Pence {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.remaining()
+}
+
+// Tender records a payment against the transaction
+// card and voucher tenders can't be more than the amount left to pay, cash can as the excess is given back as change
+// but the change has to be less than the largest denomination and possible to make from the denominations
+func (t *Transaction) Tender(tender Tender) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return ErrTransactionClosed
+	}
+
+	if tender.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	switch tender.Method {
+	case Cash, Card, Voucher, Points, GiftCard:
+	default:
+		return ErrUnknownMethod
+	}
+
+	remaining := t.remaining()
+
+	if remaining == 0 {
+		return fmt.Errorf("%w: transaction is already paid", ErrOverpayment)
+	}
+
+	if tender.Amount > remaining && !tender.Method.givesChange() {
+		return fmt.Errorf("%w: %s tender of %d but only %d left to pay", ErrOverpayment, tender.Method, tender.Amount, remaining)
+	}
+
+	// nobody hands over a whole note more than they need to, this also keeps the change small enough to work out
+	if largest := largestFirst(t.denominations); len(largest) > 0 && tender.Amount-remaining >= largest[0] {
+		return fmt.Errorf("%w: %s tender of %d would need %d change, the largest note is %d", ErrOverpayment, tender.Method, tender.Amount, tender.Amount-remaining, largest[0])
+	}
+
+	// refused now rather than when completing as a tender can't be taken back
+	if excess := tender.Amount - remaining; excess > 0 {
+		if _, err := MakeChange(excess, t.denominations); err != nil {
+			return fmt.Errorf("%s tender of %d would need %d change: %w", tender.Method, tender.Amount, excess, err)
+		}
+	}
+
+	t.tenders = append(t.tenders, tender)
+
+	return nil
+}
+
+// Complete closes the transaction once it has been paid in full and works out the change
+func (t *Transaction) Complete() (Settlement, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return Settlement{}, ErrTransactionClosed
+	}
+
+	if remaining := t.remaining(); remaining > 0 {
+		return Settlement{}, fmt.Errorf("%w: %d left to pay", ErrUnderpayment, remaining)
+	}
+
+	paid := t.paid()
+
+	change, err := MakeChange(paid-t.total, t.denominations)
+	if err != nil {
+		return Settlement{}, err
+	}
+
+	t.closed = true
+
+	tenders := make([]Tender, len(t.tenders))
+	copy(tenders, t.tenders)
+
+	return Settlement{Total: t.total, Paid: paid, Tenders: tenders, Change: change}, nil
+}
@@ -0,0 +1,152 @@
+package payment
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+)
+
+func TestTransaction(t *testing.T) {
+	tests := []struct {
+		name       string
+		total      currency.Pence
+		tenders    []Tender
+		tenderErrs []error
+		wantChange Breakdown
+		wantErr    error
+	}{
+		{
+			name:       "exact card payment",
+			total:      130,
+			tenders:    []Tender{{Method: Card, Amount: 130}},
+			tenderErrs: []error{nil},
+			wantChange: Breakdown{},
+		},
+		{
+			name:       "cash overpayment gives change",
+			total:      130,
+			tenders:    []Tender{{Method: Cash, Amount: 500}},
+			tenderErrs: []error{nil},
+			wantChange: Breakdown{{200, 1}, {100, 1}, {50, 1}, {20, 1}},
+		},
+		{
+			name:       "cash can't be a whole note more than is needed",
+			total:      130,
+			tenders:    []Tender{{Method: Cash, Amount: 5130}, {Method: Cash, Amount: 5000}},
+			tenderErrs: []error{ErrOverpayment, nil},
+			wantChange: Breakdown{{2000, 2}, {500, 1}, {200, 1}, {100, 1}, {50, 1}, {20, 1}},
+		},
+		{
+			name:       "split tender across voucher, card and cash",
+			total:      1000,
+			tenders:    []Tender{{Method: Voucher, Amount: 500}, {Method: Card, Amount: 300}, {Method: Cash, Amount: 500}},
+			tenderErrs: []error{nil, nil, nil},
+			wantChange: Breakdown{{200, 1}, {100, 1}},
+		},
+		{
+			name:       "card can't be more than the amount left to pay",
+			total:      100,
+			tenders:    []Tender{{Method: Cash, Amount: 50}, {Method: Card, Amount: 60}, {Method: Card, Amount: 50}},
+			tenderErrs: []error{nil, ErrOverpayment, nil},
+			wantChange: Breakdown{},
+		},
+		{
+			name:       "can't tender once fully paid",
+			total:      100,
+			tenders:    []Tender{{Method: Card, Amount: 100}, {Method: Cash, Amount: 10}},
+			tenderErrs: []error{nil, ErrOverpayment},
+			wantChange: Breakdown{},
+		},
+		{
+			name:       "rejects invalid tenders",
+			total:      100,
+			tenders:    []Tender{{Method: Cash, Amount: 0}, {Method: Method(42), Amount: 10}},
+			tenderErrs: []error{ErrInvalidAmount, ErrUnknownMethod},
+			wantErr:    ErrUnderpayment,
+		},
+		{
+			name:       "can't complete an underpaid transaction",
+			total:      100,
+			tenders:    []Tender{{Method: Voucher, Amount: 99}},
+			tenderErrs: []error{nil},
+			wantErr:    ErrUnderpayment,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := NewTransaction(tt.total)
+			if err != nil {
+				t.Fatalf("NewTransaction() error = %v", err)
+			}
+
+			for i, tender := range tt.tenders {
+				if err := tx.Tender(tender); !errors.Is(err, tt.tenderErrs[i]) {
+					t.Errorf("Transaction.Tender(%+v) error = %v, want %v", tender, err, tt.tenderErrs[i])
+				}
+			}
+
+			settlement, err := tx.Complete()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transaction.Complete() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(settlement.Change, tt.wantChange) {
+				t.Errorf("change = %v, want %v", settlement.Change, tt.wantChange)
+			}
+
+			if settlement.Paid-settlement.Change.Total() != tt.total {
+				t.Errorf("paid %d minus change %d doesn't match the total %d", settlement.Paid, settlement.Change.Total(), tt.total)
+			}
+
+			if err := tx.Tender(Tender{Method: Cash, Amount: 1}); !errors.Is(err, ErrTransactionClosed) {
+				t.Errorf("expected tendering a closed transaction to fail, got %v", err)
+			}
+
+			if _, err := tx.Complete(); !errors.Is(err, ErrTransactionClosed) {
+				t.Errorf("expected completing a closed transaction to fail, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTransaction_Tender_changeNotPossible(t *testing.T) {
+	// only 5 and 2 pound coins so 70p change can't be given
+	tx, err := NewTransaction(130, 500, 200)
+	if err != nil {
+		t.Fatalf("NewTransaction() error = %v", err)
+	}
+
+	if err := tx.Tender(Tender{Method: Cash, Amount: 200}); !errors.Is(err, ErrCannotMakeChange) {
+		t.Errorf("Transaction.Tender() error = %v, want %v", err, ErrCannotMakeChange)
+	}
+
+	// the refused tender isn't kept so the transaction can still be paid
+	if got := tx.Remaining(); got != 130 {
+		t.Errorf("Transaction.Remaining() = %d, want %d", got, 130)
+	}
+
+	if err := tx.Tender(Tender{Method: Cash, Amount: 330}); err != nil {
+		t.Fatalf("Transaction.Tender() error = %v", err)
+	}
+
+	settlement, err := tx.Complete()
+	if err != nil {
+		t.Fatalf("Transaction.Complete() error = %v", err)
+	}
+
+	if want := (Breakdown{{200, 1}}); !reflect.DeepEqual(settlement.Change, want) {
+		t.Errorf("change = %v, want %v", settlement.Change, want)
+	}
+}
+
+func TestNewTransaction(t *testing.T) {
+	if _, err := NewTransaction(-1); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("NewTransaction() error = %v, want %v", err, ErrInvalidAmount)
+	}
+}
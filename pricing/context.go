@@ -0,0 +1,59 @@
+package pricing
+
+import (
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// Context is what pricing can know about the rest of the transaction besides the line being priced.
+// It's passed in with each call so pricing rules never hold on to a live basket or change when a card is scanned.
+type Context struct {
+	// the customer scanned a loyalty card
+	Member bool
+	// a copy of everything in the basket
+	Basket map[sku.SKU]quantity.Quantity
+}
+
+// Quantity of a sku in the basket, zero when it isn't there
+func (c Context) Quantity(id sku.SKU) int {
+	qty := c.Basket[id]
+	return qty.Value()
+}
+
+// Pricer prices a quantity of a product
+type Pricer interface {
+	GetPrice(sku sku.SKU, quantity quantity.Quantity) currency.Pence
+}
+
+// ContextPricer is an optional interface for pricing which depends on the rest of the transaction.
+// Pricing which wraps other pricing should implement it and pass the context on.
+type ContextPricer interface {
+	GetPriceIn(ctx Context, sku sku.SKU, quantity quantity.Quantity) currency.Pence
+}
+
+// ContextOfferCounter is the same for pricing which counts how many special offers a quantity qualifies for
+type ContextOfferCounter interface {
+	OffersAppliedIn(ctx Context, sku sku.SKU, quantity quantity.Quantity) int
+}
+
+// PriceIn prices a line in context when the pricing understands it, otherwise it's priced as normal
+func PriceIn(p Pricer, ctx Context, id sku.SKU, qty quantity.Quantity) currency.Pence {
+	if contextual, ok := p.(ContextPricer); ok {
+		return contextual.GetPriceIn(ctx, id, qty)
+	}
+	return p.GetPrice(id, qty)
+}
+
+// OffersAppliedIn counts the special offers a quantity qualifies for, ok is false when the pricing doesn't count offers
+func OffersAppliedIn(p any, ctx Context, id sku.SKU, qty quantity.Quantity) (offers int, ok bool) {
+	if contextual, ok := p.(ContextOfferCounter); ok {
+		return contextual.OffersAppliedIn(ctx, id, qty), true
+	}
+	if counter, ok := p.(interface {
+		OffersApplied(sku sku.SKU, quantity quantity.Quantity) int
+	}); ok {
+		return counter.OffersApplied(id, qty), true
+	}
+	return 0, false
+}
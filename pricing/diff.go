@@ -0,0 +1,125 @@
+package pricing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// ChangeKind is how a sku's pricing changed between two price lists
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota + 1
+	Removed
+	Changed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is the difference in a single sku's pricing
+type Change struct {
+	SKU  sku.SKU
+	Kind ChangeKind
+	// Old is empty for added skus and New for removed ones
+	Old PricingData
+	New PricingData
+}
+
+// PriceChanged reports whether the unit price is different
+func (c Change) PriceChanged() bool {
+	return c.Old.UnitPrice != c.New.UnitPrice
+}
+
+// OfferChanged reports whether the special offer was added, removed or changed
+func (c Change) OfferChanged() bool {
+	return c.Old.SpecialPrice != c.New.SpecialPrice || c.Old.SpecialQuantity.Value() != c.New.SpecialQuantity.Value()
+}
+
+// MemberPriceChanged reports whether the member price is different
+func (c Change) MemberPriceChanged() bool {
+	return c.Old.MemberPrice != c.New.MemberPrice
+}
+
+// describes the offer e.g. "3 for 130", "none" when there isn't one
+func describeOffer(data PricingData) string {
+	if !data.HasSpecialOffer() {
+		return "none"
+	}
+	specialQuantity := data.SpecialQuantity
+	if specialQuantity.Value() <= 1 {
+		return fmt.Sprintf("%d each", data.SpecialPrice)
+	}
+	return fmt.Sprintf("%d for %d", specialQuantity.Value(), data.SpecialPrice)
+}
+
+// e.g. "A changed: unit price 50 -> 55, offer 3 for 130 -> none"
+func (c Change) String() string {
+	var details []string
+
+	switch c.Kind {
+	case Added:
+		details = append(details, fmt.Sprintf("unit price %d", c.New.UnitPrice))
+		if c.New.HasSpecialOffer() {
+			details = append(details, "offer "+describeOffer(c.New))
+		}
+	case Removed:
+		details = append(details, fmt.Sprintf("unit price %d", c.Old.UnitPrice))
+		if c.Old.HasSpecialOffer() {
+			details = append(details, "offer "+describeOffer(c.Old))
+		}
+	case Changed:
+		if c.PriceChanged() {
+			details = append(details, fmt.Sprintf("unit price %d -> %d", c.Old.UnitPrice, c.New.UnitPrice))
+		}
+		if c.OfferChanged() {
+			details = append(details, fmt.Sprintf("offer %s -> %s", describeOffer(c.Old), describeOffer(c.New)))
+		}
+		if c.MemberPriceChanged() {
+			details = append(details, fmt.Sprintf("member price %d -> %d", c.Old.MemberPrice, c.New.MemberPrice))
+		}
+	}
+
+	return fmt.Sprintf("%s %s: %s", c.SKU, c.Kind, strings.Join(details, ", "))
+}
+
+// Diff lists every sku added, removed or changed going from the old to the new pricing, sorted by sku
+func Diff(old, next *SpecialPricing) []Change {
+	var changes []Change
+
+	for item, oldData := range old.Config {
+		newData, exists := next.Config[item]
+		if !exists {
+			changes = append(changes, Change{SKU: item, Kind: Removed, Old: oldData})
+			continue
+		}
+
+		change := Change{SKU: item, Kind: Changed, Old: oldData, New: newData}
+		if change.PriceChanged() || change.OfferChanged() || change.MemberPriceChanged() {
+			changes = append(changes, change)
+		}
+	}
+
+	for item, newData := range next.Config {
+		if _, exists := old.Config[item]; !exists {
+			changes = append(changes, Change{SKU: item, Kind: Added, New: newData})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].SKU.Value() < changes[j].SKU.Value() })
+
+	return changes
+}
@@ -0,0 +1,51 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func TestDiff(t *testing.T) {
+	skuA, _ := sku.New('A')
+	skuB, _ := sku.New('B')
+	skuC, _ := sku.New('C')
+	skuD, _ := sku.New('D')
+	skuE, _ := sku.New('E')
+
+	old := &SpecialPricing{Config: map[sku.SKU]PricingData{
+		skuA: {UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3)},
+		skuB: {UnitPrice: 30, SpecialPrice: 45, SpecialQuantity: *quantity.New(2)},
+		skuC: {UnitPrice: 20},
+		skuD: {UnitPrice: 15},
+	}}
+	next := &SpecialPricing{Config: map[sku.SKU]PricingData{
+		skuA: {UnitPrice: 55, SpecialPrice: 130, SpecialQuantity: *quantity.New(3)},
+		skuB: {UnitPrice: 30},
+		skuC: {UnitPrice: 20},
+		skuE: {UnitPrice: 99, SpecialPrice: 150, SpecialQuantity: *quantity.New(2)},
+	}}
+
+	want := []string{
+		"A changed: unit price 50 -> 55",
+		"B changed: offer 2 for 45 -> none",
+		"D removed: unit price 15",
+		"E added: unit price 99, offer 2 for 150",
+	}
+
+	changes := Diff(old, next)
+	if len(changes) != len(want) {
+		t.Fatalf("Diff() = %v, want %v", changes, want)
+	}
+
+	for i, change := range changes {
+		if got := change.String(); got != want[i] {
+			t.Errorf("Diff()[%d] = %q, want %q", i, got, want[i])
+		}
+	}
+
+	if changes[0].OfferChanged() || !changes[1].OfferChanged() {
+		t.Errorf("OfferChanged() is wrong for %v", changes[:2])
+	}
+}
@@ -0,0 +1,288 @@
+package pricing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/filelock"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+var (
+	ErrNoPricesInForce = errors.New("no prices were in force at that time")
+	ErrBackdated       = errors.New("prices can't take effect before they are published")
+	errHistoryOrder    = errors.New("price history versions must be in order")
+)
+
+// Version is a price list as it was published, versions are never changed once written
+type Version struct {
+	Number int
+	// when the prices take over from the previous version
+	EffectiveFrom time.Time
+	Published     time.Time
+	Pricing       *SpecialPricing
+}
+
+// a version in the history file
+type versionRecord struct {
+	Version       int          `json:"version"`
+	EffectiveFrom time.Time    `json:"effective_from"`
+	Published     time.Time    `json:"published"`
+	Prices        []priceEntry `json:"prices"`
+}
+
+// History keeps every price list ever published in an append-only json lines file so the prices in force at any
+// time can be looked up e.g. to re-price an old receipt for a dispute.
+// Prices can be published ahead of time but never backdated, so what was charged in the past can't be rewritten.
+// operation is go-routine safe, processes sharing the file take turns to publish (see Publish) and see each other's
+// versions
+type History struct {
+	mu       sync.Mutex
+	file     *os.File
+	versions []Version
+	now      func() time.Time
+}
+
+// OpenHistory opens the history at the path, creating it if it doesn't exist
+func OpenHistory(path string) (*History, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := ReadHistory(file)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("%s: %w", path, err), file.Close())
+	}
+
+	return &History{file: file, versions: versions, now: time.Now}, nil
+}
+
+// Close closes the history file
+func (h *History) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.file.Close()
+}
+
+// ReadHistory reads the json lines written by a history.
+// A last line which was cut short, e.g. by a crash part way through publishing, is skipped.
+func ReadHistory(r io.Reader) ([]Version, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, _, err := parseHistory(data)
+	return versions, err
+}
+
+// parses the versions, complete is how many bytes are whole lines. A last line without a newline which doesn't parse
+// was cut short and isn't counted.
+func parseHistory(data []byte) (versions []Version, complete int, err error) {
+	for complete < len(data) {
+		line := data[complete:]
+		end := bytes.IndexByte(line, '\n')
+		if end >= 0 {
+			line = line[:end]
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			v, err := parseVersion(line, len(versions))
+			if err != nil {
+				if end < 0 {
+					return versions, complete, nil
+				}
+				return nil, 0, err
+			}
+			versions = append(versions, v)
+		}
+
+		if end < 0 {
+			return versions, len(data), nil
+		}
+		complete += end + 1
+	}
+
+	return versions, complete, nil
+}
+
+func parseVersion(line []byte, previous int) (Version, error) {
+	var record versionRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return Version{}, fmt.Errorf("failed to read price history version %d: %w", previous+1, err)
+	}
+	if record.Version != previous+1 {
+		return Version{}, fmt.Errorf("%w: found version %d after %d", errHistoryOrder, record.Version, previous)
+	}
+
+	rules, err := fromEntries(record.Prices)
+	if err != nil {
+		return Version{}, fmt.Errorf("price history version %d: %w", record.Version, err)
+	}
+
+	return Version{Number: record.Version, EffectiveFrom: record.EffectiveFrom, Published: record.Published, Pricing: rules}, nil
+}
+
+// Publish adds a new version of the prices taking effect from the given time, a zero time means straight away.
+// The prices are validated the same way as Load and refused if they have any errors.
+// The file is locked while publishing and read again first, so versions published by other processes are numbered
+// in and a version cut short by a crash is cleared away before the new one is written.
+func (h *History) Publish(p *SpecialPricing, effectiveFrom time.Time, opts ...ValidateOption) (v Version, err error) {
+	if err := Validate(p, opts...).Err(); err != nil {
+		return Version{}, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := filelock.Lock(h.file); err != nil {
+		return Version{}, err
+	}
+	defer func() {
+		err = errors.Join(err, filelock.Unlock(h.file))
+	}()
+
+	if err := h.reload(); err != nil {
+		return Version{}, err
+	}
+
+	published := h.now().UTC().Round(0)
+	if effectiveFrom.IsZero() {
+		effectiveFrom = published
+	}
+	effectiveFrom = effectiveFrom.UTC().Round(0)
+
+	if effectiveFrom.Before(published) {
+		return Version{}, fmt.Errorf("%w: effective from %s, published %s", ErrBackdated, effectiveFrom.Format(time.RFC3339), published.Format(time.RFC3339))
+	}
+
+	record := versionRecord{Version: len(h.versions) + 1, EffectiveFrom: effectiveFrom, Published: published, Prices: toEntries(p)}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return Version{}, err
+	}
+
+	if _, err := h.file.Write(append(data, '\n')); err != nil {
+		return Version{}, err
+	}
+	if err := h.file.Sync(); err != nil {
+		return Version{}, err
+	}
+
+	v = Version{Number: record.Version, EffectiveFrom: effectiveFrom, Published: published, Pricing: p.clone()}
+	h.versions = append(h.versions, v)
+
+	return v.copy(), nil
+}
+
+// reads the file again and cuts off a last line which was cut short, must be called with both locks held
+func (h *History) reload() error {
+	if _, err := h.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(h.file)
+	if err != nil {
+		return err
+	}
+
+	versions, complete, err := parseHistory(data)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case complete < len(data):
+		if err := h.file.Truncate(int64(complete)); err != nil {
+			return err
+		}
+	case len(data) > 0 && data[len(data)-1] != '\n':
+		// the version was written but not its newline
+		if _, err := h.file.Write([]byte{'\n'}); err != nil {
+			return err
+		}
+	}
+
+	h.versions = versions
+	return nil
+}
+
+// reads in the versions other processes have published, must be called with h.mu held
+func (h *History) refresh() (err error) {
+	if err := filelock.Lock(h.file); err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, filelock.Unlock(h.file))
+	}()
+
+	return h.reload()
+}
+
+// Versions lists every version in the order they were published, including those published by other processes
+func (h *History) Versions() ([]Version, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.refresh(); err != nil {
+		return nil, err
+	}
+
+	versions := make([]Version, len(h.versions))
+	for i, v := range h.versions {
+		versions[i] = v.copy()
+	}
+	return versions, nil
+}
+
+// At returns the version in force at the time, the one which most recently took effect.
+// If two versions take effect at the same time the one published last wins e.g. a correction.
+// The file is read again first so versions published by other processes are seen.
+func (h *History) At(t time.Time) (Version, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.refresh(); err != nil {
+		return Version{}, err
+	}
+
+	return versionAt(h.versions, t)
+}
+
+func versionAt(versions []Version, t time.Time) (Version, error) {
+	inForce := -1
+	for i, v := range versions {
+		if v.EffectiveFrom.After(t) {
+			continue
+		}
+		if inForce == -1 || !v.EffectiveFrom.Before(versions[inForce].EffectiveFrom) {
+			inForce = i
+		}
+	}
+
+	if inForce == -1 {
+		return Version{}, fmt.Errorf("%w: %s", ErrNoPricesInForce, t.Format(time.RFC3339))
+	}
+	return versions[inForce].copy(), nil
+}
+
+// versions are copied on the way out so nobody can change the history
+func (v Version) copy() Version {
+	v.Pricing = v.Pricing.clone()
+	return v
+}
+
+func (p *SpecialPricing) clone() *SpecialPricing {
+	config := make(map[sku.SKU]PricingData, len(p.Config))
+	for item, data := range p.Config {
+		config[item] = data
+	}
+	return &SpecialPricing{Config: config}
+}
@@ -0,0 +1,206 @@
+package pricing
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	skuA, _ := sku.New('A')
+
+	day := func(d int) time.Time {
+		return time.Date(2026, 10, d, 9, 0, 0, 0, time.UTC)
+	}
+	prices := func(unit currency.Pence) *SpecialPricing {
+		return &SpecialPricing{Config: map[sku.SKU]PricingData{skuA: {UnitPrice: unit}}}
+	}
+
+	history, err := OpenHistory(path)
+	if err != nil {
+		t.Fatalf("OpenHistory() error = %v", err)
+	}
+
+	publish := func(now time.Time, p *SpecialPricing, from time.Time) {
+		t.Helper()
+		history.now = func() time.Time { return now }
+		if _, err := history.Publish(p, from); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	// 50 from the 1st, 45 scheduled on the 2nd for the 10th, 55 from the 5th which is corrected to 52 straight away
+	publish(day(1), prices(50), time.Time{})
+	publish(day(2), prices(45), day(10))
+	publish(day(5), prices(55), day(5))
+	publish(day(5), prices(52), day(5))
+
+	if err := history.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the history survives being reopened
+	history, err = OpenHistory(path)
+	if err != nil {
+		t.Fatalf("OpenHistory() error = %v", err)
+	}
+	defer history.Close()
+
+	tests := []struct {
+		name        string
+		at          time.Time
+		wantVersion int
+		wantPrice   currency.Pence
+		wantErr     error
+	}{
+		{name: "before anything was published", at: day(1).Add(-time.Second), wantErr: ErrNoPricesInForce},
+		{name: "first version", at: day(1), wantVersion: 1, wantPrice: 50},
+		{name: "scheduled version isn't in force yet", at: day(4), wantVersion: 1, wantPrice: 50},
+		{name: "correction wins over the version it replaces", at: day(6), wantVersion: 4, wantPrice: 52},
+		{name: "scheduled version takes over", at: day(10), wantVersion: 2, wantPrice: 45},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := history.At(tt.at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("At() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if v.Number != tt.wantVersion {
+				t.Errorf("At() version = %d, want %d", v.Number, tt.wantVersion)
+			}
+			if got := v.Pricing.GetPrice(skuA, *quantity.New(1)); got != tt.wantPrice {
+				t.Errorf("At() price of A = %d, want %d", got, tt.wantPrice)
+			}
+		})
+	}
+
+	// changing what At returns doesn't change the history
+	v, _ := history.At(day(1))
+	v.Pricing.Config[skuA] = PricingData{UnitPrice: 1}
+	if again, _ := history.At(day(1)); again.Pricing.Config[skuA].UnitPrice != 50 {
+		t.Errorf("At() returned the history's own pricing")
+	}
+
+	if versions, err := history.Versions(); err != nil || len(versions) != 4 {
+		t.Errorf("Versions() = %d versions, %v, want %d", len(versions), err, 4)
+	}
+}
+
+func TestHistory_Publish_errors(t *testing.T) {
+	history, err := OpenHistory(filepath.Join(t.TempDir(), "history.jsonl"))
+	if err != nil {
+		t.Fatalf("OpenHistory() error = %v", err)
+	}
+	defer history.Close()
+
+	skuA, _ := sku.New('A')
+	now := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	history.now = func() time.Time { return now }
+
+	valid := &SpecialPricing{Config: map[sku.SKU]PricingData{skuA: {UnitPrice: 50}}}
+	if _, err := history.Publish(valid, now.Add(-time.Hour)); !errors.Is(err, ErrBackdated) {
+		t.Errorf("Publish() error = %v, want %v", err, ErrBackdated)
+	}
+
+	invalid := &SpecialPricing{Config: map[sku.SKU]PricingData{skuA: {UnitPrice: 0}}}
+	if _, err := history.Publish(invalid, time.Time{}); !errors.Is(err, ErrInvalidPricing) {
+		t.Errorf("Publish() error = %v, want %v", err, ErrInvalidPricing)
+	}
+
+	if versions, err := history.Versions(); err != nil || len(versions) != 0 {
+		t.Errorf("Versions() = %d versions, %v, want none", len(versions), err)
+	}
+}
+
+func readHistoryFile(t *testing.T, path string) ([]Version, error) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return ReadHistory(f)
+}
+
+func TestHistory_Publish_sharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	skuA, _ := sku.New('A')
+	prices := &SpecialPricing{Config: map[sku.SKU]PricingData{skuA: {UnitPrice: 50}}}
+
+	// two processes with the same history open
+	first, err := OpenHistory(path)
+	if err != nil {
+		t.Fatalf("OpenHistory() error = %v", err)
+	}
+	defer first.Close()
+	second, err := OpenHistory(path)
+	if err != nil {
+		t.Fatalf("OpenHistory() error = %v", err)
+	}
+	defer second.Close()
+
+	for i, history := range []*History{first, second, first} {
+		v, err := history.Publish(prices, time.Time{})
+		if err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		if v.Number != i+1 {
+			t.Errorf("Publish() version = %d, want %d", v.Number, i+1)
+		}
+	}
+
+	// a crash part way through writing a version leaves half a line
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"version":4,"effective_fr`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	versions, err := readHistoryFile(t, path)
+	if err != nil {
+		t.Fatalf("reading a history with a cut short version error = %v", err)
+	}
+	if len(versions) != 3 {
+		t.Errorf("read %d versions, want %d", len(versions), 3)
+	}
+
+	// publishing clears the half written version away
+	v, err := second.Publish(prices, time.Time{})
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if v.Number != 4 {
+		t.Errorf("Publish() version = %d, want %d", v.Number, 4)
+	}
+
+	versions, err = readHistoryFile(t, path)
+	if err != nil || len(versions) != 4 {
+		t.Errorf("read %d versions after publishing, error = %v, want %d", len(versions), err, 4)
+	}
+
+	// the other process sees the versions without publishing itself
+	cheaper := &SpecialPricing{Config: map[sku.SKU]PricingData{skuA: {UnitPrice: 40}}}
+	if _, err := second.Publish(cheaper, time.Time{}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if versions, err := first.Versions(); err != nil || len(versions) != 5 {
+		t.Errorf("Versions() = %d versions, %v, want %d", len(versions), err, 5)
+	}
+	if v, err := first.At(time.Now()); err != nil || v.Number != 5 || v.Pricing.Config[skuA].UnitPrice != 40 {
+		t.Errorf("At() = version %d, %v, want version 5 at 40", v.Number, err)
+	}
+}
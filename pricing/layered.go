@@ -0,0 +1,141 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+var (
+	ErrUnknownStore = errors.New("store has no pricing")
+	errNoStoreID    = errors.New("a store id is required")
+)
+
+// PriceList is the pricing for a set of skus
+type PriceList map[sku.SKU]PricingData
+
+// Layer of a LayeredPricing, later layers override earlier ones
+type Layer int
+
+const (
+	LayerBase Layer = iota
+	LayerRegion
+	LayerStore
+)
+
+func (l Layer) String() string {
+	switch l {
+	case LayerBase:
+		return "base"
+	case LayerRegion:
+		return "region"
+	case LayerStore:
+		return "store"
+	default:
+		return "unknown"
+	}
+}
+
+// Source is where a price came from e.g. the store layer for store 12
+type Source struct {
+	Layer Layer
+	// the region or store id, empty for the base layer
+	Name string
+}
+
+func (s Source) String() string {
+	if s.Name == "" {
+		return s.Layer.String()
+	}
+	return fmt.Sprintf("%s %s", s.Layer, s.Name)
+}
+
+// LayeredPricing is a national base price list with per region and per store overrides e.g. "national price list,
+// with a different A price in store 12". Prices are resolved per sku, the store's price wins over its region's which
+// wins over the base price. An override replaces the sku's whole PricingData, so a store price without a special offer
+// also removes the base special offer in that store.
+type LayeredPricing struct {
+	base    PriceList
+	regions map[string]PriceList
+	stores  map[string]store
+}
+
+type store struct {
+	region    string
+	overrides PriceList
+}
+
+func NewLayeredPricing(base PriceList) *LayeredPricing {
+	return &LayeredPricing{base: base, regions: make(map[string]PriceList), stores: make(map[string]store)}
+}
+
+// SetRegion sets the overrides for every store in a region
+func (p *LayeredPricing) SetRegion(region string, overrides PriceList) {
+	p.regions[region] = overrides
+}
+
+// SetStore adds a store to a region with its own overrides, overrides can be nil when the store uses its region's prices
+func (p *LayeredPricing) SetStore(storeID, region string, overrides PriceList) error {
+	if storeID == "" {
+		return errNoStoreID
+	}
+	p.stores[storeID] = store{region: region, overrides: overrides}
+	return nil
+}
+
+// ForStore resolves the pricing rules for a store
+func (p *LayeredPricing) ForStore(storeID string) (*StorePricing, error) {
+	s, exists := p.stores[storeID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStore, storeID)
+	}
+
+	resolved := &StorePricing{
+		SpecialPricing: &SpecialPricing{Config: make(map[sku.SKU]PricingData)},
+		StoreID:        storeID,
+		Region:         s.region,
+		sources:        make(map[sku.SKU]Source),
+	}
+
+	layers := []struct {
+		source Source
+		prices PriceList
+	}{
+		{source: Source{Layer: LayerBase}, prices: p.base},
+		{source: Source{Layer: LayerRegion, Name: s.region}, prices: p.regions[s.region]},
+		{source: Source{Layer: LayerStore, Name: storeID}, prices: s.overrides},
+	}
+
+	for _, layer := range layers {
+		for item, data := range layer.prices {
+			resolved.Config[item] = data
+			resolved.sources[item] = layer.source
+		}
+	}
+
+	return resolved, nil
+}
+
+// StorePricing is the pricing rules for a single store, it can be used anywhere SpecialPricing can
+type StorePricing struct {
+	*SpecialPricing
+	StoreID string
+	Region  string
+	sources map[sku.SKU]Source
+}
+
+// Source reports which layer the sku's price came from, ok is false when the sku has no price in the store
+func (p *StorePricing) Source(sku sku.SKU) (source Source, ok bool) {
+	source, ok = p.sources[sku]
+	return source, ok
+}
+
+// Sources reports which layer every price came from
+func (p *StorePricing) Sources() map[sku.SKU]Source {
+	sources := make(map[sku.SKU]Source, len(p.sources))
+	for item, source := range p.sources {
+		sources[item] = source
+	}
+	return sources
+}
@@ -0,0 +1,130 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func TestLayeredPricing_ForStore(t *testing.T) {
+	skuA, _ := sku.New('A')
+	skuB, _ := sku.New('B')
+	skuC, _ := sku.New('C')
+	skuD, _ := sku.New('D')
+
+	layered := NewLayeredPricing(PriceList{
+		skuA: {UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3)},
+		skuB: {UnitPrice: 30},
+		skuC: {UnitPrice: 20},
+	})
+	layered.SetRegion("north", PriceList{skuB: {UnitPrice: 28}, skuD: {UnitPrice: 15}})
+
+	if err := layered.SetStore("12", "north", PriceList{skuA: {UnitPrice: 45}}); err != nil {
+		t.Fatalf("LayeredPricing.SetStore() error = %v", err)
+	}
+	if err := layered.SetStore("7", "south", nil); err != nil {
+		t.Fatalf("LayeredPricing.SetStore() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		store      string
+		sku        sku.SKU
+		quantity   int
+		want       currency.Pence
+		wantSource Source
+		wantExists bool
+	}{
+		{
+			name:       "store override replaces the base price and its offer",
+			store:      "12",
+			sku:        skuA,
+			quantity:   3,
+			want:       135,
+			wantSource: Source{Layer: LayerStore, Name: "12"},
+			wantExists: true,
+		},
+		{
+			name:       "region override",
+			store:      "12",
+			sku:        skuB,
+			quantity:   1,
+			want:       28,
+			wantSource: Source{Layer: LayerRegion, Name: "north"},
+			wantExists: true,
+		},
+		{
+			name:       "products only sold in the region",
+			store:      "12",
+			sku:        skuD,
+			quantity:   2,
+			want:       30,
+			wantSource: Source{Layer: LayerRegion, Name: "north"},
+			wantExists: true,
+		},
+		{
+			name:       "base price",
+			store:      "12",
+			sku:        skuC,
+			quantity:   1,
+			want:       20,
+			wantSource: Source{Layer: LayerBase},
+			wantExists: true,
+		},
+		{
+			name:       "store without overrides uses the base special offer",
+			store:      "7",
+			sku:        skuA,
+			quantity:   3,
+			want:       130,
+			wantSource: Source{Layer: LayerBase},
+			wantExists: true,
+		},
+		{
+			name:     "regional products aren't sold elsewhere",
+			store:    "7",
+			sku:      skuD,
+			quantity: 1,
+			want:     0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := layered.ForStore(tt.store)
+			if err != nil {
+				t.Fatalf("LayeredPricing.ForStore() error = %v", err)
+			}
+
+			if got := rules.GetPrice(tt.sku, *quantity.New(tt.quantity)); got != tt.want {
+				t.Errorf("StorePricing.GetPrice() = %d, want %d", got, tt.want)
+			}
+
+			if got := rules.PriceExists(tt.sku); got != tt.wantExists {
+				t.Errorf("StorePricing.PriceExists() = %v, want %v", got, tt.wantExists)
+			}
+
+			got, ok := rules.Source(tt.sku)
+			if ok != tt.wantExists {
+				t.Fatalf("StorePricing.Source() ok = %v, want %v", ok, tt.wantExists)
+			}
+			if ok && got != tt.wantSource {
+				t.Errorf("StorePricing.Source() = %v, want %v", got, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestLayeredPricing_ForStore_unknown(t *testing.T) {
+	layered := NewLayeredPricing(nil)
+
+	if _, err := layered.ForStore("99"); !errors.Is(err, ErrUnknownStore) {
+		t.Errorf("LayeredPricing.ForStore() error = %v, want %v", err, ErrUnknownStore)
+	}
+
+	if err := layered.SetStore("", "north", nil); !errors.Is(err, errNoStoreID) {
+		t.Errorf("LayeredPricing.SetStore() error = %v, want %v", err, errNoStoreID)
+	}
+}
@@ -0,0 +1,126 @@
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// the json price list file
+type priceFile struct {
+	Prices []priceEntry `json:"prices"`
+}
+
+type priceEntry struct {
+	SKU             sku.SKU        `json:"sku"`
+	UnitPrice       currency.Pence `json:"unit_price"`
+	SpecialPrice    currency.Pence `json:"special_price,omitempty"`
+	SpecialQuantity int            `json:"special_quantity,omitempty"`
+	MemberPrice     currency.Pence `json:"member_price,omitempty"`
+}
+
+// Load reads a json price list e.g.
+//
+//	{"prices": [{"sku": "A", "unit_price": 50, "special_price": 130, "special_quantity": 3}]}
+//
+// The prices are validated and a *ValidationError is returned if there are any errors, run Validate to see the warnings.
+func Load(r io.Reader, opts ...ValidateOption) (*SpecialPricing, error) {
+	var file priceFile
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to read price list: %w", err)
+	}
+
+	rules, err := fromEntries(file.Prices)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Validate(rules, opts...).Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// LoadFile reads a json price list from a file
+func LoadFile(path string, opts ...ValidateOption) (*SpecialPricing, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f, opts...)
+}
+
+func fromEntries(entries []priceEntry) (*SpecialPricing, error) {
+	if err := validateEntries(entries).Err(); err != nil {
+		return nil, err
+	}
+
+	config := make(map[sku.SKU]PricingData, len(entries))
+	for i, entry := range entries {
+		if _, exists := config[entry.SKU]; exists {
+			return nil, fmt.Errorf("price %d: sku %s is listed more than once", i+1, entry.SKU)
+		}
+		config[entry.SKU] = PricingData{
+			UnitPrice:       entry.UnitPrice,
+			SpecialPrice:    entry.SpecialPrice,
+			SpecialQuantity: *quantity.New(entry.SpecialQuantity),
+			MemberPrice:     entry.MemberPrice,
+		}
+	}
+	return &SpecialPricing{Config: config}, nil
+}
+
+// checks what would be lost converting the entries, a quantity can't be negative so it would become 0 and be reported
+// as a missing special quantity instead
+func validateEntries(entries []priceEntry) Problems {
+	var problems Problems
+	for _, entry := range entries {
+		if entry.SpecialQuantity < 0 {
+			problems = append(problems, Problem{
+				SKU:      entry.SKU,
+				Severity: SeverityError,
+				Code:     ProblemNegativeQuantity,
+				Message:  fmt.Sprintf("special quantity %d is negative", entry.SpecialQuantity),
+			})
+		}
+	}
+	return problems
+}
+
+// the pricing as price list entries in sku order
+func toEntries(p *SpecialPricing) []priceEntry {
+	entries := make([]priceEntry, 0, len(p.Config))
+	for item, data := range p.Config {
+		entries = append(entries, priceEntry{
+			SKU:             item,
+			UnitPrice:       data.UnitPrice,
+			SpecialPrice:    data.SpecialPrice,
+			SpecialQuantity: data.SpecialQuantity.Value(),
+			MemberPrice:     data.MemberPrice,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].SKU.Value() < entries[j].SKU.Value() })
+	return entries
+}
+
+// Write saves the pricing as a json price list which Load can read back, skus are written in order
+func Write(w io.Writer, p *SpecialPricing) error {
+	file := priceFile{Prices: toEntries(p)}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(file)
+}
@@ -0,0 +1,74 @@
+package pricing
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func TestLoadFile(t *testing.T) {
+	rules, err := LoadFile("testdata/prices.json")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	skuA, _ := sku.New('A')
+	want := PricingData{UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3)}
+
+	if got := rules.Config[skuA]; !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadFile() A = %+v, want %+v", got, want)
+	}
+
+	if len(rules.Config) != 4 {
+		t.Errorf("LoadFile() loaded %d prices, want %d", len(rules.Config), 4)
+	}
+
+	// writing it back out and loading it again gives the same pricing
+	var buf bytes.Buffer
+	if err := Write(&buf, rules); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	reloaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(reloaded, rules) {
+		t.Errorf("round trip = %+v, want %+v", reloaded, rules)
+	}
+}
+
+func TestLoad_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "not json", input: "prices", wantErr: "failed to read price list"},
+		{name: "invalid sku", input: `{"prices": [{"sku": "$", "unit_price": 1}]}`, wantErr: "failed to read price list"},
+		{name: "unknown field", input: `{"prices": [{"sku": "A", "price": 1}]}`, wantErr: "unknown field"},
+		{name: "duplicate sku", input: `{"prices": [{"sku": "A", "unit_price": 1}, {"sku": "A", "unit_price": 2}]}`, wantErr: "listed more than once"},
+		{
+			name:    "negative special quantity",
+			input:   `{"prices": [{"sku": "A", "unit_price": 50, "special_price": 130, "special_quantity": -3}]}`,
+			wantErr: ProblemNegativeQuantity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+			if strings.Contains(fmt.Sprint(err), ProblemMissingSpecialQuantity) {
+				t.Errorf("Load() error = %v, a negative quantity isn't a missing one", err)
+			}
+		})
+	}
+}
@@ -38,6 +38,8 @@ type PricingData struct {
 	UnitPrice       currency.Pence
 	SpecialPrice    currency.Pence
 	SpecialQuantity quantity.Quantity
+	// unit price for loyalty members, zero when there isn't one
+	MemberPrice currency.Pence
 }
 
 func (p *PricingData) HasSpecialOffer() bool {
@@ -82,6 +84,26 @@ func (p *SpecialPricing) calculatePrice(data PricingData, quantity quantity.Quan
 
 }
 
+// OffersApplied returns how many times the special offer applies to the given quantity
+// an offer without a special quantity (a special price for the day) applies to every item
+func (p *SpecialPricing) OffersApplied(sku sku.SKU, quantity quantity.Quantity) int {
+	if p == nil || p.Config == nil {
+		return 0
+	}
+
+	data, exists := p.Config[sku]
+	if !exists || !data.HasSpecialOffer() {
+		return 0
+	}
+
+	specialQuantity := data.SpecialQuantity.Value()
+	if specialQuantity <= 0 {
+		specialQuantity = 1
+	}
+
+	return quantity.Value() / specialQuantity
+}
+
 func (p *SpecialPricing) GetPrice(sku sku.SKU, quantity quantity.Quantity) currency.Pence {
 
 	if p == nil || p.Config == nil {
@@ -106,3 +128,68 @@ func (p *SpecialPricing) PriceExists(sku sku.SKU) bool {
 
 	return exists
 }
+
+// GetPriceIn charges member prices when the customer has scanned a loyalty card
+func (p *SpecialPricing) GetPriceIn(ctx Context, sku sku.SKU, quantity quantity.Quantity) currency.Pence {
+	if ctx.Member {
+		return p.Members().GetPrice(sku, quantity)
+	}
+	return p.GetPrice(sku, quantity)
+}
+
+// OffersAppliedIn counts offers the way GetPriceIn prices them
+func (p *SpecialPricing) OffersAppliedIn(ctx Context, sku sku.SKU, quantity quantity.Quantity) int {
+	if ctx.Member {
+		return p.Members().OffersApplied(sku, quantity)
+	}
+	return p.OffersApplied(sku, quantity)
+}
+
+// MemberPricing prices for loyalty card holders. Products with a member price charge whichever is cheaper out of
+// every item at the member price or the normal price with its special offer, the rest are priced as normal.
+type MemberPricing struct {
+	Rules *SpecialPricing
+}
+
+// Members returns the pricing for loyalty card holders
+func (p *SpecialPricing) Members() *MemberPricing {
+	return &MemberPricing{Rules: p}
+}
+
+func (p *MemberPricing) GetPrice(sku sku.SKU, quantity quantity.Quantity) currency.Pence {
+	if p == nil {
+		return 0
+	}
+
+	price := p.Rules.GetPrice(sku, quantity)
+
+	data, exists := p.Rules.Config[sku]
+	if !exists || data.MemberPrice == 0 {
+		return price
+	}
+
+	if memberPrice := data.MemberPrice * currency.Pence(quantity.Value()); memberPrice < price {
+		return memberPrice
+	}
+	return price
+}
+
+func (p *MemberPricing) PriceExists(sku sku.SKU) bool {
+	if p == nil {
+		return false
+	}
+	return p.Rules.PriceExists(sku)
+}
+
+// OffersApplied returns how many times the special offer applies, none when the member price is cheaper
+func (p *MemberPricing) OffersApplied(sku sku.SKU, quantity quantity.Quantity) int {
+	if p == nil {
+		return 0
+	}
+
+	data, exists := p.Rules.Config[sku]
+	if exists && data.MemberPrice != 0 && data.MemberPrice*currency.Pence(quantity.Value()) < p.Rules.GetPrice(sku, quantity) {
+		return 0
+	}
+	return p.Rules.OffersApplied(sku, quantity)
+}
@@ -105,3 +105,100 @@ func TestSpecialPricing_GetPrice(t *testing.T) {
 		})
 	}
 }
+
+func TestSpecialPricing_OffersApplied(t *testing.T) {
+	skuA, err := sku.New('A')
+
+	if err != nil {
+		t.Fatalf("creating sku A failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		pricing  map[sku.SKU]PricingData
+		quantity quantity.Quantity
+		want     int
+	}{
+		{
+			name:     "no offers when the sku has no pricing",
+			pricing:  nil,
+			quantity: *quantity.New(3),
+			want:     0,
+		},
+		{
+			name:     "no offers when the sku has no special offer",
+			pricing:  map[sku.SKU]PricingData{skuA: {UnitPrice: 50}},
+			quantity: *quantity.New(3),
+			want:     0,
+		},
+		{
+			name:     "counts the number of complete multi-buys",
+			pricing:  map[sku.SKU]PricingData{skuA: {UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3)}},
+			quantity: *quantity.New(7),
+			want:     2,
+		},
+		{
+			name:     "special price for the day applies to every item",
+			pricing:  map[sku.SKU]PricingData{skuA: {UnitPrice: 50, SpecialPrice: 35}},
+			quantity: *quantity.New(2),
+			want:     2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &SpecialPricing{Config: tt.pricing}
+			if got := p.OffersApplied(skuA, tt.quantity); got != tt.want {
+				t.Errorf("SpecialPricing.OffersApplied() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemberPricing_GetPrice(t *testing.T) {
+	skuA, _ := sku.New('A')
+	skuB, _ := sku.New('B')
+
+	rules := &SpecialPricing{Config: map[sku.SKU]PricingData{
+		skuA: {UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3), MemberPrice: 45},
+		skuB: {UnitPrice: 30},
+	}}
+
+	tests := []struct {
+		name           string
+		sku            sku.SKU
+		quantity       quantity.Quantity
+		want           currency.Pence
+		wantOffersUsed int
+	}{
+		{
+			name:     "member price is cheaper than the unit price",
+			sku:      skuA,
+			quantity: *quantity.New(2),
+			want:     90,
+		},
+		{
+			name:           "special offer is cheaper than the member price",
+			sku:            skuA,
+			quantity:       *quantity.New(3),
+			want:           130,
+			wantOffersUsed: 1,
+		},
+		{
+			name:     "products without a member price are priced as normal",
+			sku:      skuB,
+			quantity: *quantity.New(2),
+			want:     60,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := rules.Members()
+			if got := members.GetPrice(tt.sku, tt.quantity); got != tt.want {
+				t.Errorf("MemberPricing.GetPrice() = %v, want %v", got, tt.want)
+			}
+			if got := members.OffersApplied(tt.sku, tt.quantity); got != tt.wantOffersUsed {
+				t.Errorf("MemberPricing.OffersApplied() = %v, want %v", got, tt.wantOffersUsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Joshswooft/thinkmoney-test/catalog"
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+var (
+	errInvalidPercent        = errors.New("percentage must be between 1 and 100")
+	errInvalidBundleQuantity = errors.New("mix and match quantity must be at least 2")
+	errInvalidBundlePrice    = errors.New("mix and match price must be positive")
+	errNoTarget              = errors.New("promotion must target a category or tags")
+	errNoProducts            = errors.New("promotion needs a catalog to look up categories and tags")
+)
+
+// Line is a basket line after per sku pricing, basket wide promotions are evaluated against these
+type Line struct {
+	SKU      sku.SKU
+	Quantity quantity.Quantity
+	// list price of a single item
+	UnitPrice currency.Pence
+	// price of the whole line after per sku pricing e.g. multi-buys, promotions work from what is charged
+	Price currency.Pence
+}
+
+// Discount taken off the basket by a promotion
+type Discount struct {
+	Promotion string
+	Amount    currency.Pence
+	// the products the discount was applied to
+	SKUs []sku.SKU
+	// how much of the amount came off each product, promotions which don't say are split by what each line costs
+	Amounts map[sku.SKU]currency.Pence
+}
+
+// Promotion is a basket wide rule which can span many skus
+type Promotion interface {
+	Name() string
+	// works out the discount for the whole basket, ok is false when the promotion doesn't apply
+	Apply(lines []Line) (discount Discount, ok bool)
+}
+
+// Products looks up the category and tags of a sku, the catalog satisfies this
+type Products interface {
+	Product(sku sku.SKU) (catalog.Product, error)
+}
+
+// Target picks the products a promotion applies to.
+// A product matches when it is in the category (if one is given) and has every one of the tags (if any are given).
+type Target struct {
+	Category string
+	Tags     []string
+}
+
+func (t Target) validate() error {
+	if t.Category == "" && len(t.Tags) == 0 {
+		return errNoTarget
+	}
+	return nil
+}
+
+func (t Target) matches(p catalog.Product) bool {
+	if t.Category != "" && p.Category != t.Category {
+		return false
+	}
+	for _, tag := range t.Tags {
+		if !p.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether the sku is targeted, unknown products never match
+func (t Target) Matches(products Products, sku sku.SKU) bool {
+	p, err := products.Product(sku)
+	return err == nil && t.matches(p)
+}
+
+// filters the lines down to the targeted products
+func (t Target) lines(products Products, lines []Line) []Line {
+	matched := make([]Line, 0, len(lines))
+	for _, line := range lines {
+		if line.Quantity.Value() > 0 && t.Matches(products, line.SKU) {
+			matched = append(matched, line)
+		}
+	}
+	return matched
+}
+
+func skusOf(lines []Line) []sku.SKU {
+	skus := make([]sku.SKU, len(lines))
+	for i, line := range lines {
+		skus[i] = line.SKU
+	}
+	return skus
+}
+
+// splits the amount in proportion to the weights, the pennies left from rounding down go to the first weights
+func apportion(amount currency.Pence, weights []currency.Pence) []currency.Pence {
+	shares := make([]currency.Pence, len(weights))
+
+	total := currency.Pence(0)
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return shares
+	}
+
+	left := amount
+	for i, w := range weights {
+		shares[i] = amount * w / total
+		left -= shares[i]
+	}
+	for i := 0; left > 0 && i < len(shares); i++ {
+		if weights[i] > 0 {
+			shares[i]++
+			left--
+		}
+	}
+	return shares
+}
+
+// PercentOff takes a percentage off every targeted product e.g. "10% off all bakery"
+type PercentOff struct {
+	Label    string
+	Target   Target
+	Percent  int
+	Products Products
+}
+
+func NewPercentOff(label string, target Target, percent int, products Products) (*PercentOff, error) {
+	if err := target.validate(); err != nil {
+		return nil, err
+	}
+	if products == nil {
+		return nil, errNoProducts
+	}
+	if percent < 1 || percent > 100 {
+		return nil, fmt.Errorf("%w: %d", errInvalidPercent, percent)
+	}
+	return &PercentOff{Label: label, Target: target, Percent: percent, Products: products}, nil
+}
+
+func (p *PercentOff) Name() string {
+	return p.Label
+}
+
+// Targets reports whether the promotion applies to the sku
+func (p *PercentOff) Targets(sku sku.SKU) bool {
+	return p.Target.Matches(p.Products, sku)
+}
+
+// the discount is rounded down to the nearest penny
+func (p *PercentOff) Apply(lines []Line) (Discount, bool) {
+	matched := p.Target.lines(p.Products, lines)
+
+	spend := currency.Pence(0)
+	for _, line := range matched {
+		spend += line.Price
+	}
+
+	amount := spend * currency.Pence(p.Percent) / 100
+	if amount <= 0 {
+		return Discount{}, false
+	}
+
+	prices := make([]currency.Pence, len(matched))
+	for i, line := range matched {
+		prices[i] = line.Price
+	}
+
+	amounts := make(map[sku.SKU]currency.Pence, len(matched))
+	for i, share := range apportion(amount, prices) {
+		amounts[matched[i].SKU] += share
+	}
+
+	return Discount{Promotion: p.Label, Amount: amount, SKUs: skusOf(matched), Amounts: amounts}, true
+}
+
+// MixAndMatch sells any Quantity of the targeted products for a fixed price e.g. "any 3 fruit for £1"
+// the cheapest qualifying items are put into bundles first. Items are priced at what their line charges so items on a
+// multi-buy are never taken below the bundle price.
+type MixAndMatch struct {
+	Label    string
+	Target   Target
+	Quantity int
+	Price    currency.Pence
+	Products Products
+}
+
+func NewMixAndMatch(label string, target Target, qty int, price currency.Pence, products Products) (*MixAndMatch, error) {
+	if err := target.validate(); err != nil {
+		return nil, err
+	}
+	if products == nil {
+		return nil, errNoProducts
+	}
+	if qty < 2 {
+		return nil, fmt.Errorf("%w: %d", errInvalidBundleQuantity, qty)
+	}
+	if price <= 0 {
+		return nil, fmt.Errorf("%w: %d", errInvalidBundlePrice, price)
+	}
+	return &MixAndMatch{Label: label, Target: target, Quantity: qty, Price: price, Products: products}, nil
+}
+
+func (m *MixAndMatch) Name() string {
+	return m.Label
+}
+
+// Targets reports whether the promotion applies to the sku
+func (m *MixAndMatch) Targets(sku sku.SKU) bool {
+	return m.Target.Matches(m.Products, sku)
+}
+
+func (m *MixAndMatch) Apply(lines []Line) (Discount, bool) {
+	if m.Quantity < 2 {
+		return Discount{}, false
+	}
+
+	type unit struct {
+		sku   sku.SKU
+		price currency.Pence
+	}
+
+	matched := m.Target.lines(m.Products, lines)
+
+	var units []unit
+	for _, line := range matched {
+		each := make([]currency.Pence, line.Quantity.Value())
+		for i := range each {
+			each[i] = 1
+		}
+		for _, price := range apportion(line.Price, each) {
+			units = append(units, unit{sku: line.SKU, price: price})
+		}
+	}
+
+	// cheapest first, ties broken by sku so the result is stable
+	sort.SliceStable(units, func(i, j int) bool {
+		if units[i].price != units[j].price {
+			return units[i].price < units[j].price
+		}
+		return units[i].sku.Value() < units[j].sku.Value()
+	})
+
+	amount := currency.Pence(0)
+	amounts := make(map[sku.SKU]currency.Pence)
+	var skus []sku.SKU
+
+	bundles := len(units) / m.Quantity
+	for b := 0; b < bundles; b++ {
+		bundle := units[b*m.Quantity : (b+1)*m.Quantity]
+
+		full := currency.Pence(0)
+		prices := make([]currency.Pence, len(bundle))
+		for i, u := range bundle {
+			full += u.price
+			prices[i] = u.price
+		}
+
+		// a bundle that is already cheaper than the deal isn't discounted
+		if full <= m.Price {
+			continue
+		}
+
+		amount += full - m.Price
+		for i, share := range apportion(full-m.Price, prices) {
+			u := bundle[i]
+			if _, discounted := amounts[u.sku]; !discounted {
+				skus = append(skus, u.sku)
+			}
+			amounts[u.sku] += share
+		}
+	}
+
+	if amount <= 0 {
+		return Discount{}, false
+	}
+
+	return Discount{Promotion: m.Label, Amount: amount, SKUs: skus, Amounts: amounts}, true
+}
+
+// ApplyPromotions runs every promotion against the basket in order and returns the discounts along with their sum.
+// Each promotion uses up what it takes off the lines, so the next one only sees what is still charged and the same
+// units are never discounted twice. The discounts can never add up to more than the basket costs.
+func ApplyPromotions(lines []Line, promotions []Promotion) (discounts []Discount, amount currency.Pence) {
+	lines = append([]Line(nil), lines...)
+
+	subtotal := currency.Pence(0)
+	for _, line := range lines {
+		subtotal += line.Price
+	}
+
+	for _, promotion := range promotions {
+		discount, ok := promotion.Apply(lines)
+		if !ok {
+			continue
+		}
+
+		if discount.Amount > subtotal-amount {
+			discount.Amount = subtotal - amount
+		}
+		if discount.Amount <= 0 {
+			continue
+		}
+
+		discount.Amounts = consume(lines, discount)
+		amount += discount.Amount
+		discounts = append(discounts, discount)
+	}
+
+	return discounts, amount
+}
+
+// takes the discount off the lines it applies to and returns how much came off each product
+func consume(lines []Line, discount Discount) map[sku.SKU]currency.Pence {
+	var (
+		indexes []int
+		weights []currency.Pence
+	)
+	for i, line := range lines {
+		if discount.Amounts != nil {
+			if share := discount.Amounts[line.SKU]; share > 0 {
+				indexes = append(indexes, i)
+				weights = append(weights, share)
+			}
+			continue
+		}
+		for _, s := range discount.SKUs {
+			if s == line.SKU {
+				indexes = append(indexes, i)
+				weights = append(weights, line.Price)
+				break
+			}
+		}
+	}
+
+	// promotions which don't say which lines they came off are split across the basket by price
+	if len(indexes) == 0 {
+		for i, line := range lines {
+			indexes = append(indexes, i)
+			weights = append(weights, line.Price)
+		}
+	}
+
+	amounts := make(map[sku.SKU]currency.Pence, len(indexes))
+	for i, share := range apportion(discount.Amount, weights) {
+		line := &lines[indexes[i]]
+		share = min(share, line.Price)
+		line.Price -= share
+		amounts[line.SKU] += share
+	}
+	return amounts
+}
@@ -0,0 +1,275 @@
+package pricing
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/catalog"
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func skuGenerator(t *testing.T, r rune) sku.SKU {
+	s, err := sku.New(r)
+	if err != nil {
+		t.Fatalf("failed to make sku, input: %c, err: %v", r, err)
+	}
+	return s
+}
+
+// A, B and C are fruit, D and E are drinks and F is bakery
+func testCatalog(t *testing.T) *catalog.Catalog {
+	products, err := catalog.New(
+		catalog.Product{SKU: skuGenerator(t, 'A'), Name: "Apple", Category: "fruit"},
+		catalog.Product{SKU: skuGenerator(t, 'B'), Name: "Banana", Category: "fruit", Tags: []string{"tropical"}},
+		catalog.Product{SKU: skuGenerator(t, 'C'), Name: "Cherry", Category: "fruit"},
+		catalog.Product{SKU: skuGenerator(t, 'D'), Name: "Diet cola", Category: "drinks"},
+		catalog.Product{SKU: skuGenerator(t, 'E'), Name: "Elderflower", Category: "drinks"},
+		catalog.Product{SKU: skuGenerator(t, 'F'), Name: "Focaccia", Category: "bakery"},
+	)
+	if err != nil {
+		t.Fatalf("failed to create catalog: %v", err)
+	}
+	return products
+}
+
+func line(t *testing.T, r rune, qty int, unitPrice currency.Pence) Line {
+	return Line{SKU: skuGenerator(t, r), Quantity: *quantity.New(qty), UnitPrice: unitPrice, Price: unitPrice * currency.Pence(qty)}
+}
+
+func TestPercentOff_Apply(t *testing.T) {
+	products := testCatalog(t)
+
+	bakery, err := NewPercentOff("10% off bakery", Target{Category: "bakery"}, 10, products)
+	if err != nil {
+		t.Fatalf("NewPercentOff() error = %v", err)
+	}
+
+	tropical, err := NewPercentOff("half price tropical fruit", Target{Category: "fruit", Tags: []string{"tropical"}}, 50, products)
+	if err != nil {
+		t.Fatalf("NewPercentOff() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		promotion *PercentOff
+		lines     []Line
+		want      Discount
+		wantOk    bool
+	}{
+		{
+			name:      "discounts every targeted line and rounds down",
+			promotion: bakery,
+			lines:     []Line{line(t, 'F', 3, 125), line(t, 'A', 1, 50)},
+			want:      Discount{Promotion: "10% off bakery", Amount: 37, SKUs: []sku.SKU{skuGenerator(t, 'F')}, Amounts: map[sku.SKU]currency.Pence{skuGenerator(t, 'F'): 37}},
+			wantOk:    true,
+		},
+		{
+			name:      "products must have every tag",
+			promotion: tropical,
+			lines:     []Line{line(t, 'A', 1, 50), line(t, 'B', 2, 30)},
+			want:      Discount{Promotion: "half price tropical fruit", Amount: 30, SKUs: []sku.SKU{skuGenerator(t, 'B')}, Amounts: map[sku.SKU]currency.Pence{skuGenerator(t, 'B'): 30}},
+			wantOk:    true,
+		},
+		{
+			name:      "doesn't apply without targeted products",
+			promotion: bakery,
+			lines:     []Line{line(t, 'A', 1, 50), line(t, 'Z', 1, 50)},
+			wantOk:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.promotion.Apply(tt.lines)
+			if ok != tt.wantOk {
+				t.Fatalf("PercentOff.Apply() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PercentOff.Apply() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMixAndMatch_Apply(t *testing.T) {
+	products := testCatalog(t)
+
+	fruit, err := NewMixAndMatch("any 3 fruit for £1", Target{Category: "fruit"}, 3, 100, products)
+	if err != nil {
+		t.Fatalf("NewMixAndMatch() error = %v", err)
+	}
+
+	drinks, err := NewMixAndMatch("any 2 drinks for 150", Target{Category: "drinks"}, 2, 150, products)
+	if err != nil {
+		t.Fatalf("NewMixAndMatch() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		promotion *MixAndMatch
+		lines     []Line
+		want      currency.Pence
+		wantSKUs  []sku.SKU
+		wantOk    bool
+	}{
+		{
+			name:      "mixes different products into a bundle",
+			promotion: fruit,
+			lines:     []Line{line(t, 'A', 1, 50), line(t, 'B', 1, 40), line(t, 'C', 1, 60)},
+			want:      150 - 100,
+			wantSKUs:  []sku.SKU{skuGenerator(t, 'B'), skuGenerator(t, 'A'), skuGenerator(t, 'C')},
+			wantOk:    true,
+		},
+		{
+			name:      "cheapest items go into the bundle first",
+			promotion: drinks,
+			// D costs 80 and E 100, the bundle is D + D leaving E at full price
+			lines:    []Line{line(t, 'D', 2, 80), line(t, 'E', 1, 100)},
+			want:     160 - 150,
+			wantSKUs: []sku.SKU{skuGenerator(t, 'D')},
+			wantOk:   true,
+		},
+		{
+			name:      "not enough items for a bundle",
+			promotion: drinks,
+			lines:     []Line{line(t, 'D', 1, 80), line(t, 'A', 5, 50)},
+			wantOk:    false,
+		},
+		{
+			name:      "bundles already cheaper than the deal aren't discounted",
+			promotion: fruit,
+			lines:     []Line{line(t, 'A', 3, 20)},
+			wantOk:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.promotion.Apply(tt.lines)
+			if ok != tt.wantOk {
+				t.Fatalf("MixAndMatch.Apply() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if got.Amount != tt.want {
+				t.Errorf("MixAndMatch.Apply() amount = %d, want %d", got.Amount, tt.want)
+			}
+			if !reflect.DeepEqual(got.SKUs, tt.wantSKUs) {
+				t.Errorf("MixAndMatch.Apply() skus = %v, want %v", got.SKUs, tt.wantSKUs)
+			}
+		})
+	}
+}
+
+func TestApplyPromotions(t *testing.T) {
+	products := testCatalog(t)
+
+	halfPrice, _ := NewPercentOff("half price fruit", Target{Category: "fruit"}, 50, products)
+	allFree, _ := NewPercentOff("free fruit", Target{Category: "fruit"}, 100, products)
+
+	lines := []Line{line(t, 'A', 2, 50)}
+
+	discounts, amount := ApplyPromotions(lines, []Promotion{halfPrice, allFree})
+
+	// the second promotion can only take off what is left
+	skuA := skuGenerator(t, 'A')
+	want := []Discount{
+		{Promotion: "half price fruit", Amount: 50, SKUs: []sku.SKU{skuA}, Amounts: map[sku.SKU]currency.Pence{skuA: 50}},
+		{Promotion: "free fruit", Amount: 50, SKUs: []sku.SKU{skuA}, Amounts: map[sku.SKU]currency.Pence{skuA: 50}},
+	}
+
+	if !reflect.DeepEqual(discounts, want) {
+		t.Errorf("ApplyPromotions() discounts = %+v, want %+v", discounts, want)
+	}
+	if amount != 100 {
+		t.Errorf("ApplyPromotions() amount = %d, want %d", amount, 100)
+	}
+	if lines[0].Price != 100 {
+		t.Errorf("ApplyPromotions() changed the lines it was given")
+	}
+}
+
+func TestApplyPromotions_stacking(t *testing.T) {
+	products := testCatalog(t)
+
+	anyThree, _ := NewMixAndMatch("any 3 fruit for £1", Target{Category: "fruit"}, 3, 100, products)
+	halfPrice, _ := NewPercentOff("half price fruit", Target{Category: "fruit"}, 50, products)
+
+	// three A's on "3 for 130"
+	multiBuy := Line{SKU: skuGenerator(t, 'A'), Quantity: *quantity.New(3), UnitPrice: 50, Price: 130}
+
+	tests := []struct {
+		name       string
+		lines      []Line
+		promotions []Promotion
+		want       currency.Pence
+	}{
+		{
+			name:       "mix and match starts from what the multi-buy charges",
+			lines:      []Line{multiBuy},
+			promotions: []Promotion{anyThree},
+			want:       130 - 100,
+		},
+		{
+			name:       "units already discounted by a promotion aren't discounted again",
+			lines:      []Line{line(t, 'A', 3, 50)},
+			promotions: []Promotion{halfPrice, anyThree},
+			want:       75,
+		},
+		{
+			name:       "the second promotion takes what is left over",
+			lines:      []Line{line(t, 'A', 3, 60)},
+			promotions: []Promotion{anyThree, halfPrice},
+			want:       80 + 50,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := ApplyPromotions(tt.lines, tt.promotions); got != tt.want {
+				t.Errorf("ApplyPromotions() amount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPromotion_errors(t *testing.T) {
+	products := testCatalog(t)
+
+	tests := []struct {
+		name    string
+		create  func() error
+		wantErr error
+	}{
+		{
+			name:    "percent off needs a target",
+			create:  func() error { _, err := NewPercentOff("x", Target{}, 10, products); return err },
+			wantErr: errNoTarget,
+		},
+		{
+			name:    "percent off must be a sensible percentage",
+			create:  func() error { _, err := NewPercentOff("x", Target{Category: "fruit"}, 101, products); return err },
+			wantErr: errInvalidPercent,
+		},
+		{
+			name:    "mix and match needs more than one item",
+			create:  func() error { _, err := NewMixAndMatch("x", Target{Category: "fruit"}, 1, 100, products); return err },
+			wantErr: errInvalidBundleQuantity,
+		},
+		{
+			name:    "mix and match needs a price",
+			create:  func() error { _, err := NewMixAndMatch("x", Target{Category: "fruit"}, 2, 0, products); return err },
+			wantErr: errInvalidBundlePrice,
+		},
+		{
+			name:    "promotions need products",
+			create:  func() error { _, err := NewMixAndMatch("x", Target{Category: "fruit"}, 2, 100, nil); return err },
+			wantErr: errNoProducts,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.create(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
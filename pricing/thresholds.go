@@ -0,0 +1,143 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+)
+
+var (
+	errInvalidSpend     = errors.New("spend threshold must be positive")
+	errInvalidAmountOff = errors.New("amount off must be positive")
+)
+
+// Measure is the spend a threshold is compared against
+type Measure int
+
+const (
+	// the basket after per sku pricing e.g. multi-buys but before any basket wide promotions
+	MeasureBeforePromotions Measure = iota
+	// the basket after per sku pricing and basket wide promotions
+	MeasureAfterPromotions
+)
+
+func (m Measure) String() string {
+	switch m {
+	case MeasureBeforePromotions:
+		return "before promotions"
+	case MeasureAfterPromotions:
+		return "after promotions"
+	default:
+		return "unknown"
+	}
+}
+
+// Spend is what the basket costs at each stage of pricing
+type Spend struct {
+	BeforePromotions currency.Pence
+	AfterPromotions  currency.Pence
+}
+
+func (s Spend) measure(m Measure) currency.Pence {
+	if m == MeasureAfterPromotions {
+		return s.AfterPromotions
+	}
+	return s.BeforePromotions
+}
+
+// SpendThreshold is a basket level discount once the customer spends enough e.g. "£5 off when you spend £40"
+// or "10% off orders over £50". Spend is compared using Measure and the discount always comes off the total
+// after basket wide promotions.
+type SpendThreshold struct {
+	Label   string
+	Spend   currency.Pence
+	Measure Measure
+	// fixed amount taken off, used when Percent is 0
+	AmountOff currency.Pence
+	Percent   int
+}
+
+func NewAmountOffThreshold(label string, spend currency.Pence, amountOff currency.Pence, measure Measure) (*SpendThreshold, error) {
+	if spend <= 0 {
+		return nil, fmt.Errorf("%w: %d", errInvalidSpend, spend)
+	}
+	if amountOff <= 0 {
+		return nil, fmt.Errorf("%w: %d", errInvalidAmountOff, amountOff)
+	}
+	return &SpendThreshold{Label: label, Spend: spend, Measure: measure, AmountOff: amountOff}, nil
+}
+
+func NewPercentOffThreshold(label string, spend currency.Pence, percent int, measure Measure) (*SpendThreshold, error) {
+	if spend <= 0 {
+		return nil, fmt.Errorf("%w: %d", errInvalidSpend, spend)
+	}
+	if percent < 1 || percent > 100 {
+		return nil, fmt.Errorf("%w: %d", errInvalidPercent, percent)
+	}
+	return &SpendThreshold{Label: label, Spend: spend, Measure: measure, Percent: percent}, nil
+}
+
+// Qualifies reports whether the spend has reached the threshold
+func (t *SpendThreshold) Qualifies(spend Spend) bool {
+	return spend.measure(t.Measure) >= t.Spend
+}
+
+// discount taken off a total, percentages are rounded down to the nearest penny and it is never more than the total
+func (t *SpendThreshold) discountOn(total currency.Pence) currency.Pence {
+	amount := t.AmountOff
+	if t.Percent > 0 {
+		amount = total * currency.Pence(t.Percent) / 100
+	}
+	if amount > total {
+		amount = total
+	}
+	return amount
+}
+
+// ApplyThresholds picks the threshold which saves the customer the most, thresholds don't stack with each other
+func ApplyThresholds(spend Spend, thresholds []*SpendThreshold) (Discount, bool) {
+	best := Discount{}
+	for _, t := range thresholds {
+		if !t.Qualifies(spend) {
+			continue
+		}
+		if amount := t.discountOn(spend.AfterPromotions); amount > best.Amount {
+			best = Discount{Promotion: t.Label, Amount: amount}
+		}
+	}
+	return best, best.Amount > 0
+}
+
+// ThresholdProgress is how far a basket is from a threshold
+type ThresholdProgress struct {
+	Threshold *SpendThreshold
+	// how much more has to be spent, measured the same way as the threshold
+	Remaining currency.Pence
+}
+
+// NextThreshold finds the closest threshold the customer hasn't reached yet which would save them more than they
+// already get, ok is false when there isn't one
+func NextThreshold(spend Spend, thresholds []*SpendThreshold) (progress ThresholdProgress, ok bool) {
+	current, _ := ApplyThresholds(spend, thresholds)
+
+	var candidates []ThresholdProgress
+	for _, t := range thresholds {
+		if t.Qualifies(spend) {
+			continue
+		}
+		// the least it could save is when the customer spends exactly enough to reach it
+		if t.discountOn(t.Spend) <= current.Amount {
+			continue
+		}
+		candidates = append(candidates, ThresholdProgress{Threshold: t, Remaining: t.Spend - spend.measure(t.Measure)})
+	}
+
+	if len(candidates) == 0 {
+		return ThresholdProgress{}, false
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Remaining < candidates[j].Remaining })
+	return candidates[0], true
+}
@@ -0,0 +1,160 @@
+package pricing
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+)
+
+func thresholds(t *testing.T, measure Measure) []*SpendThreshold {
+	fiver, err := NewAmountOffThreshold("£5 off £40", 4000, 500, measure)
+	if err != nil {
+		t.Fatalf("NewAmountOffThreshold() error = %v", err)
+	}
+	tenPercent, err := NewPercentOffThreshold("15% off £50", 5000, 15, measure)
+	if err != nil {
+		t.Fatalf("NewPercentOffThreshold() error = %v", err)
+	}
+	return []*SpendThreshold{fiver, tenPercent}
+}
+
+func TestApplyThresholds(t *testing.T) {
+	tests := []struct {
+		name    string
+		measure Measure
+		spend   Spend
+		want    Discount
+		wantOk  bool
+	}{
+		{
+			name:    "below every threshold",
+			measure: MeasureBeforePromotions,
+			spend:   Spend{BeforePromotions: 3999, AfterPromotions: 3999},
+			wantOk:  false,
+		},
+		{
+			name:    "exactly on a threshold qualifies",
+			measure: MeasureBeforePromotions,
+			spend:   Spend{BeforePromotions: 4000, AfterPromotions: 4000},
+			want:    Discount{Promotion: "£5 off £40", Amount: 500},
+			wantOk:  true,
+		},
+		{
+			name:    "the best threshold wins and they don't stack",
+			measure: MeasureBeforePromotions,
+			spend:   Spend{BeforePromotions: 6000, AfterPromotions: 6000},
+			want:    Discount{Promotion: "15% off £50", Amount: 900},
+			wantOk:  true,
+		},
+		{
+			name:    "percentages come off the total after promotions",
+			measure: MeasureBeforePromotions,
+			spend:   Spend{BeforePromotions: 6000, AfterPromotions: 5990},
+			want:    Discount{Promotion: "15% off £50", Amount: 898},
+			wantOk:  true,
+		},
+		{
+			name:    "measured before promotions ignores promotions",
+			measure: MeasureBeforePromotions,
+			spend:   Spend{BeforePromotions: 4200, AfterPromotions: 3800},
+			want:    Discount{Promotion: "£5 off £40", Amount: 500},
+			wantOk:  true,
+		},
+		{
+			name:    "measured after promotions",
+			measure: MeasureAfterPromotions,
+			spend:   Spend{BeforePromotions: 4200, AfterPromotions: 3800},
+			wantOk:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ApplyThresholds(tt.spend, thresholds(t, tt.measure))
+			if ok != tt.wantOk {
+				t.Fatalf("ApplyThresholds() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyThresholds() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextThreshold(t *testing.T) {
+	tests := []struct {
+		name          string
+		measure       Measure
+		spend         Spend
+		wantLabel     string
+		wantRemaining currency.Pence
+		wantOk        bool
+	}{
+		{
+			name:          "closest threshold",
+			measure:       MeasureBeforePromotions,
+			spend:         Spend{BeforePromotions: 3500, AfterPromotions: 3500},
+			wantLabel:     "£5 off £40",
+			wantRemaining: 500,
+			wantOk:        true,
+		},
+		{
+			name:          "next threshold once one is reached",
+			measure:       MeasureBeforePromotions,
+			spend:         Spend{BeforePromotions: 4500, AfterPromotions: 4500},
+			wantLabel:     "15% off £50",
+			wantRemaining: 500,
+			wantOk:        true,
+		},
+		{
+			name:          "remaining is measured the same way as the threshold",
+			measure:       MeasureAfterPromotions,
+			spend:         Spend{BeforePromotions: 3900, AfterPromotions: 3500},
+			wantLabel:     "£5 off £40",
+			wantRemaining: 500,
+			wantOk:        true,
+		},
+		{
+			name:    "every threshold reached",
+			measure: MeasureBeforePromotions,
+			spend:   Spend{BeforePromotions: 5000, AfterPromotions: 5000},
+			wantOk:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NextThreshold(tt.spend, thresholds(t, tt.measure))
+			if ok != tt.wantOk {
+				t.Fatalf("NextThreshold() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if got.Threshold.Label != tt.wantLabel || got.Remaining != tt.wantRemaining {
+				t.Errorf("NextThreshold() = %s with %d remaining, want %s with %d remaining", got.Threshold.Label, got.Remaining, tt.wantLabel, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestNextThreshold_skipsSmallerSavings(t *testing.T) {
+	big, _ := NewAmountOffThreshold("£10 off £30", 3000, 1000, MeasureBeforePromotions)
+	small, _ := NewAmountOffThreshold("£1 off £40", 4000, 100, MeasureBeforePromotions)
+
+	if got, ok := NextThreshold(Spend{BeforePromotions: 3500, AfterPromotions: 3500}, []*SpendThreshold{big, small}); ok {
+		t.Errorf("NextThreshold() = %+v, want no threshold", got)
+	}
+}
+
+func TestNewThreshold_errors(t *testing.T) {
+	if _, err := NewAmountOffThreshold("x", 0, 500, MeasureBeforePromotions); !errors.Is(err, errInvalidSpend) {
+		t.Errorf("error = %v, want %v", err, errInvalidSpend)
+	}
+	if _, err := NewAmountOffThreshold("x", 4000, 0, MeasureBeforePromotions); !errors.Is(err, errInvalidAmountOff) {
+		t.Errorf("error = %v, want %v", err, errInvalidAmountOff)
+	}
+	if _, err := NewPercentOffThreshold("x", 4000, 0, MeasureBeforePromotions); !errors.Is(err, errInvalidPercent) {
+		t.Errorf("error = %v, want %v", err, errInvalidPercent)
+	}
+}
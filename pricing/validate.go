@@ -0,0 +1,203 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// Severity of a problem found in a pricing config
+type Severity int
+
+const (
+	// the pricing works but probably isn't what was meant
+	SeverityWarning Severity = iota + 1
+	// the pricing is wrong and shouldn't be used
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// codes for each kind of problem so they can be filtered
+const (
+	ProblemUnknownSKU             = "unknown_sku"
+	ProblemInactiveProduct        = "inactive_product"
+	ProblemNonPositivePrice       = "non_positive_price"
+	ProblemMissingSpecialQuantity = "missing_special_quantity"
+	ProblemNegativeQuantity       = "negative_quantity"
+	ProblemMissingSpecialPrice    = "missing_special_price"
+	ProblemOfferNoSaving          = "offer_no_saving"
+	ProblemMemberPriceNoSaving    = "member_price_no_saving"
+	ProblemOverlappingPromotions  = "overlapping_promotions"
+	ProblemPromotionOverlapsOffer = "promotion_overlaps_offer"
+)
+
+// Problem is something wrong with a sku's pricing
+type Problem struct {
+	SKU      sku.SKU
+	Severity Severity
+	Code     string
+	Message  string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: sku %s: %s (%s)", p.Severity, p.SKU, p.Message, p.Code)
+}
+
+// Problems found by Validate
+type Problems []Problem
+
+// Errors returns only the problems with SeverityError
+func (p Problems) Errors() Problems {
+	var errs Problems
+	for _, problem := range p {
+		if problem.Severity == SeverityError {
+			errs = append(errs, problem)
+		}
+	}
+	return errs
+}
+
+// Err returns a *ValidationError when there are any errors, warnings on their own aren't an error
+func (p Problems) Err() error {
+	if errs := p.Errors(); len(errs) > 0 {
+		return &ValidationError{Problems: errs}
+	}
+	return nil
+}
+
+var ErrInvalidPricing = errors.New("invalid pricing")
+
+// ValidationError is returned when pricing has problems with SeverityError
+type ValidationError struct {
+	Problems Problems
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		messages[i] = problem.String()
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidPricing, strings.Join(messages, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidPricing
+}
+
+// ValidateOption adds extra checks to Validate
+type ValidateOption func(*validator)
+
+type validator struct {
+	products   Products
+	promotions []Promotion
+}
+
+// CheckCatalog reports skus which are priced but missing from the catalog or no longer sold
+func CheckCatalog(products Products) ValidateOption {
+	return func(v *validator) {
+		v.products = products
+	}
+}
+
+// CheckPromotions reports skus where basket wide promotions overlap each other or a sku's own offer
+// only promotions which can say which skus they target (e.g. PercentOff, MixAndMatch) are checked
+func CheckPromotions(promotions ...Promotion) ValidateOption {
+	return func(v *validator) {
+		v.promotions = append(v.promotions, promotions...)
+	}
+}
+
+// targeted is a promotion which can say which skus it applies to
+type targeted interface {
+	Targets(sku sku.SKU) bool
+}
+
+// Validate runs over every sku in the pricing and returns all the problems it finds, sorted by sku
+func Validate(p *SpecialPricing, opts ...ValidateOption) Problems {
+	v := &validator{}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	var problems Problems
+	add := func(item sku.SKU, severity Severity, code, format string, args ...any) {
+		problems = append(problems, Problem{SKU: item, Severity: severity, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	items := make([]sku.SKU, 0, len(p.Config))
+	for item := range p.Config {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Value() < items[j].Value() })
+
+	for _, item := range items {
+		data := p.Config[item]
+		specialQuantity := data.SpecialQuantity.Value()
+
+		if data.UnitPrice <= 0 {
+			add(item, SeverityError, ProblemNonPositivePrice, "unit price is %d", data.UnitPrice)
+		}
+
+		if data.MemberPrice < 0 {
+			add(item, SeverityError, ProblemNonPositivePrice, "member price is %d", data.MemberPrice)
+		}
+		if data.MemberPrice > 0 && data.MemberPrice >= data.UnitPrice {
+			add(item, SeverityWarning, ProblemMemberPriceNoSaving, "member price %d is not less than the unit price %d", data.MemberPrice, data.UnitPrice)
+		}
+
+		if data.HasSpecialOffer() {
+			switch {
+			case data.SpecialPrice <= 0:
+				add(item, SeverityError, ProblemMissingSpecialPrice, "offer for %d has a special price of %d", specialQuantity, data.SpecialPrice)
+			case specialQuantity == 0:
+				add(item, SeverityError, ProblemMissingSpecialQuantity, "special price %d has no special quantity and is treated as 1 each", data.SpecialPrice)
+			default:
+				separately := data.UnitPrice * currency.Pence(specialQuantity)
+				if data.SpecialPrice > separately {
+					add(item, SeverityError, ProblemOfferNoSaving, "%d for %d costs more than buying them separately for %d", specialQuantity, data.SpecialPrice, separately)
+				} else if data.SpecialPrice == separately {
+					add(item, SeverityWarning, ProblemOfferNoSaving, "%d for %d is the same as buying them separately", specialQuantity, data.SpecialPrice)
+				}
+			}
+		}
+
+		if v.products != nil {
+			product, err := v.products.Product(item)
+			switch {
+			case err != nil:
+				add(item, SeverityWarning, ProblemUnknownSKU, "sku is priced but not in the catalog")
+			case product.Discontinued:
+				add(item, SeverityWarning, ProblemInactiveProduct, "%s is priced but no longer sold", product.Name)
+			}
+		}
+
+		var names []string
+		for _, promotion := range v.promotions {
+			if t, ok := promotion.(targeted); ok && t.Targets(item) {
+				names = append(names, promotion.Name())
+			}
+		}
+		if len(names) > 1 {
+			add(item, SeverityWarning, ProblemOverlappingPromotions, "targeted by more than one promotion: %s", strings.Join(names, ", "))
+		}
+		if len(names) > 0 && data.HasSpecialOffer() {
+			add(item, SeverityWarning, ProblemPromotionOverlapsOffer, "has its own offer and is targeted by %s", strings.Join(names, ", "))
+		}
+	}
+
+	return problems
+}
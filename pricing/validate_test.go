@@ -0,0 +1,144 @@
+package pricing
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/catalog"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+type problemSummary struct {
+	SKU      rune
+	Severity Severity
+	Code     string
+}
+
+func summarise(problems Problems) []problemSummary {
+	summaries := []problemSummary{}
+	for _, p := range problems {
+		summaries = append(summaries, problemSummary{SKU: p.SKU.Value(), Severity: p.Severity, Code: p.Code})
+	}
+	return summaries
+}
+
+func TestValidate(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	tests := []struct {
+		name string
+		data PricingData
+		want []problemSummary
+	}{
+		{
+			name: "valid multi-buy",
+			data: PricingData{UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3), MemberPrice: 45},
+			want: []problemSummary{},
+		},
+		{
+			name: "price for the day with a special quantity of 1",
+			data: PricingData{UnitPrice: 50, SpecialPrice: 35, SpecialQuantity: *quantity.New(1)},
+			want: []problemSummary{},
+		},
+		{
+			name: "non-positive unit price",
+			data: PricingData{UnitPrice: 0},
+			want: []problemSummary{{'A', SeverityError, ProblemNonPositivePrice}},
+		},
+		{
+			name: "special price without a special quantity",
+			data: PricingData{UnitPrice: 50, SpecialPrice: 35},
+			want: []problemSummary{{'A', SeverityError, ProblemMissingSpecialQuantity}},
+		},
+		{
+			name: "special quantity without a special price",
+			data: PricingData{UnitPrice: 50, SpecialQuantity: *quantity.New(3)},
+			want: []problemSummary{{'A', SeverityError, ProblemMissingSpecialPrice}},
+		},
+		{
+			name: "deal costs more than buying separately",
+			data: PricingData{UnitPrice: 50, SpecialPrice: 160, SpecialQuantity: *quantity.New(3)},
+			want: []problemSummary{{'A', SeverityError, ProblemOfferNoSaving}},
+		},
+		{
+			name: "deal costs the same as buying separately",
+			data: PricingData{UnitPrice: 50, SpecialPrice: 150, SpecialQuantity: *quantity.New(3)},
+			want: []problemSummary{{'A', SeverityWarning, ProblemOfferNoSaving}},
+		},
+		{
+			name: "member price doesn't save anything",
+			data: PricingData{UnitPrice: 50, MemberPrice: 55},
+			want: []problemSummary{{'A', SeverityWarning, ProblemMemberPriceNoSaving}},
+		},
+		{
+			name: "every problem is reported",
+			data: PricingData{UnitPrice: -1, MemberPrice: -1, SpecialQuantity: *quantity.New(2)},
+			want: []problemSummary{
+				{'A', SeverityError, ProblemNonPositivePrice},
+				{'A', SeverityError, ProblemNonPositivePrice},
+				{'A', SeverityError, ProblemMissingSpecialPrice},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := Validate(&SpecialPricing{Config: map[sku.SKU]PricingData{skuA: tt.data}})
+			if got := summarise(problems); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_catalogAndPromotions(t *testing.T) {
+	products := testCatalog(t)
+	if err := products.Put(catalog.Product{SKU: skuGenerator(t, 'G'), Name: "Grapes", Category: "fruit", Discontinued: true}); err != nil {
+		t.Fatalf("failed to add product: %v", err)
+	}
+
+	fruit, _ := NewPercentOff("10% off fruit", Target{Category: "fruit"}, 10, products)
+	tropical, _ := NewMixAndMatch("any 2 tropical for 50", Target{Tags: []string{"tropical"}}, 2, 50, products)
+
+	rules := &SpecialPricing{Config: map[sku.SKU]PricingData{
+		skuGenerator(t, 'A'): {UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3)},
+		skuGenerator(t, 'B'): {UnitPrice: 30},
+		skuGenerator(t, 'D'): {UnitPrice: 80},
+		skuGenerator(t, 'G'): {UnitPrice: 90},
+		skuGenerator(t, 'Z'): {UnitPrice: 10},
+	}}
+
+	problems := Validate(rules, CheckCatalog(products), CheckPromotions(fruit, tropical))
+
+	want := []problemSummary{
+		{'A', SeverityWarning, ProblemPromotionOverlapsOffer},
+		{'B', SeverityWarning, ProblemOverlappingPromotions},
+		{'G', SeverityWarning, ProblemInactiveProduct},
+		{'Z', SeverityWarning, ProblemUnknownSKU},
+	}
+
+	if got := summarise(problems); !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %v, want %v", problems, want)
+	}
+
+	if err := problems.Err(); err != nil {
+		t.Errorf("warnings on their own shouldn't be an error, got %v", err)
+	}
+}
+
+func TestLoad_validates(t *testing.T) {
+	input := `{"prices": [{"sku": "A", "unit_price": 50, "special_price": 160, "special_quantity": 3}, {"sku": "B", "unit_price": 0}]}`
+
+	_, err := Load(strings.NewReader(input))
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || !errors.Is(err, ErrInvalidPricing) {
+		t.Fatalf("Load() error = %v, want a validation error", err)
+	}
+
+	if len(validationErr.Problems) != 2 {
+		t.Errorf("Load() problems = %v, want 2", validationErr.Problems)
+	}
+}
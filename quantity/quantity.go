@@ -1,6 +1,9 @@
 package quantity
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // A product quantity is the positive count of the item and therefore cant be initialized below zero
 type Quantity struct {
@@ -40,3 +43,19 @@ func (q *Quantity) Add(amount int) int {
 func (q *Quantity) String() string {
 	return fmt.Sprintf("%d", q.value)
 }
+
+// MarshalJSON encodes the quantity as a plain number
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.value)
+}
+
+// UnmarshalJSON decodes a plain number, negative numbers become zero just like New()
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	var value int
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*q = *New(value)
+	return nil
+}
@@ -1,6 +1,8 @@
 package quantity
 
 import (
+	"encoding/json"
+	"fmt"
 	"testing"
 )
 
@@ -74,3 +76,35 @@ func TestQuantity_Add(t *testing.T) {
 		})
 	}
 }
+
+func TestQuantity_JSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		json      string
+		wantValue int
+		wantErr   bool
+	}{
+		{name: "decodes a number", json: "7", wantValue: 7},
+		{name: "negative numbers become zero", json: "-3", wantValue: 0},
+		{name: "rejects anything that isn't a number", json: `"7"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q Quantity
+			err := json.Unmarshal([]byte(tt.json), &q)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Quantity.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if q.value != tt.wantValue {
+				t.Errorf("Quantity.UnmarshalJSON() = %d, want %d", q.value, tt.wantValue)
+			}
+
+			if err == nil {
+				data, _ := json.Marshal(q)
+				if string(data) != fmt.Sprint(tt.wantValue) {
+					t.Errorf("Quantity.MarshalJSON() = %s, want %d", data, tt.wantValue)
+				}
+			}
+		})
+	}
+}
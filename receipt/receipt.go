@@ -0,0 +1,217 @@
+package receipt
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// Kind of receipt
+type Kind int
+
+const (
+	Sale Kind = iota + 1
+	Refund
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Sale:
+		return "sale"
+	case Refund:
+		return "refund"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText writes the kind by name in json
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Pricer prices a quantity of a product, the checkout's pricing rules satisfy this
+type Pricer interface {
+	GetPrice(sku sku.SKU, quantity quantity.Quantity) currency.Pence
+}
+
+// Line is a single product on the receipt
+// for a sale the price is what was charged for the line, for a refund it is what was given back
+type Line struct {
+	SKU      sku.SKU           `json:"sku"`
+	Name     string            `json:"name,omitempty"`
+	Quantity quantity.Quantity `json:"quantity"`
+	Price    currency.Pence    `json:"price"`
+}
+
+// Discount is a basket wide promotion taken off the total
+type Discount struct {
+	Description string         `json:"description"`
+	Amount      currency.Pence `json:"amount"`
+	// how much came off each product, refunds give back the share of the returned items.
+	// Without it the discount is split across the lines by price.
+	Amounts map[sku.SKU]currency.Pence `json:"amounts,omitempty"`
+}
+
+// Nudge tells the customer how much more they need to spend to get a saving
+type Nudge struct {
+	Description string         `json:"description"`
+	Remaining   currency.Pence `json:"remaining"`
+}
+
+// Suggestion is an offer the customer nearly qualified for e.g. "add 1 more A to save 20p"
+type Suggestion struct {
+	SKU    sku.SKU        `json:"sku"`
+	Name   string         `json:"name,omitempty"`
+	Add    int            `json:"add"`
+	Saving currency.Pence `json:"saving"`
+}
+
+// Receipt is the record of a sale or a refund
+type Receipt struct {
+	ID     string    `json:"id"`
+	Kind   Kind      `json:"kind"`
+	Issued time.Time `json:"issued"`
+	Lines  []Line    `json:"lines"`
+	// basket wide promotions, already taken off the total
+	Discounts []Discount `json:"discounts,omitempty"`
+	// amount the customer paid for a sale or was given back for a refund
+	Total currency.Pence `json:"total"`
+	// the sale a refund was made against, empty for sales
+	OriginalID string `json:"original_id,omitempty"`
+	// masked loyalty card the sale was made with
+	Member string `json:"member,omitempty"`
+	// price experiment and variant the sale was priced with e.g. "cheaper-b/trial"
+	Variant string `json:"variant,omitempty"`
+	// savings the customer nearly qualified for, only on sales
+	Nudges      []Nudge      `json:"nudges,omitempty"`
+	Suggestions []Suggestion `json:"suggestions,omitempty"`
+
+	// what each sku cost at every quantity up to the one sold, refunds are priced from it
+	prices map[sku.SKU][]currency.Pence
+	// the basket wide promotions and spend thresholds the sale was made under, refunds run them against what the
+	// customer keeps
+	promotions []pricing.Promotion
+	thresholds []*pricing.SpendThreshold
+}
+
+// a copy which shares nothing with the receipt, the store only hands out copies
+func (r *Receipt) clone() *Receipt {
+	c := *r
+	c.Lines = slices.Clone(r.Lines)
+	c.Discounts = slices.Clone(r.Discounts)
+	for i, discount := range c.Discounts {
+		c.Discounts[i].Amounts = maps.Clone(discount.Amounts)
+	}
+	c.Nudges = slices.Clone(r.Nudges)
+	c.Suggestions = slices.Clone(r.Suggestions)
+	c.promotions = slices.Clone(r.promotions)
+	c.thresholds = slices.Clone(r.thresholds)
+	c.prices = maps.Clone(r.prices)
+	for id, prices := range c.prices {
+		c.prices[id] = slices.Clone(prices)
+	}
+	return &c
+}
+
+// name printed on the receipt, falls back to the sku when the product name isn't known
+func (l Line) label() string {
+	if l.Name == "" {
+		return l.SKU.String()
+	}
+	// cut by characters so a multi byte name isn't split mid character
+	if name := []rune(l.Name); len(name) > 10 {
+		return string(name[:10])
+	}
+	return l.Name
+}
+
+// PriceLines prices each of the given quantities, the lines keep the order of the skus given
+func PriceLines(pricer Pricer, skus []sku.SKU, quantities map[sku.SKU]quantity.Quantity) []Line {
+	lines := make([]Line, 0, len(skus))
+	for _, s := range skus {
+		qty := quantities[s]
+		lines = append(lines, Line{SKU: s, Quantity: qty, Price: pricer.GetPrice(s, qty)})
+	}
+	return lines
+}
+
+// Reprice prices a copy of the receipt again e.g. with the prices in force when it was issued to check what was charged.
+// Sales to members are re-priced with member prices. Basket wide discounts aren't part of the pricing so they are kept
+// as they were and taken off the new total. Everything else is kept, including the variant the sale was priced with.
+func (r *Receipt) Reprice(pricer Pricer) *Receipt {
+	repriced := r.clone()
+
+	ctx := pricing.Context{Member: r.Member != "", Basket: make(map[sku.SKU]quantity.Quantity, len(r.Lines))}
+	for _, line := range r.Lines {
+		ctx.Basket[line.SKU] = line.Quantity
+	}
+
+	for i, line := range repriced.Lines {
+		repriced.Lines[i].Price = pricing.PriceIn(pricer, ctx, line.SKU, line.Quantity)
+	}
+
+	total := sumLines(repriced.Lines)
+	for _, discount := range repriced.Discounts {
+		total -= discount.Amount
+	}
+	repriced.Total = max(total, 0)
+
+	return repriced
+}
+
+func sumLines(lines []Line) currency.Pence {
+	total := currency.Pence(0)
+	for _, line := range lines {
+		total += line.Price
+	}
+	return total
+}
+
+// Format writes a plain text version of the receipt suitable for printing
+func (r *Receipt) Format(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %s\n", strings.ToUpper(r.Kind.String()), r.ID)
+	if r.OriginalID != "" {
+		fmt.Fprintf(&b, "against %s\n", r.OriginalID)
+	}
+	fmt.Fprintf(&b, "%s\n", r.Issued.Format(time.RFC3339))
+	if r.Member != "" {
+		fmt.Fprintf(&b, "member %s\n", r.Member)
+	}
+	if r.Variant != "" {
+		fmt.Fprintf(&b, "pricing %s\n", r.Variant)
+	}
+
+	for _, line := range r.Lines {
+		qty := line.Quantity
+		fmt.Fprintf(&b, "%-10s x%-4d %8d\n", line.label(), qty.Value(), line.Price)
+	}
+
+	for _, discount := range r.Discounts {
+		fmt.Fprintf(&b, "%-16.16s %8d\n", discount.Description, -discount.Amount)
+	}
+
+	fmt.Fprintf(&b, "%-16s %8d\n", "TOTAL", r.Total)
+
+	for _, nudge := range r.Nudges {
+		fmt.Fprintf(&b, "spend %d more for %s\n", nudge.Remaining, nudge.Description)
+	}
+
+	for _, suggestion := range r.Suggestions {
+		item := Line{SKU: suggestion.SKU, Name: suggestion.Name}
+		fmt.Fprintf(&b, "add %d more %s to save %dp\n", suggestion.Add, item.label(), suggestion.Saving)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,331 @@
+package receipt
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+var (
+	ErrReceiptNotFound    = errors.New("receipt not found")
+	ErrNotASale           = errors.New("refunds can only be made against a sale")
+	ErrNothingToRefund    = errors.New("no items were returned")
+	ErrReturnNotPurchased = errors.New("returned more items than are left on the receipt")
+)
+
+// Store issues receipts and keeps hold of them so refunds can be made later
+// simple in-memory store which is go-routine safe
+type Store struct {
+	mu       sync.RWMutex
+	receipts map[string]*Receipt
+	// refund receipts by the id of the sale they were made against
+	refunds map[string][]*Receipt
+	nextID  int
+	now     func() time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		receipts: make(map[string]*Receipt),
+		refunds:  make(map[string][]*Receipt),
+		now:      time.Now,
+	}
+}
+
+// SaleDetails is everything needed to issue a sale receipt
+type SaleDetails struct {
+	Lines []Line
+	// basket wide promotions taken off the lines
+	Discounts []Discount
+	// what the customer paid for the lines after any discounts
+	Total currency.Pence
+	// masked loyalty card number
+	Member      string
+	Nudges      []Nudge
+	Suggestions []Suggestion
+	// price experiment and variant the sale was priced with
+	Variant string
+	// the pricing the sale was made under, what each line would have cost at every quantity up to the one bought is
+	// kept with the receipt so refunds don't depend on today's prices. Without it refunds are in proportion to the price
+	// of the line.
+	Pricing Pricer `json:"-"`
+	// the basket wide promotions and spend thresholds the sale was made under, refunds run them again against what the
+	// customer keeps. Without them the customer keeps the discounts which came off the items they keep.
+	Promotions []pricing.Promotion       `json:"-"`
+	Thresholds []*pricing.SpendThreshold `json:"-"`
+}
+
+func (s *Store) issue(kind Kind, lines []Line, discounts []Discount, total currency.Pence, originalID string) *Receipt {
+	s.nextID++
+
+	r := &Receipt{
+		ID:         fmt.Sprintf("R%06d", s.nextID),
+		Kind:       kind,
+		Issued:     s.now(),
+		Lines:      lines,
+		Discounts:  discounts,
+		Total:      total,
+		OriginalID: originalID,
+	}
+	s.receipts[r.ID] = r
+
+	return r
+}
+
+// IssueSale records a sale
+func (s *Store) IssueSale(sale SaleDetails) *Receipt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := s.issue(Sale, sale.Lines, sale.Discounts, sale.Total, "")
+	r.Member = sale.Member
+	r.Variant = sale.Variant
+	r.Nudges = sale.Nudges
+	r.Suggestions = sale.Suggestions
+	r.prices = linePrices(sale.Lines, sale.Pricing)
+	r.promotions = sale.Promotions
+	r.thresholds = sale.Thresholds
+	return r.clone()
+}
+
+// what each sku on the lines cost for every quantity from none to the quantity bought
+func linePrices(lines []Line, pricing Pricer) map[sku.SKU][]currency.Pence {
+	bought := make(map[sku.SKU]int, len(lines))
+	charged := make(map[sku.SKU]currency.Pence, len(lines))
+	for _, line := range lines {
+		bought[line.SKU] += line.Quantity.Value()
+		charged[line.SKU] += line.Price
+	}
+
+	prices := make(map[sku.SKU][]currency.Pence, len(bought))
+	for id, n := range bought {
+		table := make([]currency.Pence, n+1)
+		for q := 1; q <= n; q++ {
+			if pricing != nil {
+				table[q] = pricing.GetPrice(id, *quantity.New(q))
+			} else {
+				table[q] = charged[id] * currency.Pence(q) / currency.Pence(n)
+			}
+		}
+		prices[id] = table
+	}
+	return prices
+}
+
+// Get a copy of a receipt by its id
+func (s *Store) Get(id string) (*Receipt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, found := s.receipts[id]
+	if !found {
+		return nil, ErrReceiptNotFound
+	}
+	return r.clone(), nil
+}
+
+// Refunds returns copies of every refund made against a sale, oldest first
+func (s *Store) Refunds(originalID string) []*Receipt {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	refunds := make([]*Receipt, len(s.refunds[originalID]))
+	for i, refund := range s.refunds[originalID] {
+		refunds[i] = refund.clone()
+	}
+	return refunds
+}
+
+// Refund returns items from an earlier sale and issues a refund receipt.
+//
+// The customer gets back what they paid less what the items they keep cost, re-priced with the prices, promotions and
+// spend thresholds kept from the sale. So they lose any offer they no longer qualify for e.g. returning one A from
+// "3 for 130" refunds 130 - 2×50, returning one item from "any 3 for 100" refunds nothing and returning enough to drop
+// below a spend threshold gives up its discount. Across all refunds the customer can never get back more than they paid.
+func (s *Store) Refund(originalID string, returns map[sku.SKU]quantity.Quantity) (*Receipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	original, found := s.receipts[originalID]
+	if !found {
+		return nil, ErrReceiptNotFound
+	}
+
+	if original.Kind != Sale {
+		return nil, ErrNotASale
+	}
+
+	// what the customer still has from the sale and how much they have paid for it
+	kept := make(map[sku.SKU]int)
+	for _, line := range original.Lines {
+		kept[line.SKU] += line.Quantity.Value()
+	}
+
+	netPaid := original.Total
+	for _, refund := range s.refunds[originalID] {
+		netPaid -= refund.Total
+		for _, line := range refund.Lines {
+			kept[line.SKU] -= line.Quantity.Value()
+		}
+	}
+
+	returned := make([]sku.SKU, 0, len(returns))
+	for item, qty := range returns {
+		if qty.Value() == 0 {
+			continue
+		}
+		if qty.Value() > kept[item] {
+			return nil, fmt.Errorf("%w: sku %s returned %d but only %d left", ErrReturnNotPurchased, item, qty.Value(), kept[item])
+		}
+		returned = append(returned, item)
+	}
+
+	if len(returned) == 0 {
+		return nil, ErrNothingToRefund
+	}
+
+	sort.Slice(returned, func(i, j int) bool { return returned[i].Value() < returned[j].Value() })
+
+	// each line is what its product cost before and after the return, the lines are then fitted to the total
+	lines := make([]Line, 0, len(returned))
+	for _, item := range returned {
+		qty := returns[item]
+		prices := original.prices[item]
+		lines = append(lines, Line{SKU: item, Quantity: qty, Price: prices[kept[item]] - prices[kept[item]-qty.Value()]})
+		kept[item] -= qty.Value()
+	}
+
+	total := max(netPaid-original.priceKept(kept), 0)
+	fitLines(lines, total)
+
+	refund := s.issue(Refund, lines, nil, total, originalID)
+	s.refunds[originalID] = append(s.refunds[originalID], refund)
+
+	return refund.clone(), nil
+}
+
+// what the items the customer keeps cost with the prices from the sale, less the basket wide discounts they still get
+func (r *Receipt) priceKept(kept map[sku.SKU]int) currency.Pence {
+	skus := make([]sku.SKU, 0, len(kept))
+	for id, n := range kept {
+		if n > 0 {
+			skus = append(skus, id)
+		}
+	}
+	// sorted by sku, the same order the checkout runs the promotions in
+	sort.Slice(skus, func(i, j int) bool { return skus[i].Value() < skus[j].Value() })
+
+	subtotal := currency.Pence(0)
+	lines := make([]pricing.Line, 0, len(skus))
+	for _, id := range skus {
+		prices := r.prices[id]
+		lines = append(lines, pricing.Line{SKU: id, Quantity: *quantity.New(kept[id]), UnitPrice: prices[1], Price: prices[kept[id]]})
+		subtotal += prices[kept[id]]
+	}
+
+	if r.promotions == nil && r.thresholds == nil {
+		// the discounts can't be worked out again so the customer keeps the share which came off what they keep
+		bought := make(map[sku.SKU]int)
+		for _, line := range r.Lines {
+			bought[line.SKU] += line.Quantity.Value()
+		}
+
+		total := subtotal
+		for id, discount := range r.discounted() {
+			total -= discount * currency.Pence(kept[id]) / currency.Pence(bought[id])
+		}
+		return total
+	}
+
+	_, amount := pricing.ApplyPromotions(lines, r.promotions)
+	spend := pricing.Spend{BeforePromotions: subtotal, AfterPromotions: subtotal - amount}
+
+	// a return can take the basket below a spend threshold it qualified for
+	total := spend.AfterPromotions
+	if threshold, ok := pricing.ApplyThresholds(spend, r.thresholds); ok {
+		total -= threshold.Amount
+	}
+	return total
+}
+
+// how much the basket wide discounts took off each sku
+func (r *Receipt) discounted() map[sku.SKU]currency.Pence {
+	discounted := make(map[sku.SKU]currency.Pence)
+	for _, discount := range r.Discounts {
+		if discount.Amounts != nil {
+			for id, amount := range discount.Amounts {
+				discounted[id] += amount
+			}
+			continue
+		}
+
+		weights := make([]currency.Pence, len(r.Lines))
+		for i, line := range r.Lines {
+			weights[i] = line.Price
+		}
+		for i, share := range apportion(discount.Amount, weights) {
+			discounted[r.Lines[i].SKU] += share
+		}
+	}
+	return discounted
+}
+
+// splits the amount in proportion to the weights, the pennies left from rounding down go to the first weights
+func apportion(amount currency.Pence, weights []currency.Pence) []currency.Pence {
+	shares := make([]currency.Pence, len(weights))
+
+	total := currency.Pence(0)
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return shares
+	}
+
+	left := amount
+	for i, w := range weights {
+		shares[i] = amount * w / total
+		left -= shares[i]
+	}
+	for i := 0; left > 0 && i < len(shares); i++ {
+		if weights[i] > 0 {
+			shares[i]++
+			left--
+		}
+	}
+	return shares
+}
+
+// moves the line prices up or down in proportion to their price so they add up to the total
+func fitLines(lines []Line, total currency.Pence) {
+	diff := total - sumLines(lines)
+	if diff == 0 {
+		return
+	}
+
+	weights := make([]currency.Pence, len(lines))
+	for i, line := range lines {
+		weights[i] = line.Price
+	}
+	if diff > 0 && sumLines(lines) <= 0 {
+		// nothing to weigh the lines by so they share it equally
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+
+	sign := currency.Pence(1)
+	if diff < 0 {
+		sign, diff = -1, -diff
+	}
+	for i, share := range apportion(diff, weights) {
+		lines[i].Price += sign * share
+	}
+}
@@ -0,0 +1,370 @@
+package receipt
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/catalog"
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// A is 50 or 3 for 130, B is 30, C is 50 and D is 1000
+type testPricer struct{}
+
+func (testPricer) GetPrice(s sku.SKU, qty quantity.Quantity) currency.Pence {
+	switch s.Value() {
+	case 'A':
+		return currency.Pence(qty.Value()/3)*130 + currency.Pence(qty.Value()%3)*50
+	case 'B':
+		return currency.Pence(qty.Value()) * 30
+	case 'C':
+		return currency.Pence(qty.Value()) * 50
+	case 'D':
+		return currency.Pence(qty.Value()) * 1000
+	default:
+		return 0
+	}
+}
+
+func skuGenerator(t *testing.T, r rune) sku.SKU {
+	s, err := sku.New(r)
+	if err != nil {
+		t.Fatalf("failed to make sku, input: %c, err: %v", r, err)
+	}
+	return s
+}
+
+func TestStore_Refund(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+	skuC := skuGenerator(t, 'C')
+	skuD := skuGenerator(t, 'D')
+
+	type refund struct {
+		returns   map[sku.SKU]quantity.Quantity
+		wantTotal currency.Pence
+		wantErr   error
+	}
+
+	products, err := catalog.New(
+		catalog.Product{SKU: skuA, Name: "Apple", Category: "fruit"},
+		catalog.Product{SKU: skuC, Name: "Cherry", Category: "fruit"},
+	)
+	if err != nil {
+		t.Fatalf("failed to create catalog: %v", err)
+	}
+
+	anyThreeFruit, err := pricing.NewMixAndMatch("any 3 fruit for 100", pricing.Target{Category: "fruit"}, 3, 100, products)
+	if err != nil {
+		t.Fatalf("failed to create promotion: %v", err)
+	}
+
+	fiveOff, err := pricing.NewAmountOffThreshold("500 off over 4000", 4000, 500, pricing.MeasureBeforePromotions)
+	if err != nil {
+		t.Fatalf("failed to create threshold: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		lines      []Line
+		discounts  []Discount
+		promotions []pricing.Promotion
+		thresholds []*pricing.SpendThreshold
+		total      currency.Pence
+		refunds    []refund
+	}{
+		{
+			name:  "returning one item from a multi-buy loses the offer",
+			lines: []Line{{SKU: skuA, Quantity: *quantity.New(3), Price: 130}},
+			total: 130,
+			refunds: []refund{
+				{returns: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(1)}, wantTotal: 130 - 2*50},
+			},
+		},
+		{
+			name:  "returning items over several refunds",
+			lines: []Line{{SKU: skuA, Quantity: *quantity.New(3), Price: 130}, {SKU: skuB, Quantity: *quantity.New(1), Price: 30}},
+			total: 160,
+			refunds: []refund{
+				{returns: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(1), skuB: *quantity.New(1)}, wantTotal: 30 + 30},
+				{returns: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(2)}, wantTotal: 100},
+				{returns: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(1)}, wantErr: ErrReturnNotPurchased},
+			},
+		},
+		{
+			name:      "basket wide discounts are given back with the items they came off",
+			lines:     []Line{{SKU: skuA, Quantity: *quantity.New(3), Price: 130}},
+			discounts: []Discount{{Description: "any 3 for 100", Amount: 30, Amounts: map[sku.SKU]currency.Pence{skuA: 30}}},
+			total:     100,
+			refunds: []refund{
+				{returns: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(1)}, wantTotal: 130 - 2*50 - 10},
+				{returns: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(2)}, wantTotal: 100 - 20},
+			},
+		},
+		{
+			name:       "returning an item from a mix and match loses the bundle",
+			lines:      []Line{{SKU: skuC, Quantity: *quantity.New(3), Price: 150}},
+			discounts:  []Discount{{Description: "any 3 fruit for 100", Amount: 50, Amounts: map[sku.SKU]currency.Pence{skuC: 50}}},
+			promotions: []pricing.Promotion{anyThreeFruit},
+			total:      100,
+			refunds: []refund{
+				// the 2 kept cost 100 without the bundle which is everything paid
+				{returns: map[sku.SKU]quantity.Quantity{skuC: *quantity.New(1)}, wantTotal: 0},
+				{returns: map[sku.SKU]quantity.Quantity{skuC: *quantity.New(2)}, wantTotal: 100},
+			},
+		},
+		{
+			name:       "returning an item outside the bundle keeps it",
+			lines:      []Line{{SKU: skuC, Quantity: *quantity.New(4), Price: 200}},
+			discounts:  []Discount{{Description: "any 3 fruit for 100", Amount: 50, Amounts: map[sku.SKU]currency.Pence{skuC: 50}}},
+			promotions: []pricing.Promotion{anyThreeFruit},
+			total:      150,
+			refunds: []refund{
+				{returns: map[sku.SKU]quantity.Quantity{skuC: *quantity.New(1)}, wantTotal: 50},
+				{returns: map[sku.SKU]quantity.Quantity{skuC: *quantity.New(1)}, wantTotal: 0},
+			},
+		},
+		{
+			name:       "returning enough to drop below a spend threshold gives up its discount",
+			lines:      []Line{{SKU: skuD, Quantity: *quantity.New(4), Price: 4000}},
+			discounts:  []Discount{{Description: "500 off over 4000", Amount: 500}},
+			thresholds: []*pricing.SpendThreshold{fiveOff},
+			total:      3500,
+			refunds: []refund{
+				// the 3 kept cost 3000 without the threshold
+				{returns: map[sku.SKU]quantity.Quantity{skuD: *quantity.New(1)}, wantTotal: 500},
+				{returns: map[sku.SKU]quantity.Quantity{skuD: *quantity.New(3)}, wantTotal: 3000},
+			},
+		},
+		{
+			name:       "returning items while staying over a spend threshold keeps its discount",
+			lines:      []Line{{SKU: skuD, Quantity: *quantity.New(5), Price: 5000}},
+			discounts:  []Discount{{Description: "500 off over 4000", Amount: 500}},
+			thresholds: []*pricing.SpendThreshold{fiveOff},
+			total:      4500,
+			refunds: []refund{
+				{returns: map[sku.SKU]quantity.Quantity{skuD: *quantity.New(1)}, wantTotal: 1000},
+			},
+		},
+		{
+			name:      "discounts which don't say which items they came off are split by price",
+			lines:     []Line{{SKU: skuA, Quantity: *quantity.New(1), Price: 50}, {SKU: skuB, Quantity: *quantity.New(1), Price: 30}},
+			discounts: []Discount{{Description: "10% off", Amount: 8}},
+			total:     72,
+			refunds: []refund{
+				{returns: map[sku.SKU]quantity.Quantity{skuB: *quantity.New(1)}, wantTotal: 30 - 3},
+				{returns: map[sku.SKU]quantity.Quantity{skuA: *quantity.New(1)}, wantTotal: 50 - 5},
+			},
+		},
+		{
+			name:  "never refunds more than was paid",
+			lines: []Line{{SKU: skuB, Quantity: *quantity.New(2), Price: 60}},
+			// customer had a discount at the till
+			total: 40,
+			refunds: []refund{
+				{returns: map[sku.SKU]quantity.Quantity{skuB: *quantity.New(2)}, wantTotal: 40},
+			},
+		},
+		{
+			name:  "can't return items which weren't bought",
+			lines: []Line{{SKU: skuA, Quantity: *quantity.New(1), Price: 50}},
+			total: 50,
+			refunds: []refund{
+				{returns: map[sku.SKU]quantity.Quantity{skuC: *quantity.New(1)}, wantErr: ErrReturnNotPurchased},
+				{returns: map[sku.SKU]quantity.Quantity{}, wantErr: ErrNothingToRefund},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore()
+			sale := store.IssueSale(SaleDetails{
+				Lines:      tt.lines,
+				Discounts:  tt.discounts,
+				Total:      tt.total,
+				Pricing:    testPricer{},
+				Promotions: tt.promotions,
+				Thresholds: tt.thresholds,
+			})
+
+			refunded := currency.Pence(0)
+			for _, r := range tt.refunds {
+				got, err := store.Refund(sale.ID, r.returns)
+				if !errors.Is(err, r.wantErr) {
+					t.Fatalf("Store.Refund() error = %v, want %v", err, r.wantErr)
+				}
+				if err != nil {
+					continue
+				}
+
+				refunded += got.Total
+
+				if got.Total != r.wantTotal {
+					t.Errorf("refund total = %d, want %d", got.Total, r.wantTotal)
+				}
+				if lines := sumLines(got.Lines); lines != got.Total {
+					t.Errorf("refund lines add up to %d, want the total %d", lines, got.Total)
+				}
+				if got.Kind != Refund || got.OriginalID != sale.ID || got.ID == sale.ID {
+					t.Errorf("unexpected refund receipt: %+v", got)
+				}
+			}
+
+			if refunded > sale.Total {
+				t.Errorf("refunded %d which is more than the %d paid", refunded, sale.Total)
+			}
+		})
+	}
+}
+
+func TestStore_Refund_errors(t *testing.T) {
+	store := NewStore()
+
+	if _, err := store.Refund("missing", nil); !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("Store.Refund() error = %v, want %v", err, ErrReceiptNotFound)
+	}
+
+	skuA := skuGenerator(t, 'A')
+	sale := store.IssueSale(SaleDetails{Lines: []Line{{SKU: skuA, Quantity: *quantity.New(1), Price: 50}}, Total: 50})
+	refund, err := store.Refund(sale.ID, map[sku.SKU]quantity.Quantity{skuA: *quantity.New(1)})
+	if err != nil {
+		t.Fatalf("Store.Refund() error = %v", err)
+	}
+
+	if _, err := store.Refund(refund.ID, map[sku.SKU]quantity.Quantity{skuA: *quantity.New(1)}); !errors.Is(err, ErrNotASale) {
+		t.Errorf("Store.Refund() error = %v, want %v", err, ErrNotASale)
+	}
+
+	if got := store.Refunds(sale.ID); len(got) != 1 || !reflect.DeepEqual(got[0], refund) {
+		t.Errorf("Store.Refunds() = %v, want [%v]", got, refund)
+	}
+}
+
+// prices which go up after the sale
+type changingPricer struct {
+	unit *currency.Pence
+}
+
+func (p changingPricer) GetPrice(_ sku.SKU, qty quantity.Quantity) currency.Pence {
+	return currency.Pence(qty.Value()) * *p.unit
+}
+
+func TestStore_Refund_salePrices(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+
+	unit := currency.Pence(50)
+	store := NewStore()
+	sale := store.IssueSale(SaleDetails{
+		Lines:   []Line{{SKU: skuA, Quantity: *quantity.New(2), Price: 100}},
+		Total:   100,
+		Pricing: changingPricer{unit: &unit},
+	})
+
+	unit = 80
+
+	// refunded at the price it was sold at
+	refund, err := store.Refund(sale.ID, map[sku.SKU]quantity.Quantity{skuA: *quantity.New(1)})
+	if err != nil {
+		t.Fatalf("Store.Refund() error = %v", err)
+	}
+	if refund.Total != 50 {
+		t.Errorf("refund total = %d, want %d", refund.Total, 50)
+	}
+
+	// sold without pricing so refunded in proportion to the line, the odd penny goes back with the first return
+	sale = store.IssueSale(SaleDetails{Lines: []Line{{SKU: skuB, Quantity: *quantity.New(3), Price: 100}}, Total: 100})
+	for _, want := range []currency.Pence{34, 33, 33} {
+		refund, err := store.Refund(sale.ID, map[sku.SKU]quantity.Quantity{skuB: *quantity.New(1)})
+		if err != nil {
+			t.Fatalf("Store.Refund() error = %v", err)
+		}
+		if refund.Total != want {
+			t.Errorf("refund total = %d, want %d", refund.Total, want)
+		}
+	}
+}
+
+func TestStore_Get_copies(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+
+	store := NewStore()
+	sale := store.IssueSale(SaleDetails{Lines: []Line{{SKU: skuA, Quantity: *quantity.New(1), Price: 50}}, Total: 50})
+	sale.Lines[0].Price = 0
+
+	got, err := store.Get(sale.ID)
+	if err != nil {
+		t.Fatalf("Store.Get() error = %v", err)
+	}
+	got.Lines[0].Quantity = *quantity.New(5)
+
+	again, _ := store.Get(sale.ID)
+	if again.Lines[0].Price != 50 || again.Lines[0].Quantity.Value() != 1 {
+		t.Errorf("changing a receipt from the store changed the stored receipt: %+v", again.Lines[0])
+	}
+}
+
+func TestReceipt_Reprice(t *testing.T) {
+	skuA := skuGenerator(t, 'A')
+	skuB := skuGenerator(t, 'B')
+
+	r := &Receipt{
+		Lines: []Line{
+			{SKU: skuB, Name: "Bread", Quantity: *quantity.New(1), Price: 25},
+			{SKU: skuA, Quantity: *quantity.New(3), Price: 150},
+		},
+		Discounts: []Discount{{Description: "10% off bread", Amount: 2}},
+		Total:     173,
+		Variant:   "cheaper-b/trial",
+	}
+
+	got := r.Reprice(testPricer{})
+	wantLines := []Line{
+		{SKU: skuB, Name: "Bread", Quantity: *quantity.New(1), Price: 30},
+		{SKU: skuA, Quantity: *quantity.New(3), Price: 130},
+	}
+
+	if !reflect.DeepEqual(got.Lines, wantLines) {
+		t.Errorf("Reprice() lines = %v, want %v", got.Lines, wantLines)
+	}
+	if got.Total != 30+130-2 {
+		t.Errorf("Reprice() total = %d, want %d", got.Total, 30+130-2)
+	}
+	if got.Variant != r.Variant || len(got.Discounts) != 1 {
+		t.Errorf("Reprice() = %+v, want the variant and discounts kept", got)
+	}
+	if r.Lines[0].Price != 25 || r.Total != 173 {
+		t.Errorf("Reprice() changed the receipt")
+	}
+
+	// members are re-priced with member prices
+	member := &Receipt{Lines: []Line{{SKU: skuA, Quantity: *quantity.New(2), Price: 80}}, Member: "************5678"}
+	prices := &pricing.SpecialPricing{Config: map[sku.SKU]pricing.PricingData{skuA: {UnitPrice: 50, MemberPrice: 40}}}
+	if got := member.Reprice(prices); got.Total != 80 {
+		t.Errorf("Reprice() member total = %d, want %d", got.Total, 80)
+	}
+}
+
+func TestLine_label(t *testing.T) {
+	tests := []struct {
+		name string
+		line Line
+		want string
+	}{
+		{name: "falls back to the sku", line: Line{SKU: skuGenerator(t, 'A')}, want: "A"},
+		{name: "short names are kept", line: Line{Name: "Pineapple"}, want: "Pineapple"},
+		{name: "long names are cut to 10 characters", line: Line{Name: "Pineapple chunks"}, want: "Pineapple "},
+		{name: "multi byte names are cut by character", line: Line{Name: "Crème brûlée"}, want: "Crème brûl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.line.label(); got != tt.want {
+				t.Errorf("Line.label() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// Compiled is the pricing the rules describe at a point in time
+type Compiled struct {
+	Pricing    *pricing.SpecialPricing
+	Thresholds []*pricing.SpendThreshold
+	// pricing which works but probably isn't what was meant e.g. an offer that doesn't save anything
+	Warnings ErrorList
+}
+
+// the clauses for one sku gathered from every statement it appears in
+type skuClauses struct {
+	unit   *Clause
+	member *Clause
+	offers []Clause
+}
+
+// active is true when the clause hasn't ended by the given time, the until date includes the whole day
+func (c Clause) active(at time.Time) bool {
+	if c.Until.IsZero() {
+		return true
+	}
+	end := time.Date(c.Until.Year(), c.Until.Month(), c.Until.Day()+1, 0, 0, 0, 0, at.Location())
+	return at.Before(end)
+}
+
+// Compile turns the rules into pricing for the given time, offers and basket discounts past their until date are left out.
+// A sku can only have one offer at a time so an offer with an until date replaces one without while it's running.
+func (f *File) Compile(at time.Time) (*Compiled, error) {
+	var errs ErrorList
+	fail := func(pos Position, format string, args ...any) {
+		errs = append(errs, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	items := make(map[sku.SKU]*skuClauses)
+	var order []sku.SKU
+	var thresholds []*pricing.SpendThreshold
+
+	for _, statement := range f.Statements {
+		if statement.SKU == nil {
+			for _, clause := range statement.Clauses {
+				if !clause.active(at) {
+					continue
+				}
+				threshold, err := compileThreshold(clause)
+				if err != nil {
+					fail(clause.Pos, "%v", err)
+					continue
+				}
+				thresholds = append(thresholds, threshold)
+			}
+			continue
+		}
+
+		item, exists := items[*statement.SKU]
+		if !exists {
+			item = &skuClauses{}
+			items[*statement.SKU] = item
+			order = append(order, *statement.SKU)
+		}
+
+		for _, clause := range statement.Clauses {
+			clause := clause
+			switch {
+			case clause.Kind == UnitPrice && item.unit != nil:
+				fail(clause.Pos, "sku %s already has a unit price on line %d", statement.SKU, item.unit.Pos.Line)
+			case clause.Kind == UnitPrice:
+				item.unit = &clause
+			case clause.Kind == MemberPrice && item.member != nil:
+				fail(clause.Pos, "sku %s already has a member price on line %d", statement.SKU, item.member.Pos.Line)
+			case clause.Kind == MemberPrice:
+				item.member = &clause
+			case clause.Kind.isOffer() && clause.active(at):
+				item.offers = append(item.offers, clause)
+			}
+		}
+	}
+
+	compiled := &Compiled{Pricing: &pricing.SpecialPricing{Config: make(map[sku.SKU]pricing.PricingData)}, Thresholds: thresholds}
+
+	// positions to report pricing problems against
+	positions := make(map[sku.SKU]map[string]Position)
+
+	for _, id := range order {
+		item := items[id]
+		if item.unit == nil {
+			fail(firstPosition(f, id), `sku %s needs a unit price e.g. "50 each"`, id)
+			continue
+		}
+
+		data := pricing.PricingData{UnitPrice: item.unit.Price}
+		problemAt := map[string]Position{"": item.unit.Pos}
+
+		if item.member != nil {
+			data.MemberPrice = item.member.Price
+			problemAt[pricing.ProblemMemberPriceNoSaving] = item.member.Pos
+		}
+
+		offer, err := pickOffer(id, item.offers)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if offer != nil {
+			data.SpecialQuantity, data.SpecialPrice, err = offerPricing(*offer, item.unit.Price)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, code := range []string{pricing.ProblemMissingSpecialQuantity, pricing.ProblemMissingSpecialPrice, pricing.ProblemOfferNoSaving} {
+				problemAt[code] = offer.Pos
+			}
+		}
+
+		compiled.Pricing.Config[id] = data
+		positions[id] = problemAt
+	}
+
+	if len(errs) > 0 {
+		return nil, sortErrors(errs)
+	}
+
+	for _, problem := range pricing.Validate(compiled.Pricing) {
+		pos, exists := positions[problem.SKU][problem.Code]
+		if !exists {
+			pos = positions[problem.SKU][""]
+		}
+
+		err := &Error{Pos: pos, Msg: fmt.Sprintf("sku %s: %s", problem.SKU, problem.Message)}
+		if problem.Severity == pricing.SeverityError {
+			errs = append(errs, err)
+		} else {
+			compiled.Warnings = append(compiled.Warnings, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, sortErrors(errs)
+	}
+	sortErrors(compiled.Warnings)
+
+	return compiled, nil
+}
+
+// Compile parses and compiles the rules in one go
+func Compile(src string, at time.Time) (*Compiled, error) {
+	f, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return f.Compile(at)
+}
+
+// picks the offer in force, dated offers win over ones that don't end
+func pickOffer(id sku.SKU, offers []Clause) (*Clause, *Error) {
+	var dated, undated []Clause
+	for _, offer := range offers {
+		if offer.Until.IsZero() {
+			undated = append(undated, offer)
+		} else {
+			dated = append(dated, offer)
+		}
+	}
+
+	for _, candidates := range [][]Clause{dated, undated} {
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return &candidates[0], nil
+		default:
+			return nil, &Error{Pos: candidates[1].Pos, Msg: fmt.Sprintf("sku %s already has an offer running on line %d, only one can apply at a time", id, candidates[0].Pos.Line)}
+		}
+	}
+
+	return nil, nil
+}
+
+// "buy 2 get 1 free" is sold as 3 for the price of 2
+func offerPricing(offer Clause, unit currency.Pence) (quantity.Quantity, currency.Pence, *Error) {
+	if offer.Kind != BuyGetFree {
+		return *quantity.New(offer.Quantity), offer.Price, nil
+	}
+
+	if offer.Free > math.MaxInt-offer.Quantity || (unit > 0 && currency.Pence(offer.Quantity) > math.MaxInt/unit) {
+		return quantity.Quantity{}, 0, &Error{Pos: offer.Pos, Msg: fmt.Sprintf("%q is too big to price", offer.String())}
+	}
+	return *quantity.New(offer.Quantity + offer.Free), unit * currency.Pence(offer.Quantity), nil
+}
+
+func compileThreshold(clause Clause) (*pricing.SpendThreshold, error) {
+	measure := pricing.MeasureBeforePromotions
+	if clause.AfterPromotions {
+		measure = pricing.MeasureAfterPromotions
+	}
+
+	// the label is shown on the receipt where the end date is just noise
+	clause.Until = time.Time{}
+	label := clause.String()
+	if clause.Kind == PercentOff {
+		return pricing.NewPercentOffThreshold(label, clause.Over, clause.Percent, measure)
+	}
+	return pricing.NewAmountOffThreshold(label, clause.Over, clause.Price, measure)
+}
+
+func firstPosition(f *File, id sku.SKU) Position {
+	for _, statement := range f.Statements {
+		if statement.SKU != nil && *statement.SKU == id {
+			return statement.Pos
+		}
+	}
+	return Position{}
+}
+
+func sortErrors(errs ErrorList) ErrorList {
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Pos.Line != errs[j].Pos.Line {
+			return errs[i].Pos.Line < errs[j].Pos.Line
+		}
+		return errs[i].Pos.Column < errs[j].Pos.Column
+	})
+	return errs
+}
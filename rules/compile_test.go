@@ -0,0 +1,143 @@
+package rules
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func TestCompile(t *testing.T) {
+	src, err := os.ReadFile("testdata/prices.rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	skuA, _ := sku.New('A')
+	skuB, _ := sku.New('B')
+	skuC, _ := sku.New('C')
+
+	tests := []struct {
+		name           string
+		at             time.Time
+		wantB          pricing.PricingData
+		wantThresholds int
+	}{
+		{
+			name:           "christmas offer is running on its last day",
+			at:             time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC),
+			wantB:          pricing.PricingData{UnitPrice: 30, SpecialPrice: 60, SpecialQuantity: *quantity.New(3)},
+			wantThresholds: 2,
+		},
+		{
+			name:           "christmas offer has ended",
+			at:             time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantB:          pricing.PricingData{UnitPrice: 30},
+			wantThresholds: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := Compile(string(src), tt.at)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			config := compiled.Pricing.Config
+			if want := (pricing.PricingData{UnitPrice: 50, SpecialPrice: 130, SpecialQuantity: *quantity.New(3)}); !reflect.DeepEqual(config[skuA], want) {
+				t.Errorf("Compile() A = %+v, want %+v", config[skuA], want)
+			}
+			if !reflect.DeepEqual(config[skuB], tt.wantB) {
+				t.Errorf("Compile() B = %+v, want %+v", config[skuB], tt.wantB)
+			}
+			if config[skuC].MemberPrice != 15 {
+				t.Errorf("Compile() C member price = %d, want %d", config[skuC].MemberPrice, 15)
+			}
+			if len(compiled.Thresholds) != tt.wantThresholds {
+				t.Errorf("Compile() got %d thresholds, want %d", len(compiled.Thresholds), tt.wantThresholds)
+			}
+		})
+	}
+}
+
+func TestCompile_datedOfferReplacesEveryday(t *testing.T) {
+	compiled, err := Compile("A: 50 each; 3 for 130\nA: 2 for 80 until 2026-06-30", time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	skuA, _ := sku.New('A')
+	if got := compiled.Pricing.GetPrice(skuA, *quantity.New(2)); got != 80 {
+		t.Errorf("GetPrice() = %d, want %d", got, 80)
+	}
+}
+
+func TestCompile_errors(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		src  string
+		want ErrorList
+	}{
+		{
+			name: "no unit price",
+			src:  "B: buy 2 get 1 free",
+			want: ErrorList{{Pos: Position{Line: 1, Column: 1}, Msg: `sku B needs a unit price e.g. "50 each"`}},
+		},
+		{
+			name: "two unit prices",
+			src:  "A: 50 each\nA: 60 each",
+			want: ErrorList{{Pos: Position{Line: 2, Column: 4}, Msg: "sku A already has a unit price on line 1"}},
+		},
+		{
+			name: "two offers at once",
+			src:  "A: 50 each; 3 for 130; 2 for 90",
+			want: ErrorList{{Pos: Position{Line: 1, Column: 24}, Msg: "sku A already has an offer running on line 1, only one can apply at a time"}},
+		},
+		{
+			name: "offer costs more",
+			src:  "A: 50 each\nA: 3 for 200",
+			want: ErrorList{{Pos: Position{Line: 2, Column: 4}, Msg: "sku A: 3 for 200 costs more than buying them separately for 150"}},
+		},
+		{
+			name: "offer too big to price",
+			src:  "A: 50 each; buy 9223372036854775807 get 1 free",
+			want: ErrorList{{Pos: Position{Line: 1, Column: 13}, Msg: `"buy 9223372036854775807 get 1 free" is too big to price`}},
+		},
+		{
+			name: "percentage over 100",
+			src:  "basket: 150% off over 1000",
+			want: ErrorList{{Pos: Position{Line: 1, Column: 9}, Msg: "percentage must be between 1 and 100: 150"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.src, at)
+
+			var got ErrorList
+			if !errors.As(err, &got) {
+				t.Fatalf("Compile() error = %v, want an ErrorList", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compile() error =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_warnings(t *testing.T) {
+	compiled, err := Compile("A: 50 each\nA: member 60", time.Now())
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	if len(compiled.Warnings) != 1 || compiled.Warnings[0].Pos != (Position{Line: 2, Column: 4}) {
+		t.Errorf("Compile() warnings = %v, want one for the member price on line 2", compiled.Warnings)
+	}
+}
@@ -0,0 +1,86 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (c Clause) String() string {
+	var b strings.Builder
+
+	switch c.Kind {
+	case UnitPrice:
+		fmt.Fprintf(&b, "%d each", c.Price)
+	case MultiBuy:
+		fmt.Fprintf(&b, "%d for %d", c.Quantity, c.Price)
+	case BuyGetFree:
+		fmt.Fprintf(&b, "buy %d get %d free", c.Quantity, c.Free)
+	case MemberPrice:
+		fmt.Fprintf(&b, "member %d", c.Price)
+	case AmountOff:
+		fmt.Fprintf(&b, "%d off over %d", c.Price, c.Over)
+	case PercentOff:
+		fmt.Fprintf(&b, "%d%% off over %d", c.Percent, c.Over)
+	}
+
+	if c.AfterPromotions {
+		b.WriteString(" after promotions")
+	}
+	if !c.Until.IsZero() {
+		fmt.Fprintf(&b, " until %s", c.Until.Format(dateLayout))
+	}
+
+	return b.String()
+}
+
+func (s Statement) String() string {
+	target := basketTarget
+	if s.SKU != nil {
+		target = s.SKU.String()
+	}
+
+	clauses := make([]string, len(s.Clauses))
+	for i, clause := range s.Clauses {
+		clauses[i] = clause.String()
+	}
+
+	line := target + ": " + strings.Join(clauses, "; ")
+	if s.Trailing != "" {
+		line += " " + s.Trailing
+	}
+	return line
+}
+
+// Format writes the rules out in the normal layout, one statement per line with single spaces between words.
+// Comments and the order of the statements are kept so the output parses back to the same rules.
+func (f *File) Format() string {
+	var b strings.Builder
+
+	for i, statement := range f.Statements {
+		if statement.Blank && i > 0 {
+			b.WriteString("\n")
+		}
+		for _, comment := range statement.Comments {
+			b.WriteString(comment + "\n")
+		}
+		b.WriteString(statement.String() + "\n")
+	}
+
+	if len(f.Comments) > 0 && len(f.Statements) > 0 {
+		b.WriteString("\n")
+	}
+	for _, comment := range f.Comments {
+		b.WriteString(comment + "\n")
+	}
+
+	return b.String()
+}
+
+// Format parses and normalises the rules, the source is returned unchanged alongside the error when it doesn't parse
+func Format(src string) (string, error) {
+	f, err := Parse(src)
+	if err != nil {
+		return src, err
+	}
+	return f.Format(), nil
+}
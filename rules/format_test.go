@@ -0,0 +1,57 @@
+package rules
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "spacing and case are normalised",
+			src:  "a :50   EACH ;3 for 130\n\n\n  basket:10 % off over 5000 After promotions",
+			want: "A: 50 each; 3 for 130\n\nbasket: 10% off over 5000 after promotions\n",
+		},
+		{
+			name: "comments are kept",
+			src:  "# prices\nA: 50 each   # per item\n# end",
+			want: "# prices\nA: 50 each # per item\n\n# end\n",
+		},
+		{
+			name: "blank lines between comments are kept",
+			src:  "A: 50 each\n\n# fruit\n\n\n# on offer until christmas\nB: 30 each\n# drinks\n\nC: 20 each\n\n# end\n\n# really\n\n",
+			want: "A: 50 each\n\n# fruit\n\n# on offer until christmas\nB: 30 each\n# drinks\n\nC: 20 each\n\n# end\n\n# really\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.src)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_roundTrip(t *testing.T) {
+	src, err := os.ReadFile("testdata/prices.rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the example is already formatted so it comes back unchanged
+	got, err := Format(string(src))
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if got != string(src) {
+		t.Errorf("Format() =\n%s\nwant\n%s", got, src)
+	}
+}
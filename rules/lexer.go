@@ -0,0 +1,164 @@
+package rules
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// Position of a token in the source, lines and columns start at 1
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNewline
+	tokenWord
+	tokenNumber
+	tokenDate
+	tokenColon
+	tokenSemicolon
+	tokenPercent
+	tokenComment
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokenEOF:
+		return "end of file"
+	case tokenNewline:
+		return "end of line"
+	case tokenWord:
+		return "word"
+	case tokenNumber:
+		return "number"
+	case tokenDate:
+		return "date"
+	case tokenColon:
+		return `":"`
+	case tokenSemicolon:
+		return `";"`
+	case tokenPercent:
+		return `"%"`
+	case tokenComment:
+		return "comment"
+	default:
+		return "unknown"
+	}
+}
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  Position
+}
+
+// describes the token for error messages
+func (t token) describe() string {
+	switch t.kind {
+	case tokenWord, tokenNumber, tokenDate:
+		return fmt.Sprintf("%s %q", t.kind, t.text)
+	default:
+		return t.kind.String()
+	}
+}
+
+type lexer struct {
+	src  []rune
+	next int
+	pos  Position
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: []rune(src), pos: Position{Line: 1, Column: 1}}
+}
+
+func (l *lexer) peek(offset int) rune {
+	if l.next+offset >= len(l.src) {
+		return 0
+	}
+	return l.src[l.next+offset]
+}
+
+func (l *lexer) advance() rune {
+	r := l.src[l.next]
+	l.next++
+	if r == '\n' {
+		l.pos.Line++
+		l.pos.Column = 1
+	} else {
+		l.pos.Column++
+	}
+	return r
+}
+
+// takes runes while they match
+func (l *lexer) take(match func(rune) bool) string {
+	start := l.next
+	for l.next < len(l.src) && match(l.src[l.next]) {
+		l.advance()
+	}
+	return string(l.src[start:l.next])
+}
+
+// tokens reads every token in the source, it stops at the first character it doesn't understand
+func (l *lexer) tokens() ([]token, error) {
+	var tokens []token
+
+	for {
+		l.take(func(r rune) bool { return r != '\n' && unicode.IsSpace(r) })
+
+		start := l.pos
+		if l.next >= len(l.src) {
+			return append(tokens, token{kind: tokenEOF, pos: start}), nil
+		}
+
+		r := l.peek(0)
+		switch {
+		case r == '\n':
+			l.advance()
+			tokens = append(tokens, token{kind: tokenNewline, text: "\n", pos: start})
+		case r == ':':
+			l.advance()
+			tokens = append(tokens, token{kind: tokenColon, text: ":", pos: start})
+		case r == ';':
+			l.advance()
+			tokens = append(tokens, token{kind: tokenSemicolon, text: ";", pos: start})
+		case r == '%':
+			l.advance()
+			tokens = append(tokens, token{kind: tokenPercent, text: "%", pos: start})
+		case r == '#':
+			text := l.take(func(r rune) bool { return r != '\n' })
+			tokens = append(tokens, token{kind: tokenComment, text: text, pos: start})
+		case unicode.IsLetter(r):
+			tokens = append(tokens, token{kind: tokenWord, text: l.take(unicode.IsLetter), pos: start})
+		case unicode.IsDigit(r):
+			tokens = append(tokens, l.number(start))
+		default:
+			return nil, &Error{Pos: start, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+}
+
+// a number or a date written as YYYY-MM-DD
+func (l *lexer) number(start Position) token {
+	digits := l.take(unicode.IsDigit)
+
+	if len(digits) == 4 && l.peek(0) == '-' && unicode.IsDigit(l.peek(1)) {
+		text := digits
+		for i := 0; i < 2 && l.peek(0) == '-'; i++ {
+			l.advance()
+			text += "-" + l.take(unicode.IsDigit)
+		}
+		return token{kind: tokenDate, text: text, pos: start}
+	}
+
+	return token{kind: tokenNumber, text: digits, pos: start}
+}
@@ -0,0 +1,429 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+const dateLayout = "2006-01-02"
+
+// the target of statements which apply to the whole basket
+const basketTarget = "basket"
+
+// Error is a problem with the rules at a position in the source
+type Error struct {
+	Pos Position
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// ErrorList is every error found in the rules, in the order they appear
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// ClauseKind is what a clause does
+type ClauseKind int
+
+const (
+	// "50 each"
+	UnitPrice ClauseKind = iota + 1
+	// "3 for 130"
+	MultiBuy
+	// "buy 2 get 1 free"
+	BuyGetFree
+	// "member 45"
+	MemberPrice
+	// "500 off over 4000"
+	AmountOff
+	// "10% off over 5000"
+	PercentOff
+)
+
+func (k ClauseKind) isOffer() bool {
+	return k == MultiBuy || k == BuyGetFree
+}
+
+func (k ClauseKind) isBasket() bool {
+	return k == AmountOff || k == PercentOff
+}
+
+// Clause is a single rule separated from the others on its line by ";"
+type Clause struct {
+	Pos  Position
+	Kind ClauseKind
+	// unit, member or multi-buy price or the amount off
+	Price currency.Pence
+	// how many for a multi-buy or how many to buy for a free one
+	Quantity int
+	Free     int
+	Percent  int
+	// spend threshold for basket discounts and whether it's measured after basket wide promotions
+	Over            currency.Pence
+	AfterPromotions bool
+	// the last day the clause applies, zero when it doesn't end
+	Until time.Time
+}
+
+// Statement is a line of rules for a sku or the basket
+type Statement struct {
+	Pos Position
+	// nil for basket statements
+	SKU     *sku.SKU
+	Clauses []Clause
+	// comments on the lines before the statement and at the end of it, an empty comment is a blank line between them
+	Comments []string
+	Trailing string
+	// there was an empty line before the statement or its comments
+	Blank bool
+}
+
+// File is a parsed set of rules
+type File struct {
+	Statements []Statement
+	// comments after the last statement, an empty comment is a blank line between them
+	Comments []string
+}
+
+type parser struct {
+	tokens []token
+	next   int
+	errs   ErrorList
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *parser) fail(t token, format string, args ...any) error {
+	return &Error{Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	t := p.advance()
+	if t.kind != kind {
+		return t, p.fail(t, "expected %s, found %s", what, t.describe())
+	}
+	return t, nil
+}
+
+func (p *parser) expectWord(word string) error {
+	t := p.advance()
+	if t.kind != tokenWord || strings.ToLower(t.text) != word {
+		return p.fail(t, "expected %q, found %s", word, t.describe())
+	}
+	return nil
+}
+
+func (p *parser) number(what string) (int, error) {
+	t, err := p.expect(tokenNumber, what)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(t.text)
+	if err != nil {
+		return 0, p.fail(t, "%s %q is too big", what, t.text)
+	}
+	return n, nil
+}
+
+// the word at the current token in lower case, empty when it isn't a word
+func (p *parser) word() string {
+	if t := p.peek(); t.kind == tokenWord {
+		return strings.ToLower(t.text)
+	}
+	return ""
+}
+
+// skips the rest of a line which failed to parse
+func (p *parser) skipLine() {
+	for {
+		switch p.peek().kind {
+		case tokenEOF:
+			return
+		case tokenNewline:
+			p.advance()
+			return
+		}
+		p.advance()
+	}
+}
+
+// Parse reads the rules, every error is returned in an ErrorList with its line and column
+//
+//	# one statement per line, clauses are separated by ";"
+//	A: 50 each; 3 for 130
+//	B: 30 each; buy 2 get 1 free until 2026-12-31
+//	C: 20 each; member 15
+//	basket: 500 off over 4000; 10% off over 5000 after promotions
+func Parse(src string) (*File, error) {
+	tokens, err := newLexer(src).tokens()
+	if err != nil {
+		return nil, ErrorList{err.(*Error)}
+	}
+
+	p := &parser{tokens: tokens}
+	file := &File{}
+
+	var comments []string
+	// blank lines are kept so formatting doesn't squash groups of rules together
+	lineStart, blank := true, false
+	for p.peek().kind != tokenEOF {
+		switch t := p.peek(); t.kind {
+		case tokenNewline:
+			p.advance()
+			switch {
+			case !lineStart:
+			case len(comments) > 0:
+				if comments[len(comments)-1] != "" {
+					comments = append(comments, "")
+				}
+			case len(file.Statements) > 0:
+				blank = true
+			}
+			lineStart = true
+			continue
+		case tokenComment:
+			comments = append(comments, p.advance().text)
+			lineStart = false
+			continue
+		}
+
+		statement, err := p.statement()
+		lineStart = true
+		if err != nil {
+			p.errs = append(p.errs, err.(*Error))
+			p.skipLine()
+			continue
+		}
+
+		statement.Comments, comments = comments, nil
+		statement.Blank, blank = blank, false
+		file.Statements = append(file.Statements, statement)
+	}
+	// blank lines at the end of the file aren't kept
+	for len(comments) > 0 && comments[len(comments)-1] == "" {
+		comments = comments[:len(comments)-1]
+	}
+	file.Comments = comments
+
+	if len(p.errs) > 0 {
+		return nil, p.errs
+	}
+	return file, nil
+}
+
+func (p *parser) statement() (Statement, error) {
+	target, err := p.expect(tokenWord, `a sku or "basket"`)
+	if err != nil {
+		return Statement{}, err
+	}
+
+	statement := Statement{Pos: target.pos}
+
+	if strings.ToLower(target.text) != basketTarget {
+		runes := []rune(target.text)
+		if len(runes) != 1 {
+			return Statement{}, p.fail(target, `expected a sku or "basket", found %s`, target.describe())
+		}
+		s, err := sku.New(runes[0])
+		if err != nil {
+			return Statement{}, p.fail(target, "invalid sku %q: %v", target.text, err)
+		}
+		statement.SKU = &s
+	}
+
+	if _, err := p.expect(tokenColon, `":"`); err != nil {
+		return Statement{}, err
+	}
+
+	for {
+		clause, err := p.clause(statement.SKU == nil)
+		if err != nil {
+			return Statement{}, err
+		}
+		statement.Clauses = append(statement.Clauses, clause)
+
+		if p.peek().kind != tokenSemicolon {
+			break
+		}
+		p.advance()
+	}
+
+	if p.peek().kind == tokenComment {
+		statement.Trailing = p.advance().text
+	}
+
+	if t := p.advance(); t.kind != tokenNewline && t.kind != tokenEOF {
+		return Statement{}, p.fail(t, `expected ";" or the end of the line, found %s`, t.describe())
+	}
+
+	return statement, nil
+}
+
+func (p *parser) clause(basket bool) (Clause, error) {
+	start := p.peek()
+	clause := Clause{Pos: start.pos}
+
+	var err error
+	switch {
+	case basket:
+		err = p.basketClause(&clause)
+	case p.word() == "buy":
+		err = p.buyGetFree(&clause)
+	case p.word() == "member":
+		p.advance()
+		clause.Kind = MemberPrice
+		var price int
+		price, err = p.number("a member price")
+		clause.Price = currency.Pence(price)
+	case start.kind == tokenNumber:
+		err = p.priceClause(&clause)
+	default:
+		err = p.fail(start, `expected "50 each", "3 for 130", "buy 2 get 1 free" or "member 45", found %s`, start.describe())
+	}
+	if err != nil {
+		return Clause{}, err
+	}
+
+	if p.word() == "until" {
+		until := p.advance()
+		if !clause.Kind.isOffer() && !clause.Kind.isBasket() {
+			return Clause{}, p.fail(until, `only offers and basket discounts can have an "until" date`)
+		}
+		date, err := p.expect(tokenDate, "a date like 2026-12-31")
+		if err != nil {
+			return Clause{}, err
+		}
+		clause.Until, err = time.Parse(dateLayout, date.text)
+		if err != nil {
+			return Clause{}, p.fail(date, "invalid date %q", date.text)
+		}
+	}
+
+	return clause, nil
+}
+
+// "50 each" or "3 for 130"
+func (p *parser) priceClause(clause *Clause) error {
+	n, err := p.number("a price")
+	if err != nil {
+		return err
+	}
+
+	switch p.word() {
+	case "each":
+		p.advance()
+		clause.Kind = UnitPrice
+		clause.Price = currency.Pence(n)
+		return nil
+	case "for":
+		p.advance()
+		price, err := p.number("a price")
+		if err != nil {
+			return err
+		}
+		clause.Kind = MultiBuy
+		clause.Quantity = n
+		clause.Price = currency.Pence(price)
+		return nil
+	default:
+		t := p.peek()
+		return p.fail(t, `expected "each" or "for", found %s`, t.describe())
+	}
+}
+
+// "buy 2 get 1 free"
+func (p *parser) buyGetFree(clause *Clause) error {
+	p.advance()
+
+	buyAt := p.peek()
+	buy, err := p.number("how many to buy")
+	if err != nil {
+		return err
+	}
+	if buy < 1 {
+		return p.fail(buyAt, "must buy at least 1 to get any free")
+	}
+	if err := p.expectWord("get"); err != nil {
+		return err
+	}
+	freeAt := p.peek()
+	free, err := p.number("how many are free")
+	if err != nil {
+		return err
+	}
+	if free < 1 {
+		return p.fail(freeAt, "at least 1 must be free")
+	}
+	if err := p.expectWord("free"); err != nil {
+		return err
+	}
+
+	clause.Kind = BuyGetFree
+	clause.Quantity = buy
+	clause.Free = free
+	return nil
+}
+
+// "500 off over 4000" or "10% off over 5000" optionally followed by "after promotions"
+func (p *parser) basketClause(clause *Clause) error {
+	n, err := p.number("an amount or percentage off")
+	if err != nil {
+		return err
+	}
+
+	if p.peek().kind == tokenPercent {
+		p.advance()
+		clause.Kind = PercentOff
+		clause.Percent = n
+	} else {
+		clause.Kind = AmountOff
+		clause.Price = currency.Pence(n)
+	}
+
+	if err := p.expectWord("off"); err != nil {
+		return err
+	}
+	if err := p.expectWord("over"); err != nil {
+		return err
+	}
+
+	over, err := p.number("a spend threshold")
+	if err != nil {
+		return err
+	}
+	clause.Over = currency.Pence(over)
+
+	if p.word() == "after" {
+		p.advance()
+		if err := p.expectWord("promotions"); err != nil {
+			return err
+		}
+		clause.AfterPromotions = true
+	}
+
+	return nil
+}
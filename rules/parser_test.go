@@ -0,0 +1,111 @@
+package rules
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	src, err := os.ReadFile("testdata/prices.rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Parse(string(src))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(f.Statements) != 7 {
+		t.Fatalf("Parse() got %d statements, want %d", len(f.Statements), 7)
+	}
+
+	offer := f.Statements[3]
+	want := []Clause{{Pos: Position{Line: 7, Column: 4}, Kind: BuyGetFree, Quantity: 2, Free: 1, Until: time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)}}
+	if !reflect.DeepEqual(offer.Clauses, want) {
+		t.Errorf("Parse() B offer = %+v, want %+v", offer.Clauses, want)
+	}
+	if !offer.Blank || !reflect.DeepEqual(offer.Comments, []string{"# christmas offers"}) {
+		t.Errorf("Parse() B offer blank = %v comments = %q, want a blank line and the comment", offer.Blank, offer.Comments)
+	}
+
+	if got := f.Statements[4].Trailing; got != "# no offer on these" {
+		t.Errorf("Parse() trailing comment = %q", got)
+	}
+
+	basket := f.Statements[6]
+	if basket.SKU != nil || basket.Clauses[0].Kind != PercentOff || basket.Clauses[0].Percent != 10 || !basket.Clauses[0].AfterPromotions {
+		t.Errorf("Parse() basket = %+v", basket)
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want ErrorList
+	}{
+		{
+			name: "unknown character",
+			src:  "A: 50 each\nB: £30 each",
+			want: ErrorList{{Pos: Position{Line: 2, Column: 4}, Msg: `unexpected character '£'`}},
+		},
+		{
+			name: "missing colon",
+			src:  "A 50 each",
+			want: ErrorList{{Pos: Position{Line: 1, Column: 3}, Msg: `expected ":", found number "50"`}},
+		},
+		{
+			name: "every bad line is reported",
+			src:  "A: 50 eech\nB: 30 each\nC: buy 2 get free",
+			want: ErrorList{
+				{Pos: Position{Line: 1, Column: 7}, Msg: `expected "each" or "for", found word "eech"`},
+				{Pos: Position{Line: 3, Column: 14}, Msg: `expected how many are free, found word "free"`},
+			},
+		},
+		{
+			name: "nothing to buy or nothing free",
+			src:  "A: 50 each; buy 0 get 1 free\nB: 30 each; buy 2 get 0 free",
+			want: ErrorList{
+				{Pos: Position{Line: 1, Column: 17}, Msg: "must buy at least 1 to get any free"},
+				{Pos: Position{Line: 2, Column: 23}, Msg: "at least 1 must be free"},
+			},
+		},
+		{
+			name: "sku too long",
+			src:  "AB: 50 each",
+			want: ErrorList{{Pos: Position{Line: 1, Column: 1}, Msg: `expected a sku or "basket", found word "AB"`}},
+		},
+		{
+			name: "until on a unit price",
+			src:  "A: 50 each until 2026-01-01",
+			want: ErrorList{{Pos: Position{Line: 1, Column: 12}, Msg: `only offers and basket discounts can have an "until" date`}},
+		},
+		{
+			name: "invalid date",
+			src:  "A: 50 each; 3 for 130 until 2026-02-30",
+			want: ErrorList{{Pos: Position{Line: 1, Column: 29}, Msg: `invalid date "2026-02-30"`}},
+		},
+		{
+			name: "basket needs a threshold",
+			src:  "basket: 500 off",
+			want: ErrorList{{Pos: Position{Line: 1, Column: 16}, Msg: `expected "over", found end of file`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.src)
+
+			var got ErrorList
+			if !errors.As(err, &got) {
+				t.Fatalf("Parse() error = %v, want an ErrorList", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() error =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package script
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+var (
+	ErrStepLimit = errors.New("script ran for too many steps")
+	ErrTimeout   = errors.New("script ran for too long")
+)
+
+// Limits keep scripts from using up the till. Scripts have no loops and can't allocate memory, so the size and depth
+// limits checked when a script is loaded also bound how long it can run and how much stack it needs.
+// The step limit and timeout are checked while it runs as well, in case a basket lookup is slow.
+type Limits struct {
+	// longest script in bytes
+	MaxSource int
+	// deepest nesting of brackets, operators and function calls
+	MaxDepth int
+	// most steps a script may take, each number, variable, operator and function call is a step.
+	// Scripts which could take more are rejected when they're loaded.
+	MaxSteps int
+	// longest a single run may take
+	Timeout time.Duration
+}
+
+var DefaultLimits = Limits{MaxSource: 4096, MaxDepth: 32, MaxSteps: 1000, Timeout: 10 * time.Millisecond}
+
+// Env is what a script can see when it prices a line
+type Env struct {
+	SKU sku.SKU
+	// qty in the script
+	Quantity int
+	// unit and price in the script, the price of one item and of the whole line from the normal pricing
+	UnitPrice currency.Pence
+	Price     currency.Pence
+	// basket("A") in the script, the quantity of any sku in the basket
+	Basket func(sku sku.SKU) int
+}
+
+// Script is a compiled pricing script which can be run any number of times, it is safe to use from many go-routines
+type Script struct {
+	src    string
+	root   *node
+	limits Limits
+}
+
+// Compile parses and type checks a script, every problem that can be found without running it is returned as an *Error
+//
+//	# second one half price
+//	qty / 2 * unit / 2 + (qty - qty / 2) * unit
+func Compile(src string, limits Limits) (*Script, error) {
+	root, nodes, err := parse(src, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodes > limits.MaxSteps {
+		return nil, &Error{Pos: root.pos, Msg: fmt.Sprintf("script could take %d steps, the limit is %d", nodes, limits.MaxSteps)}
+	}
+
+	return &Script{src: src, root: root, limits: limits}, nil
+}
+
+func (s *Script) String() string {
+	return s.src
+}
+
+type run struct {
+	env      Env
+	steps    int
+	maxSteps int
+	deadline time.Time
+}
+
+// Run works out the price of a line, an error is returned for runtime problems like dividing by zero,
+// overflowing, a negative price or going over the limits
+func (s *Script) Run(env Env) (currency.Pence, error) {
+	r := &run{env: env, maxSteps: s.limits.MaxSteps, deadline: time.Now().Add(s.limits.Timeout)}
+
+	price, err := r.eval(s.root)
+	if err != nil {
+		return 0, err
+	}
+
+	if price < 0 {
+		return 0, &Error{Pos: s.root.pos, Msg: fmt.Sprintf("script worked out a negative price %d", price)}
+	}
+
+	return currency.Pence(price), nil
+}
+
+func (r *run) eval(n *node) (int64, error) {
+	r.steps++
+	if r.steps > r.maxSteps {
+		return 0, fmt.Errorf("%w: the limit is %d", ErrStepLimit, r.maxSteps)
+	}
+	if time.Now().After(r.deadline) {
+		return 0, ErrTimeout
+	}
+
+	switch n.kind {
+	case nodeNumber:
+		return n.value, nil
+
+	case nodeVariable:
+		switch n.name {
+		case "qty":
+			return int64(r.env.Quantity), nil
+		case "unit":
+			return int64(r.env.UnitPrice), nil
+		default:
+			return int64(r.env.Price), nil
+		}
+
+	case nodeUnary:
+		x, err := r.eval(n.args[0])
+		if err != nil {
+			return 0, err
+		}
+		if n.name == "!" {
+			return 1 - x, nil
+		}
+		if x == math.MinInt64 {
+			return 0, overflow(n)
+		}
+		return -x, nil
+
+	case nodeBinary:
+		return r.binary(n)
+
+	case nodeCall:
+		return r.call(n)
+	}
+
+	return 0, &Error{Pos: n.pos, Msg: "unknown expression"}
+}
+
+func (r *run) binary(n *node) (int64, error) {
+	x, err := r.eval(n.args[0])
+	if err != nil {
+		return 0, err
+	}
+
+	// && and || only look at the right hand side when they need to
+	switch {
+	case n.name == "&&" && x == 0:
+		return 0, nil
+	case n.name == "||" && x == 1:
+		return 1, nil
+	}
+
+	y, err := r.eval(n.args[1])
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.name {
+	case "&&", "||":
+		return y, nil
+	case "==":
+		return boolean(x == y), nil
+	case "!=":
+		return boolean(x != y), nil
+	case "<":
+		return boolean(x < y), nil
+	case "<=":
+		return boolean(x <= y), nil
+	case ">":
+		return boolean(x > y), nil
+	case ">=":
+		return boolean(x >= y), nil
+	case "+":
+		if (y > 0 && x > math.MaxInt64-y) || (y < 0 && x < math.MinInt64-y) {
+			return 0, overflow(n)
+		}
+		return x + y, nil
+	case "-":
+		if (y < 0 && x > math.MaxInt64+y) || (y > 0 && x < math.MinInt64+y) {
+			return 0, overflow(n)
+		}
+		return x - y, nil
+	case "*":
+		if x != 0 && y != 0 {
+			product := x * y
+			if product/y != x || (x == -1 && y == math.MinInt64) || (y == -1 && x == math.MinInt64) {
+				return 0, overflow(n)
+			}
+		}
+		return x * y, nil
+	case "/", "%":
+		if y == 0 {
+			return 0, &Error{Pos: n.pos, Msg: "division by zero"}
+		}
+		if x == math.MinInt64 && y == -1 {
+			return 0, overflow(n)
+		}
+		if n.name == "/" {
+			return x / y, nil
+		}
+		return x % y, nil
+	}
+
+	return 0, &Error{Pos: n.pos, Msg: fmt.Sprintf("unknown operator %q", n.name)}
+}
+
+func (r *run) call(n *node) (int64, error) {
+	switch n.name {
+	case "basket":
+		if r.env.Basket == nil {
+			return 0, nil
+		}
+		return int64(r.env.Basket(n.sku)), nil
+
+	case "if":
+		condition, err := r.eval(n.args[0])
+		if err != nil {
+			return 0, err
+		}
+		if condition == 1 {
+			return r.eval(n.args[1])
+		}
+		return r.eval(n.args[2])
+	}
+
+	// min and max
+	best, err := r.eval(n.args[0])
+	if err != nil {
+		return 0, err
+	}
+	for _, arg := range n.args[1:] {
+		value, err := r.eval(arg)
+		if err != nil {
+			return 0, err
+		}
+		if (n.name == "min" && value < best) || (n.name == "max" && value > best) {
+			best = value
+		}
+	}
+	return best, nil
+}
+
+func boolean(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func overflow(n *node) error {
+	return &Error{Pos: n.pos, Msg: fmt.Sprintf("%q overflowed", n.name)}
+}
@@ -0,0 +1,107 @@
+package script
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func TestCompile_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want *Error
+	}{
+		{name: "unknown variable", src: "qyt * unit", want: &Error{Pos: Position{Line: 1, Column: 1}, Msg: `unknown variable "qyt", scripts can use qty, unit and price`}},
+		{name: "unknown function", src: "\n  floor(price)", want: &Error{Pos: Position{Line: 2, Column: 3}, Msg: `unknown function "floor", scripts can use min, max, if and basket`}},
+		{name: "returns true/false", src: "qty > 3", want: &Error{Pos: Position{Line: 1, Column: 5}, Msg: "a script must work out a price, not true/false"}},
+		{name: "number used as condition", src: "if(qty, 1, 2)", want: &Error{Pos: Position{Line: 1, Column: 4}, Msg: "the condition of if() needs true/false"}},
+		{name: "chained comparison", src: "if(1 < qty < 3, 1, 2)", want: &Error{Pos: Position{Line: 1, Column: 12}, Msg: `comparisons can't be chained, use && instead of "<"`}},
+		{name: "invalid sku", src: `basket("$")`, want: &Error{Pos: Position{Line: 1, Column: 8}, Msg: `invalid sku "$": a SKU must not contain any special characters`}},
+		{name: "unclosed bracket", src: "(qty * unit", want: &Error{Pos: Position{Line: 1, Column: 12}, Msg: `expected ")", found the end of the script`}},
+		{name: "too deep", src: strings.Repeat("(", 40) + "1" + strings.Repeat(")", 40), want: &Error{Pos: Position{Line: 1, Column: 33}, Msg: "script is nested more than 32 deep"}},
+		{name: "too many steps", src: strings.Repeat("1 + ", 600) + "1", want: &Error{Pos: Position{Line: 1, Column: 2399}, Msg: "script could take 1201 steps, the limit is 1000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.src, DefaultLimits)
+
+			var got *Error
+			if !errors.As(err, &got) {
+				t.Fatalf("Compile() error = %v, want an *Error", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compile() error = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScript_Run(t *testing.T) {
+	skuC, _ := sku.New('C')
+	basket := func(id sku.SKU) int {
+		if id == skuC {
+			return 2
+		}
+		return 0
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		env     Env
+		want    currency.Pence
+		wantErr string
+	}{
+		{name: "second one half price", src: "qty / 2 * unit / 2 + (qty - qty / 2) * unit", env: Env{Quantity: 3, UnitPrice: 50}, want: 125},
+		{name: "reads the basket", src: `if(basket("C") >= 2 && !(qty == 0), price - 10, price)`, env: Env{Quantity: 1, Price: 30, Basket: basket}, want: 20},
+		{name: "min and max", src: "max(min(price, 100), 10)", env: Env{Price: 250}, want: 100},
+		{name: "only the chosen branch runs", src: "if(qty > 0, price / qty, 0)", env: Env{Quantity: 0}, want: 0},
+		{name: "division by zero", src: "price / qty", env: Env{Price: 10}, wantErr: "1:7: division by zero"},
+		{name: "overflow", src: "price * 9223372036854775807", env: Env{Price: 2}, wantErr: `1:7: "*" overflowed`},
+		{name: "negative price", src: "price - 100", env: Env{Price: 10}, wantErr: "1:7: script worked out a negative price -90"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := Compile(tt.src, DefaultLimits)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			got, err := script.Run(tt.env)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Run() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Run() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScript_Run_timeout(t *testing.T) {
+	script, err := Compile(`basket("A") + basket("B")`, Limits{MaxSource: 100, MaxDepth: 10, MaxSteps: 10, Timeout: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	slow := func(sku.SKU) int {
+		time.Sleep(5 * time.Millisecond)
+		return 1
+	}
+
+	if _, err := script.Run(Env{Basket: slow}); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Run() error = %v, want %v", err, ErrTimeout)
+	}
+}
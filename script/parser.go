@@ -0,0 +1,458 @@
+package script
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// Position in a script, lines and columns start at 1
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Error is a problem with a script found when it's loaded or run
+type Error struct {
+	Pos Position
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+type valueType int
+
+const (
+	typeInt valueType = iota
+	typeBool
+)
+
+func (t valueType) String() string {
+	if t == typeBool {
+		return "true/false"
+	}
+	return "a number"
+}
+
+type nodeKind int
+
+const (
+	nodeNumber nodeKind = iota
+	nodeVariable
+	nodeUnary
+	nodeBinary
+	nodeCall
+)
+
+// node of the parsed script, only the fields for its kind are set
+type node struct {
+	kind nodeKind
+	pos  Position
+	typ  valueType
+
+	value int64
+	// variable, operator or function name
+	name string
+	args []*node
+	// the sku given to basket()
+	sku sku.SKU
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenString
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  Position
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEOF:
+		return "the end of the script"
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// operators made of two characters are checked before single ones
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "<", ">", "!", "(", ")", ","}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	pos := Position{Line: 1, Column: 1}
+
+	advance := func(n int) {
+		for _, r := range src[:n] {
+			if r == '\n' {
+				pos.Line++
+				pos.Column = 1
+			} else {
+				pos.Column++
+			}
+		}
+		src = src[n:]
+	}
+
+	take := func(match func(rune) bool) int {
+		n := 0
+		for n < len(src) {
+			r, size := utf8.DecodeRuneInString(src[n:])
+			if !match(r) {
+				break
+			}
+			n += size
+		}
+		return n
+	}
+
+next:
+	for len(src) > 0 {
+		r, size := utf8.DecodeRuneInString(src)
+		start := pos
+
+		switch {
+		case unicode.IsSpace(r):
+			advance(size)
+		case r == '#':
+			advance(take(func(r rune) bool { return r != '\n' }))
+		case unicode.IsDigit(r):
+			n := take(unicode.IsDigit)
+			tokens = append(tokens, token{kind: tokenNumber, text: src[:n], pos: start})
+			advance(n)
+		case unicode.IsLetter(r) || r == '_':
+			n := take(func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' })
+			tokens = append(tokens, token{kind: tokenIdent, text: src[:n], pos: start})
+			advance(n)
+		case r == '"':
+			end := strings.IndexAny(src[1:], "\"\n")
+			if end == -1 || src[1+end] != '"' {
+				return nil, &Error{Pos: start, Msg: "string is missing its closing quote"}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: src[1 : 1+end], pos: start})
+			advance(end + 2)
+		default:
+			for _, op := range operators {
+				if len(src) >= len(op) && src[:len(op)] == op {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, pos: start})
+					advance(len(op))
+					continue next
+				}
+			}
+			return nil, &Error{Pos: start, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: pos}), nil
+}
+
+// variables a script can read, see Env
+var variables = map[string]bool{"qty": true, "unit": true, "price": true}
+
+type parser struct {
+	tokens   []token
+	next     int
+	depth    int
+	maxDepth int
+	nodes    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *parser) isOperator(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if t := p.advance(); t.kind != tokenOperator || t.text != op {
+		return &Error{Pos: t.pos, Msg: fmt.Sprintf("expected %q, found %s", op, t.describe())}
+	}
+	return nil
+}
+
+func (p *parser) newNode(n *node) *node {
+	p.nodes++
+	return n
+}
+
+// parse reads a whole script, the result is type checked and must be a number
+func parse(src string, limits Limits) (*node, int, error) {
+	if len(src) > limits.MaxSource {
+		return nil, 0, &Error{Pos: Position{Line: 1, Column: 1}, Msg: fmt.Sprintf("script is %d bytes, the limit is %d", len(src), limits.MaxSource)}
+	}
+
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	p := &parser{tokens: tokens, maxDepth: limits.MaxDepth}
+	root, err := p.expression(0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, 0, &Error{Pos: t.pos, Msg: fmt.Sprintf("expected an operator or the end of the script, found %s", t.describe())}
+	}
+
+	if root.typ != typeInt {
+		return nil, 0, &Error{Pos: root.pos, Msg: "a script must work out a price, not true/false"}
+	}
+
+	return root, p.nodes, nil
+}
+
+// binary operators from the loosest to the tightest binding
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) expression(level int) (*node, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+
+	x, err := p.expression(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator(precedence[level]...) {
+		op := p.advance()
+
+		y, err := p.expression(level + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		n := p.newNode(&node{kind: nodeBinary, pos: op.pos, name: op.text, args: []*node{x, y}})
+		if err := check(n); err != nil {
+			return nil, err
+		}
+		x = n
+
+		// comparisons don't chain, "1 < qty < 3" doesn't mean what it looks like
+		if level == 2 && p.isOperator(precedence[level]...) {
+			t := p.peek()
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("comparisons can't be chained, use && instead of %q", t.text)}
+		}
+	}
+
+	return x, nil
+}
+
+func (p *parser) unary() (*node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > p.maxDepth {
+		return nil, &Error{Pos: p.peek().pos, Msg: fmt.Sprintf("script is nested more than %d deep", p.maxDepth)}
+	}
+
+	if p.isOperator("-", "!") {
+		op := p.advance()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		n := p.newNode(&node{kind: nodeUnary, pos: op.pos, name: op.text, args: []*node{x}})
+		return n, check(n)
+	}
+
+	return p.primary()
+}
+
+func (p *parser) primary() (*node, error) {
+	t := p.advance()
+
+	switch t.kind {
+	case tokenNumber:
+		value, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("number %s is too big", t.text)}
+		}
+		return p.newNode(&node{kind: nodeNumber, pos: t.pos, value: value}), nil
+
+	case tokenIdent:
+		if p.isOperator("(") {
+			return p.call(t)
+		}
+		if t.text == "true" || t.text == "false" {
+			value := int64(0)
+			if t.text == "true" {
+				value = 1
+			}
+			return p.newNode(&node{kind: nodeNumber, pos: t.pos, typ: typeBool, value: value}), nil
+		}
+		if !variables[t.text] {
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unknown variable %q, scripts can use qty, unit and price", t.text)}
+		}
+		return p.newNode(&node{kind: nodeVariable, pos: t.pos, name: t.text}), nil
+
+	case tokenOperator:
+		if t.text == "(" {
+			x, err := p.expression(0)
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		}
+	}
+
+	return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("expected a number, variable or function, found %s", t.describe())}
+}
+
+func (p *parser) call(name token) (*node, error) {
+	p.advance()
+
+	n := p.newNode(&node{kind: nodeCall, pos: name.pos, name: name.text})
+
+	if name.text == "basket" {
+		arg := p.advance()
+		if arg.kind != tokenString {
+			return nil, &Error{Pos: arg.pos, Msg: fmt.Sprintf(`basket() takes a sku in quotes e.g. basket("A"), found %s`, arg.describe())}
+		}
+		runes := []rune(arg.text)
+		if len(runes) != 1 {
+			return nil, &Error{Pos: arg.pos, Msg: fmt.Sprintf("invalid sku %q: %v", arg.text, sku.ErrInvalidLength)}
+		}
+		id, err := sku.New(runes[0])
+		if err != nil {
+			return nil, &Error{Pos: arg.pos, Msg: fmt.Sprintf("invalid sku %q: %v", arg.text, err)}
+		}
+		n.sku = id
+		return n, p.expect(")")
+	}
+
+	if !p.isOperator(")") {
+		for {
+			arg, err := p.expression(0)
+			if err != nil {
+				return nil, err
+			}
+			n.args = append(n.args, arg)
+
+			if !p.isOperator(",") {
+				break
+			}
+			p.advance()
+		}
+	}
+
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return n, check(n)
+}
+
+func typeError(n *node, want valueType, what string) error {
+	return &Error{Pos: n.pos, Msg: fmt.Sprintf("%s needs %s", what, want)}
+}
+
+// check works out the type of a node from its arguments, mixing up numbers and true/false is an error
+func check(n *node) error {
+	want := func(arg *node, typ valueType, what string) error {
+		if arg.typ != typ {
+			return typeError(arg, typ, what)
+		}
+		return nil
+	}
+
+	switch n.kind {
+	case nodeUnary:
+		if n.name == "!" {
+			n.typ = typeBool
+			return want(n.args[0], typeBool, `"!"`)
+		}
+		return want(n.args[0], typeInt, `"-"`)
+
+	case nodeBinary:
+		x, y := n.args[0], n.args[1]
+		what := fmt.Sprintf("%q", n.name)
+		switch n.name {
+		case "&&", "||":
+			n.typ = typeBool
+			if err := want(x, typeBool, what); err != nil {
+				return err
+			}
+			return want(y, typeBool, what)
+		case "==", "!=":
+			n.typ = typeBool
+			return want(y, x.typ, what)
+		case "<", "<=", ">", ">=":
+			n.typ = typeBool
+		}
+		if err := want(x, typeInt, what); err != nil {
+			return err
+		}
+		return want(y, typeInt, what)
+
+	case nodeCall:
+		switch n.name {
+		case "min", "max":
+			if len(n.args) == 0 {
+				return &Error{Pos: n.pos, Msg: fmt.Sprintf("%s() needs at least one number", n.name)}
+			}
+			for _, arg := range n.args {
+				if err := want(arg, typeInt, n.name+"()"); err != nil {
+					return err
+				}
+			}
+			return nil
+		case "if":
+			if len(n.args) != 3 {
+				return &Error{Pos: n.pos, Msg: fmt.Sprintf("if() takes a condition and two values, found %d arguments", len(n.args))}
+			}
+			if err := want(n.args[0], typeBool, "the condition of if()"); err != nil {
+				return err
+			}
+			n.typ = n.args[1].typ
+			return want(n.args[2], n.typ, "both values of if()")
+		default:
+			return &Error{Pos: n.pos, Msg: fmt.Sprintf("unknown function %q, scripts can use min, max, if and basket", n.name)}
+		}
+	}
+
+	return nil
+}
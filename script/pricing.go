@@ -0,0 +1,157 @@
+package script
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+// PricingRules is the normal pricing scripts sit on top of, it matches checkout.PricingRules
+type PricingRules interface {
+	GetPrice(sku sku.SKU, quantity quantity.Quantity) currency.Pence
+	PriceExists(sku sku.SKU) bool
+}
+
+// Pricing prices skus with a script using the normal pricing for everything else.
+// If a script fails when it runs the line falls back to its normal price and the error is logged.
+// The basket and whether the customer is a member come in with each call (see pricing.ContextPricer), so scripts always
+// see the basket being priced and member prices are passed on to the normal pricing.
+type Pricing struct {
+	rules   PricingRules
+	scripts map[sku.SKU]*Script
+	logger  *slog.Logger
+}
+
+type Option func(*Pricing)
+
+// WithLogger logs scripts which fail when they run
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Pricing) {
+		p.logger = logger
+	}
+}
+
+// NewPricing puts the scripts in front of the normal pricing
+func NewPricing(rules PricingRules, scripts map[sku.SKU]*Script, opts ...Option) *Pricing {
+	p := &Pricing{rules: rules, scripts: scripts, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// GetPrice prices a line without knowing the rest of the transaction, basket() is zero for every sku
+func (p *Pricing) GetPrice(id sku.SKU, qty quantity.Quantity) currency.Pence {
+	return p.GetPriceIn(pricing.Context{}, id, qty)
+}
+
+// GetPriceIn prices a line, scripts read the basket from the context
+func (p *Pricing) GetPriceIn(ctx pricing.Context, id sku.SKU, qty quantity.Quantity) currency.Pence {
+	if p == nil {
+		return 0
+	}
+
+	price := pricing.PriceIn(p.rules, ctx, id, qty)
+
+	script, exists := p.scripts[id]
+	if !exists {
+		return price
+	}
+
+	scripted, err := script.Run(Env{
+		SKU:       id,
+		Quantity:  qty.Value(),
+		UnitPrice: pricing.PriceIn(p.rules, ctx, id, *quantity.New(1)),
+		Price:     price,
+		Basket:    ctx.Quantity,
+	})
+	if err != nil {
+		p.logger.Warn("pricing script failed, using the normal price", slog.String("sku", id.String()), slog.Any("error", err))
+		return price
+	}
+
+	return scripted
+}
+
+// PriceExists only looks at the normal pricing, scripts change the price of skus which are already sold
+func (p *Pricing) PriceExists(id sku.SKU) bool {
+	if p == nil {
+		return false
+	}
+	return p.rules.PriceExists(id)
+}
+
+// OffersAppliedIn passes through to the normal pricing, scripted skus don't have offers it knows about
+func (p *Pricing) OffersAppliedIn(ctx pricing.Context, id sku.SKU, qty quantity.Quantity) int {
+	if p == nil {
+		return 0
+	}
+	if _, scripted := p.scripts[id]; scripted {
+		return 0
+	}
+	offers, _ := pricing.OffersAppliedIn(p.rules, ctx, id, qty)
+	return offers
+}
+
+// the json scripts file
+type scriptFile struct {
+	Scripts []scriptEntry `json:"scripts"`
+}
+
+type scriptEntry struct {
+	SKU    sku.SKU `json:"sku"`
+	Script string  `json:"script"`
+}
+
+// Load reads a json file of scripts and compiles every one, all the scripts which don't compile are reported together e.g.
+//
+//	{"scripts": [{"sku": "A", "script": "qty / 2 * unit / 2 + (qty - qty / 2) * unit"}]}
+func Load(r io.Reader, limits Limits) (map[sku.SKU]*Script, error) {
+	var file scriptFile
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to read scripts: %w", err)
+	}
+
+	scripts := make(map[sku.SKU]*Script, len(file.Scripts))
+	var errs []error
+	for i, entry := range file.Scripts {
+		if _, exists := scripts[entry.SKU]; exists {
+			errs = append(errs, fmt.Errorf("script %d: sku %s is listed more than once", i+1, entry.SKU))
+			continue
+		}
+
+		script, err := Compile(entry.Script, limits)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("script %d: sku %s: %w", i+1, entry.SKU, err))
+			continue
+		}
+		scripts[entry.SKU] = script
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return scripts, nil
+}
+
+// LoadFile reads a json file of scripts from disk
+func LoadFile(path string, limits Limits) (map[sku.SKU]*Script, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f, limits)
+}
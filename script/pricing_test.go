@@ -0,0 +1,98 @@
+package script
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Joshswooft/thinkmoney-test/currency"
+	"github.com/Joshswooft/thinkmoney-test/pricing"
+	"github.com/Joshswooft/thinkmoney-test/quantity"
+	"github.com/Joshswooft/thinkmoney-test/sku"
+)
+
+func TestPricing(t *testing.T) {
+	scripts, err := LoadFile("testdata/scripts.json", DefaultLimits)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	skuA, _ := sku.New('A')
+	skuC, _ := sku.New('C')
+	skuD, _ := sku.New('D')
+
+	rules := &pricing.SpecialPricing{Config: map[sku.SKU]pricing.PricingData{
+		skuA: {UnitPrice: 50},
+		skuC: {UnitPrice: 20},
+		skuD: {UnitPrice: 15, MemberPrice: 12},
+	}}
+
+	p := NewPricing(rules, scripts)
+
+	price := func(ctx pricing.Context, id sku.SKU, qty int) currency.Pence {
+		return p.GetPriceIn(ctx, id, *quantity.New(qty))
+	}
+
+	empty := pricing.Context{}
+	withC := pricing.Context{Basket: map[sku.SKU]quantity.Quantity{skuC: *quantity.New(1)}}
+
+	if got := price(empty, skuA, 2); got != 75 {
+		t.Errorf("GetPriceIn(A, 2) = %d, want %d", got, 75)
+	}
+	if got := price(empty, skuD, 2); got != 30 {
+		t.Errorf("GetPriceIn(D, 2) without a C = %d, want %d", got, 30)
+	}
+	if got := price(withC, skuD, 2); got != 20 {
+		t.Errorf("GetPriceIn(D, 2) with a C = %d, want %d", got, 20)
+	}
+	if got := price(withC, skuC, 1); got != 20 {
+		t.Errorf("GetPriceIn(C, 1) without a script = %d, want %d", got, 20)
+	}
+
+	// member prices come from the normal pricing and the script runs on top of them
+	withC.Member = true
+	if got := price(withC, skuD, 2); got != 14 {
+		t.Errorf("GetPriceIn(D, 2) for a member with a C = %d, want %d", got, 14)
+	}
+
+	// without a context the basket is empty
+	if got := p.GetPrice(skuD, *quantity.New(2)); got != 30 {
+		t.Errorf("GetPrice(D, 2) = %d, want %d", got, 30)
+	}
+}
+
+func TestPricing_fallsBackWhenScriptFails(t *testing.T) {
+	skuA, _ := sku.New('A')
+	script, err := Compile("price / (qty - 1)", DefaultLimits)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	var logs bytes.Buffer
+	rules := &pricing.SpecialPricing{Config: map[sku.SKU]pricing.PricingData{skuA: {UnitPrice: 50}}}
+	p := NewPricing(rules, map[sku.SKU]*Script{skuA: script}, WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+	if got := p.GetPrice(skuA, *quantity.New(1)); got != 50 {
+		t.Errorf("GetPrice() = %d, want the normal price %d", got, 50)
+	}
+	if !strings.Contains(logs.String(), "division by zero") {
+		t.Errorf("expected the failure to be logged, got %q", logs.String())
+	}
+}
+
+func TestLoad_errors(t *testing.T) {
+	input := `{"scripts": [{"sku": "A", "script": "qty *"}, {"sku": "B", "script": "price"}, {"sku": "C", "script": "cost"}]}`
+
+	_, err := Load(strings.NewReader(input), DefaultLimits)
+	if err == nil {
+		t.Fatal("Load() expected an error")
+	}
+
+	// every bad script is reported, not just the first
+	for _, want := range []string{"script 1: sku A: 1:6: expected a number", `script 3: sku C: 1:1: unknown variable "cost"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v, want it to contain %q", err, want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 var (
 	ErrNoSpecialCharacters = errors.New("a SKU must not contain any special characters")
+	ErrInvalidLength       = errors.New("a SKU must be a single character")
 )
 
 // a SKU known as Stock Keeping Unit is a unique identifier for a product
@@ -39,3 +40,24 @@ func validate(r rune) error {
 	}
 	return nil
 }
+
+// MarshalText lets skus be used in json, including as map keys
+func (s SKU) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText validates the sku the same way as New()
+func (s *SKU) UnmarshalText(text []byte) error {
+	runes := []rune(string(text))
+	if len(runes) != 1 {
+		return ErrInvalidLength
+	}
+
+	parsed, err := New(runes[0])
+	if err != nil {
+		return err
+	}
+
+	*s = parsed
+	return nil
+}
@@ -49,3 +49,37 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestSKU_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    SKU
+		wantErr error
+	}{
+		{name: "parses a single letter", text: "a", want: SKU{value: 'A'}},
+		{name: "rejects an empty sku", text: "", wantErr: ErrInvalidLength},
+		{name: "rejects more than one character", text: "AB", wantErr: ErrInvalidLength},
+		{name: "rejects special characters", text: "#", wantErr: ErrNoSpecialCharacters},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got SKU
+			err := got.UnmarshalText([]byte(tt.text))
+			if err != tt.wantErr {
+				t.Errorf("SKU.UnmarshalText() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SKU.UnmarshalText() = %v, want %v", got, tt.want)
+			}
+
+			if err == nil {
+				text, _ := got.MarshalText()
+				if string(text) != got.String() {
+					t.Errorf("SKU.MarshalText() = %s, want %s", text, got)
+				}
+			}
+		})
+	}
+}